@@ -1,19 +1,25 @@
 package main
 
 import (
-	"dididaren/docs"
 	"dididaren/internal/handler"
-	"dididaren/internal/middleware"
+	"dididaren/internal/migrations"
 	"dididaren/internal/repository"
+	"dididaren/internal/router"
 	"dididaren/internal/service"
+	"dididaren/pkg/auth"
 	"dididaren/pkg/config"
 	"dididaren/pkg/database"
+	"dididaren/pkg/logger"
+	"dididaren/pkg/migrate"
+	"dididaren/pkg/notify"
+	"dididaren/pkg/realtime"
+	"dididaren/pkg/storage"
+	"flag"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // @title          滴滴打人 API
@@ -38,11 +44,15 @@ import (
 // @description              Bearer token authentication
 
 func main() {
+	configPath := flag.String("config", "", "配置文件路径，默认使用环境变量 CONFIG_FILE 或 "+config.DefaultPath)
+	flag.Parse()
+
 	// 加载配置
-	cfg, err := config.Load()
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatalf("加载配置失败: %v", err)
 	}
+	gin.SetMode(cfg.Server.Mode)
 
 	// 初始化数据库连接
 	db, err := database.Init(cfg)
@@ -50,6 +60,26 @@ func main() {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
 
+	// 内存数据库每次启动都是空库，直接执行全部迁移
+	if database.InMemory(cfg.Database) {
+		if _, err := migrations.Up(db); err != nil {
+			log.Fatalf("执行数据库迁移失败: %v", err)
+		}
+	}
+
+	// 表结构由 cmd/migrate 维护，存在未执行的迁移时拒绝启动
+	migrator, err := migrate.New(db, migrations.All())
+	if err != nil {
+		log.Fatalf("加载数据库迁移失败: %v", err)
+	}
+	pending, err := migrator.Pending()
+	if err != nil {
+		log.Fatalf("检查数据库迁移失败: %v", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("存在 %d 个未执行的数据库迁移，请先执行 go run ./cmd/migrate up", len(pending))
+	}
+
 	// 初始化 repositories
 	userRepo := repository.NewUserRepository(db)
 	securityRepo := repository.NewSecurityRepository(db)
@@ -57,95 +87,119 @@ func main() {
 	dangerZoneRepo := repository.NewDangerZoneRepository(db)
 	systemConfigRepo := repository.NewSystemConfigRepository(db)
 	ratingRepo := repository.NewRatingRepository(db)
+	dispatchRepo := repository.NewDispatchRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	geofenceRepo := repository.NewGeofenceRepository(db)
+	contactRepo := repository.NewContactRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	verifyCodeRepo := repository.NewVerifyCodeRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	staffApplicationRepo := repository.NewStaffApplicationRepository(db)
+
+	appLogger, err := logger.New(cfg.Log)
+	if err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
+	}
+	hub := realtime.NewHub()
+	notifier := notify.New(cfg.Notify, appLogger)
+	fileStorage := storage.New(cfg.Storage)
+
+	// 初始化令牌签发，吊销名单随后从数据库加载
+	revocationService := service.NewRevocationService(tokenRepo, cfg.JWT.AccessTTL, appLogger)
+	tokenManager, err := auth.NewTokenManager(cfg.JWT, revocationService)
+	if err != nil {
+		log.Fatalf("初始化令牌签发失败: %v", err)
+	}
 
 	// 初始化 services
-	userService := service.NewUserService(userRepo)
-	securityService := service.NewSecurityService(securityRepo)
-	emergencyService := service.NewEmergencyService(emergencyRepo)
-	dangerZoneService := service.NewDangerZoneService(dangerZoneRepo)
 	systemConfigService := service.NewSystemConfigService(systemConfigRepo)
+	geoService := service.NewGeoService(dangerZoneRepo, securityRepo, appLogger)
+	emergencyStateMachine := service.NewEmergencyStateMachine(emergencyRepo, hub)
+	dispatchService := service.NewDispatchService(emergencyRepo, securityRepo, dispatchRepo, geoService, emergencyStateMachine, systemConfigService, hub, appLogger)
+	roleService := service.NewRoleService(roleRepo, userRepo, securityRepo, revocationService)
+	featureFlagService := service.NewFeatureFlagService(systemConfigService, userRepo, roleService)
+	sessionService := service.NewSessionService(tokenRepo, revocationService, appLogger)
+	authService := service.NewAuthService(userRepo, tokenRepo, tokenManager, revocationService, roleService)
+	verifyCodeService := service.NewVerifyCodeService(verifyCodeRepo, notifier, systemConfigService, appLogger)
+	loginGuardService := service.NewLoginGuardService(loginAttemptRepo, userRepo, systemConfigService, appLogger)
+	userService := service.NewUserService(userRepo, authService, verifyCodeService, loginGuardService)
+	securityService := service.NewSecurityService(securityRepo, locationRepo, geoService, emergencyStateMachine, dispatchService, hub)
+	notificationService := service.NewNotificationService(notificationRepo, contactRepo, userRepo, notifier, cfg.Notify, appLogger)
+	emergencyService := service.NewEmergencyService(emergencyRepo, securityRepo, emergencyStateMachine, dispatchService, notificationService, hub)
+	dangerZoneService := service.NewDangerZoneService(dangerZoneRepo, geoService)
 	ratingService := service.NewRatingService(ratingRepo)
+	heatService := service.NewHeatService(dangerZoneRepo, emergencyRepo, systemConfigService, appLogger)
+	heatmapService := service.NewHeatmapService(emergencyRepo, systemConfigService, appLogger)
+	geofenceService := service.NewGeofenceService(geofenceRepo, userRepo, geoService, systemConfigService, hub)
+	accountService := service.NewAccountService(accountRepo, userRepo, roleService, revocationService, geoService, fileStorage, appLogger)
+	staffApplicationService := service.NewStaffApplicationService(staffApplicationRepo, securityRepo, userRepo, fileStorage, notifier, revocationService, geoService, appLogger)
+	contactService := service.NewContactService(contactRepo, userRepo, verifyCodeService, systemConfigService, appLogger)
+
+	// 加载空间索引，并定期重建以兜底数据库中的直接变更
+	if err := geoService.Load(); err != nil {
+		log.Fatalf("加载空间索引失败: %v", err)
+	}
+	geoService.Start(5 * time.Minute)
+	if err := revocationService.Load(); err != nil {
+		log.Fatalf("加载会话吊销名单失败: %v", err)
+	}
+	revocationService.Start(time.Minute)
+	heatService.Start(time.Hour)
+	verifyCodeService.Start(time.Hour)
+	loginGuardService.Start(time.Hour)
+
+	// 恢复重启前未完成的派单和通知
+	if err := dispatchService.Resume(); err != nil {
+		log.Fatalf("恢复派单失败: %v", err)
+	}
+	if err := notificationService.Resume(); err != nil {
+		log.Fatalf("恢复紧急联系人通知失败: %v", err)
+	}
 
 	// 初始化 handlers
 	userHandler := handler.NewUserHandler(userService)
+	authHandler := handler.NewAuthHandler(authService)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	contactHandler := handler.NewContactHandler(contactService)
 	securityHandler := handler.NewSecurityHandler(securityService)
+	staffApplicationHandler := handler.NewStaffApplicationHandler(staffApplicationService)
 	emergencyHandler := handler.NewEmergencyHandler(emergencyService)
 	dangerZoneHandler := handler.NewDangerZoneHandler(dangerZoneService)
 	systemConfigHandler := handler.NewSystemConfigHandler(systemConfigService)
+	featureFlagHandler := handler.NewFeatureFlagHandler(featureFlagService)
 	ratingHandler := handler.NewRatingHandler(ratingService)
+	streamTicketService := service.NewStreamTicketService()
+	realtimeHandler := handler.NewRealtimeHandler(hub, emergencyService, streamTicketService, cfg.Server.AllowedOrigins)
+	heatmapHandler := handler.NewHeatmapHandler(heatmapService)
+	geofenceHandler := handler.NewGeofenceHandler(geofenceService)
 
 	// 初始化路由
-	r := gin.Default()
-
-	// 配置 swagger
-	docs.SwaggerInfo.BasePath = "/api/v1"
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// API 路由组
-	api := r.Group("/api/v1")
-	{
-		// 用户相关路由
-		api.POST("/users/register", userHandler.Register)
-		api.POST("/users/login", userHandler.Login)
-
-		// 需要认证的路由组
-		auth := api.Group("/", middleware.Auth())
-		{
-			// 用户相关
-			auth.GET("/users/info", userHandler.GetUserInfo)
-			auth.PUT("/users/info", userHandler.UpdateUserInfo)
-			auth.PUT("/users/password", userHandler.UpdatePassword)
-
-			// 安保人员相关
-			auth.POST("/security/staff", securityHandler.CreateStaff)
-			auth.GET("/security/staff/:id", securityHandler.GetStaff)
-			auth.GET("/security/staff", securityHandler.ListStaffs)
-			auth.PUT("/security/staff/:id/status", securityHandler.UpdateStaffStatus)
-			auth.POST("/security/ratings", securityHandler.CreateRating)
-			auth.GET("/security/ratings", securityHandler.ListRatings)
-			auth.PUT("/security/staff/location", securityHandler.UpdateLocation)
-			auth.GET("/security/staff/info", securityHandler.GetStaffInfo)
-			auth.POST("/security/staff/apply", securityHandler.ApplySecurityStaff)
-			auth.POST("/security/staff/accept-event", securityHandler.AcceptEvent)
-			auth.POST("/security/staff/complete-event", securityHandler.CompleteEvent)
-
-			// 紧急事件相关
-			auth.POST("/emergency", emergencyHandler.Create)
-			auth.GET("/emergency/:id", emergencyHandler.GetByID)
-			auth.GET("/emergency", emergencyHandler.List)
-			auth.PUT("/emergency/:id", emergencyHandler.Update)
-			auth.DELETE("/emergency/:id", emergencyHandler.Delete)
-			auth.POST("/emergency/:id/handling", emergencyHandler.CreateHandlingRecord)
-			auth.GET("/emergency/:id/handling", emergencyHandler.ListHandlingRecords)
-			auth.PUT("/emergency/:id/status", emergencyHandler.UpdateStatus)
-
-			// 危险区域相关
-			auth.POST("/danger-zones", dangerZoneHandler.Create)
-			auth.GET("/danger-zones/:id", dangerZoneHandler.GetByID)
-			auth.GET("/danger-zones", dangerZoneHandler.List)
-			auth.PUT("/danger-zones/:id", dangerZoneHandler.Update)
-			auth.DELETE("/danger-zones/:id", dangerZoneHandler.Delete)
-			auth.GET("/danger-zones/nearby", dangerZoneHandler.GetNearbyZones)
-			auth.GET("/danger-zones/active", dangerZoneHandler.GetAllActiveZones)
-			auth.PUT("/danger-zones/:id/heat-level", dangerZoneHandler.UpdateHeatLevel)
-
-			// 系统配置相关
-			auth.POST("/system/configs", systemConfigHandler.Create)
-			auth.GET("/system/configs/:id", systemConfigHandler.GetByID)
-			auth.GET("/system/configs", systemConfigHandler.List)
-			auth.PUT("/system/configs/:id", systemConfigHandler.Update)
-			auth.DELETE("/system/configs/:id", systemConfigHandler.Delete)
-			auth.GET("/system/configs/:key/value", systemConfigHandler.GetValue)
-			auth.PUT("/system/configs/:key/value", systemConfigHandler.UpdateValue)
-
-			// 评价相关
-			auth.POST("/ratings", ratingHandler.CreateRating)
-			auth.GET("/ratings/:id", ratingHandler.GetRating)
-			auth.GET("/ratings", ratingHandler.ListRatings)
-			auth.PUT("/ratings/:id", ratingHandler.UpdateRating)
-			auth.DELETE("/ratings/:id", ratingHandler.DeleteRating)
-		}
-	}
+	r := router.SetupRouter(
+		tokenManager,
+		sessionService,
+		streamTicketService,
+		userHandler,
+		authHandler,
+		sessionHandler,
+		roleHandler,
+		accountHandler,
+		contactHandler,
+		emergencyHandler,
+		securityHandler,
+		staffApplicationHandler,
+		dangerZoneHandler,
+		ratingHandler,
+		systemConfigHandler,
+		featureFlagHandler,
+		realtimeHandler,
+		heatmapHandler,
+		geofenceHandler,
+	)
 
 	// 启动服务器
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"dididaren/internal/migrations"
+	"dididaren/pkg/config"
+	"dididaren/pkg/database"
+	"dididaren/pkg/migrate"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+const usage = `用法：
+  migrate [-config 配置文件] up          执行所有未执行的迁移
+  migrate [-config 配置文件] down [n]    回滚最近执行的 n 个迁移，默认 1 个
+  migrate [-config 配置文件] status      查看迁移执行状态`
+
+func main() {
+	configPath := flag.String("config", "", "配置文件路径，默认使用环境变量 CONFIG_FILE 或 "+config.DefaultPath)
+	flag.Usage = func() { fmt.Println(usage) }
+	flag.Parse()
+	args := flag.Args()
+	if len(args) < 1 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+	if database.InMemory(cfg.Database) {
+		log.Fatal("内存数据库随进程退出销毁，迁移由 cmd/api 启动时自动执行")
+	}
+	db, err := database.Init(cfg)
+	if err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+	migrator, err := migrate.New(db, migrations.All())
+	if err != nil {
+		log.Fatalf("加载数据库迁移失败: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up()
+		for _, m := range done {
+			fmt.Printf("已执行 %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("没有需要执行的迁移")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("无效的回滚数量: %s", args[1])
+			}
+		}
+		done, err := migrator.Down(steps)
+		for _, m := range done {
+			fmt.Printf("已回滚 %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("没有可以回滚的迁移")
+		}
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range statuses {
+			state := "未执行"
+			if s.AppliedAt != nil {
+				state = "已执行 " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Unknown {
+				state += "（代码中不存在该迁移）"
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/service"
+	"dididaren/pkg/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ContactHandler struct {
+	service *service.ContactService
+}
+
+func NewContactHandler(service *service.ContactService) *ContactHandler {
+	return &ContactHandler{service: service}
+}
+
+// GetEmergencyContacts 获取当前用户的紧急联系人
+func (h *ContactHandler) GetEmergencyContacts(c *gin.Context) {
+	contacts, err := h.service.List(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": contacts})
+}
+
+// AddEmergencyContact 添加紧急联系人并发送邀请短信
+func (h *ContactHandler) AddEmergencyContact(c *gin.Context) {
+	var req model.CreateContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := h.service.Create(c.GetUint("user_id"), &req, c.ClientIP())
+	if err != nil {
+		writeContactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": contact, "message": inviteMessage(contact)})
+}
+
+// UpdateEmergencyContact 修改紧急联系人
+func (h *ContactHandler) UpdateEmergencyContact(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	var req model.UpdateContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := h.service.Update(c.GetUint("user_id"), uint(id), &req, c.ClientIP())
+	if err != nil {
+		writeContactError(c, err)
+		return
+	}
+
+	if contact.Status == model.ContactStatusPending {
+		c.JSON(http.StatusOK, gin.H{"data": contact, "message": inviteMessage(contact)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": contact})
+}
+
+// DeleteEmergencyContact 删除紧急联系人
+func (h *ContactHandler) DeleteEmergencyContact(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := h.service.Delete(c.GetUint("user_id"), uint(id)); err != nil {
+		writeContactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// SetDefaultEmergencyContact 设置默认紧急联系人
+func (h *ContactHandler) SetDefaultEmergencyContact(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	if err := h.service.SetDefault(c.GetUint("user_id"), uint(id)); err != nil {
+		writeContactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已设为默认紧急联系人"})
+}
+
+// InviteEmergencyContact 重新发送紧急联系人邀请短信
+func (h *ContactHandler) InviteEmergencyContact(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	ttl, err := h.service.Invite(c.GetUint("user_id"), uint(id), c.ClientIP())
+	if err != nil {
+		writeContactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邀请短信已发送", "expires_in": int(ttl.Seconds())})
+}
+
+// VerifyEmergencyContact 用联系人告知的验证码确认紧急联系人
+func (h *ContactHandler) VerifyEmergencyContact(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	var req model.VerifyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contact, err := h.service.Verify(c.GetUint("user_id"), uint(id), req.Code)
+	if err != nil {
+		writeContactError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": contact})
+}
+
+// inviteMessage 根据邀请短信是否发送成功给出提示
+func inviteMessage(contact *model.EmergencyContact) string {
+	if contact.InvitedAt == nil {
+		return "邀请短信发送失败，请稍后重新发送"
+	}
+	return "邀请短信已发送，请向联系人索取验证码完成确认"
+}
+
+// writeContactError 将紧急联系人相关错误映射为 HTTP 状态码
+func writeContactError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrContactNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.ErrContactExists, errors.ErrContactLimit, errors.ErrContactVerified:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.ErrVerifyCodeTooFrequent:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.ErrVerifyCodeSendFailed:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	case errors.ErrInvalidPhone, errors.ErrContactIsSelf, errors.ErrInvalidVerifyCode, errors.ErrVerifyCodeExpired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
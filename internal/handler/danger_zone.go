@@ -3,8 +3,10 @@ package handler
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/service"
+	"dididaren/pkg/geo"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -203,7 +205,7 @@ func (h *DangerZoneHandler) CheckLocation(c *gin.Context) {
 	})
 }
 
-// GetNearbyZones 获取附近的危险区域
+// GetNearbyZones 获取附近的危险区域，radius 单位为米，结果按距离排序并附带距离
 func (h *DangerZoneHandler) GetNearbyZones(c *gin.Context) {
 	latitude, err := strconv.ParseFloat(c.Query("latitude"), 64)
 	if err != nil {
@@ -218,7 +220,7 @@ func (h *DangerZoneHandler) GetNearbyZones(c *gin.Context) {
 	}
 
 	radius, err := strconv.ParseFloat(c.Query("radius"), 64)
-	if err != nil {
+	if err != nil || radius <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的半径"})
 		return
 	}
@@ -232,6 +234,62 @@ func (h *DangerZoneHandler) GetNearbyZones(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": zones})
 }
 
+// ExportGeoJSON 导出危险区域为 GeoJSON
+// @Summary 导出危险区域
+// @Description 将危险区域导出为 GeoJSON FeatureCollection，圆形区域导出为带 radius 属性的点，多边形区域导出为 Polygon/MultiPolygon
+// @Tags 危险区域
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param active query bool false "是否只导出活跃区域，默认false"
+// @Success 200 {object} geo.FeatureCollection
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/danger-zones/export [get]
+func (h *DangerZoneHandler) ExportGeoJSON(c *gin.Context) {
+	activeOnly, _ := strconv.ParseBool(c.DefaultQuery("active", "false"))
+
+	collection, err := h.service.ExportGeoJSON(activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=danger_zones.geojson")
+	c.Header("Content-Type", "application/geo+json")
+	c.JSON(http.StatusOK, collection)
+}
+
+// ImportGeoJSON 从 GeoJSON 批量导入危险区域
+// @Summary 导入危险区域
+// @Description 从 GeoJSON FeatureCollection 批量导入危险区域，任一要素不合法时整体失败。属性中带 id 的要素更新对应区域，否则新建
+// @Tags 危险区域
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param request body geo.FeatureCollection true "GeoJSON 要素集合"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/danger-zones/import [post]
+func (h *DangerZoneHandler) ImportGeoJSON(c *gin.Context) {
+	var collection geo.FeatureCollection
+	if err := c.ShouldBindJSON(&collection); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, updated, err := h.service.ImportGeoJSON(&collection)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "导入成功",
+		"created": created,
+		"updated": updated,
+	})
+}
+
 // GetAllActiveZones 获取所有活跃的危险区域
 func (h *DangerZoneHandler) GetAllActiveZones(c *gin.Context) {
 	zones, err := h.service.GetAllActiveZones()
@@ -264,3 +322,48 @@ func (h *DangerZoneHandler) UpdateHeatLevel(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
 }
+
+// GetHeatHistory 获取危险区域的热度历史
+// @Summary 获取危险区域热度历史
+// @Description 获取危险区域在时间范围内的热度变化记录（后台计算或手动设置），按时间排序
+// @Tags 危险区域
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param id path int true "危险区域ID"
+// @Param from query string false "开始时间（RFC3339），默认为结束时间前30天"
+// @Param to query string false "结束时间（RFC3339），默认为当前时间"
+// @Success 200 {array} model.DangerZoneHeatHistory
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/danger-zones/{id}/heat-history [get]
+func (h *DangerZoneHandler) GetHeatHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束时间"})
+			return
+		}
+	}
+	from := to.Add(-30 * 24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始时间"})
+			return
+		}
+	}
+
+	histories, err := h.service.ListHeatHistory(uint(id), from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": histories})
+}
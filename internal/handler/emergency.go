@@ -3,6 +3,8 @@ package handler
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/service"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
 	"net/http"
 	"strconv"
 
@@ -57,6 +59,7 @@ func (h *EmergencyHandler) Create(c *gin.Context) {
 // @Success 200 {object} model.Emergency
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/v1/emergencies/{id} [get]
 func (h *EmergencyHandler) GetByID(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -65,9 +68,9 @@ func (h *EmergencyHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	emergency, err := h.service.GetByID(uint(id))
+	emergency, err := h.service.GetByID(uint(id), c.GetUint("user_id"), c.GetStringSlice("roles"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeEmergencyError(c, err)
 		return
 	}
 
@@ -118,9 +121,9 @@ func (h *EmergencyHandler) Update(c *gin.Context) {
 		return
 	}
 
-	emergency, err := h.service.Update(uint(id), &req)
+	emergency, err := h.service.Update(uint(id), c.GetUint("user_id"), c.GetStringSlice("roles"), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeEmergencyError(c, err)
 		return
 	}
 
@@ -145,7 +148,7 @@ func (h *EmergencyHandler) Delete(c *gin.Context) {
 
 // CreateHandlingRecord 创建处理记录
 // @Summary 创建处理记录
-// @Description 为紧急事件创建一条处理记录
+// @Description 为紧急事件创建一条处理记录，只能为自己接单或报警的事件添加，拥有 emergency:manage 权限的用户不受限制
 // @Tags 紧急事件
 // @Accept json
 // @Produce json
@@ -155,6 +158,7 @@ func (h *EmergencyHandler) Delete(c *gin.Context) {
 // @Success 200 {object} model.HandlingRecord
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/v1/emergencies/{id}/records [post]
 func (h *EmergencyHandler) CreateHandlingRecord(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -169,9 +173,9 @@ func (h *EmergencyHandler) CreateHandlingRecord(c *gin.Context) {
 		return
 	}
 
-	record, err := h.service.CreateHandlingRecord(uint(id), &req)
+	record, err := h.service.CreateHandlingRecord(uint(id), c.GetUint("user_id"), c.GetStringSlice("roles"), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeEmergencyError(c, err)
 		return
 	}
 
@@ -189,6 +193,7 @@ func (h *EmergencyHandler) CreateHandlingRecord(c *gin.Context) {
 // @Success 200 {array} model.HandlingRecord
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/v1/emergencies/{id}/records [get]
 func (h *EmergencyHandler) ListHandlingRecords(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -197,16 +202,90 @@ func (h *EmergencyHandler) ListHandlingRecords(c *gin.Context) {
 		return
 	}
 
-	records, err := h.service.ListHandlingRecords(uint(id))
+	records, err := h.service.ListHandlingRecords(uint(id), c.GetUint("user_id"), c.GetStringSlice("roles"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeEmergencyError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, records)
 }
 
+// ListDispatchOffers 获取派单记录
+// @Summary 获取派单记录
+// @Description 获取紧急事件的所有派单记录，包括每轮的派单对象、距离和响应结果
+// @Tags 紧急事件
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param id path int true "紧急事件ID"
+// @Success 200 {array} model.DispatchOffer
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/emergency/{id}/offers [get]
+func (h *EmergencyHandler) ListDispatchOffers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	offers, err := h.service.ListDispatchOffers(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, offers)
+}
+
+// ListNotifications 获取紧急联系人通知记录
+// @Summary 获取紧急联系人通知记录
+// @Description 获取紧急事件发给紧急联系人的通知，包括渠道、发送次数和最终结果。没有查看敏感信息权限时接收方和内容中的手机号脱敏
+// @Tags 紧急事件
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param id path int true "紧急事件ID"
+// @Success 200 {array} model.NotificationDelivery
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/emergency/{id}/notifications [get]
+func (h *EmergencyHandler) ListNotifications(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	deliveries, err := h.service.ListNotifications(uint(id), c.GetUint("user_id"), c.GetStringSlice("roles"))
+	if err != nil {
+		writeEmergencyError(c, err)
+		return
+	}
+	if !auth.HasPermission(c.GetStringSlice("roles"), auth.PermSensitiveView) {
+		for i := range deliveries {
+			deliveries[i].MaskSensitive()
+		}
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
 // UpdateStatus 更新紧急事件状态
+// @Summary 更新紧急事件状态
+// @Description 按状态机流转紧急事件状态，非法流转返回错误。接单、出发、到达现场需由安保人员操作或人工指派，转人工的事件改回已派单会重新派单
+// @Tags 紧急事件
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param id path int true "紧急事件ID"
+// @Param request body model.UpdateEmergencyStatusRequest true "目标状态"
+// @Success 200 {object} model.Emergency
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/emergency/{id}/status [put]
 func (h *EmergencyHandler) UpdateStatus(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -220,10 +299,56 @@ func (h *EmergencyHandler) UpdateStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateStatus(uint(id), req.Status); err != nil {
+	emergency, err := h.service.UpdateStatus(uint(id), req.Status, req.Remark)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, emergency)
+}
+
+// AssignStaff 人工指派安保人员
+// @Summary 人工指派安保人员
+// @Description 为未接单或已转人工的紧急事件指派安保人员，事件进入已接单状态并停止派单
+// @Tags 紧急事件
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param id path int true "紧急事件ID"
+// @Param request body model.AssignStaffRequest true "安保人员"
+// @Success 200 {object} model.Emergency
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/emergency/{id}/assign [put]
+func (h *EmergencyHandler) AssignStaff(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	var req model.AssignStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "状态更新成功"})
+	emergency, err := h.service.AssignStaff(uint(id), req.StaffID)
+	if err != nil {
+		writeEmergencyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, emergency)
+}
+
+// writeEmergencyError 事件不存在或无权访问时返回 404，其余错误返回 400
+func writeEmergencyError(c *gin.Context, err error) {
+	if err == errors.ErrEventNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 }
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeatureFlagHandler struct {
+	service *service.FeatureFlagService
+}
+
+func NewFeatureFlagHandler(service *service.FeatureFlagService) *FeatureFlagHandler {
+	return &FeatureFlagHandler{service: service}
+}
+
+// List 获取当前用户的功能开关
+// 角色取自访问令牌，城市由客户端通过 city 参数上报，无法校验，cities 规则只能用于非敏感的灰度
+func (h *FeatureFlagHandler) List(c *gin.Context) {
+	flags, err := h.service.EvaluateAll(model.FeatureContext{
+		UserID: c.GetUint("user_id"),
+		Roles:  c.GetStringSlice("roles"),
+		City:   c.Query("city"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": flags})
+}
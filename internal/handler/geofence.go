@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/service"
+	"dididaren/pkg/errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GeofenceHandler struct {
+	service *service.GeofenceService
+}
+
+func NewGeofenceHandler(service *service.GeofenceService) *GeofenceHandler {
+	return &GeofenceHandler{service: service}
+}
+
+// UpdateLocationAlerts 开启或关闭危险区域提醒
+func (h *GeofenceHandler) UpdateLocationAlerts(c *gin.Context) {
+	var req model.UpdateLocationAlertsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	if err := h.service.SetLocationAlerts(userID, *req.Enabled); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
+// ReportLocation 上报用户位置，返回进出危险区域的情况和本次发出的提醒
+func (h *GeofenceHandler) ReportLocation(c *gin.Context) {
+	var req model.ReportLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	result, err := h.service.ReportLocation(userID, req.Latitude, req.Longitude)
+	if err != nil {
+		if err == errors.ErrLocationAlertsDisabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// GetStats 按危险区域统计进出和提醒次数
+func (h *GeofenceHandler) GetStats(c *gin.Context) {
+	var err error
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束时间"})
+			return
+		}
+	}
+	from := to.Add(-7 * 24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始时间"})
+			return
+		}
+	}
+
+	stats, err := h.service.GetStats(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HeatmapHandler struct {
+	service *service.HeatmapService
+}
+
+func NewHeatmapHandler(service *service.HeatmapService) *HeatmapHandler {
+	return &HeatmapHandler{service: service}
+}
+
+// GetHeatmap 获取紧急事件热力图
+// @Summary 获取紧急事件热力图
+// @Description 将范围和时间段内的紧急事件按 geohash 格子聚合，返回每个格子的事件数和按严重程度加权的权重，用于地图热力层
+// @Tags 紧急事件
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param min_lat query number true "最小纬度"
+// @Param max_lat query number true "最大纬度"
+// @Param min_lng query number true "最小经度"
+// @Param max_lng query number true "最大经度"
+// @Param from query string false "开始时间（RFC3339），默认为结束时间前30天"
+// @Param to query string false "结束时间（RFC3339），默认为当前时间"
+// @Param precision query int false "geohash 精度（1-8），默认按范围自动选择"
+// @Param type query string false "事件类型，多个用逗号分隔"
+// @Param status query string false "事件状态，多个用逗号分隔"
+// @Success 200 {object} model.Heatmap
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/emergency/heatmap [get]
+func (h *HeatmapHandler) GetHeatmap(c *gin.Context) {
+	var query model.HeatmapQuery
+	bounds := []struct {
+		name  string
+		value *float64
+	}{
+		{"min_lat", &query.MinLat},
+		{"max_lat", &query.MaxLat},
+		{"min_lng", &query.MinLng},
+		{"max_lng", &query.MaxLng},
+	}
+	for _, bound := range bounds {
+		value, err := strconv.ParseFloat(c.Query(bound.name), 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的范围：" + bound.name})
+			return
+		}
+		*bound.value = value
+	}
+
+	var err error
+	query.To = time.Now()
+	if value := c.Query("to"); value != "" {
+		if query.To, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束时间"})
+			return
+		}
+	}
+	query.From = query.To.Add(-30 * 24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		if query.From, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始时间"})
+			return
+		}
+	}
+
+	if value := c.Query("precision"); value != "" {
+		if query.Precision, err = strconv.Atoi(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的精度"})
+			return
+		}
+	}
+	if value := c.Query("type"); value != "" {
+		query.Types = strings.Split(value, ",")
+	}
+	if value := c.Query("status"); value != "" {
+		for _, item := range strings.Split(value, ",") {
+			status, err := strconv.Atoi(item)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的状态"})
+				return
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	heatmap, err := h.service.GetHeatmap(&query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": heatmap})
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/service"
+	"dididaren/pkg/errors"
 	"net/http"
 	"strconv"
 
@@ -36,6 +37,7 @@ func (h *RatingHandler) CreateRating(c *gin.Context) {
 		return
 	}
 
+	req.UserID = c.GetUint("user_id")
 	rating, err := h.service.CreateRating(&req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -113,6 +115,7 @@ func (h *RatingHandler) ListRatings(c *gin.Context) {
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/v1/ratings/{id} [put]
 func (h *RatingHandler) UpdateRating(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -127,9 +130,9 @@ func (h *RatingHandler) UpdateRating(c *gin.Context) {
 		return
 	}
 
-	err = h.service.UpdateRating(uint(id), &req)
+	err = h.service.UpdateRating(uint(id), c.GetUint("user_id"), c.GetStringSlice("roles"), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeRatingError(c, err)
 		return
 	}
 
@@ -147,6 +150,7 @@ func (h *RatingHandler) UpdateRating(c *gin.Context) {
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Router /api/v1/ratings/{id} [delete]
 func (h *RatingHandler) DeleteRating(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -155,11 +159,20 @@ func (h *RatingHandler) DeleteRating(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteRating(uint(id))
+	err = h.service.DeleteRating(uint(id), c.GetUint("user_id"), c.GetStringSlice("roles"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeRatingError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
+
+// writeRatingError 评价不存在或无权修改时返回 404，其余错误返回 400
+func writeRatingError(c *gin.Context, err error) {
+	if err == errors.ErrRatingNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"dididaren/internal/service"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/realtime"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// realtimeWriteTimeout 单次写入客户端的超时
+	realtimeWriteTimeout = 10 * time.Second
+	// realtimeHeartbeat 心跳间隔，用于保持连接并及时发现断开的客户端
+	realtimeHeartbeat = 30 * time.Second
+)
+
+type RealtimeHandler struct {
+	hub              *realtime.Hub
+	emergencyService *service.EmergencyService
+	tickets          *service.StreamTicketService
+	upgrader         websocket.Upgrader
+}
+
+// NewRealtimeHandler allowedOrigins 为允许建立 WebSocket 连接的网页来源，与服务同源的页面和非浏览器客户端始终允许
+func NewRealtimeHandler(hub *realtime.Hub, emergencyService *service.EmergencyService, tickets *service.StreamTicketService, allowedOrigins []string) *RealtimeHandler {
+	return &RealtimeHandler{
+		hub:              hub,
+		emergencyService: emergencyService,
+		tickets:          tickets,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     originChecker(allowedOrigins),
+		},
+	}
+}
+
+// originChecker 校验 WebSocket 握手的 Origin，防止其他网站借用户的浏览器建立连接
+func originChecker(allowedOrigins []string) func(r *http.Request) bool {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[strings.TrimSuffix(strings.ToLower(origin), "/")] = true
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		return allowed[strings.ToLower(origin)]
+	}
+}
+
+// IssueTicket 签发实时推送的连接凭证
+// @Summary 获取实时推送连接凭证
+// @Description 签发30秒内有效、只能使用一次的连接凭证，浏览器建立 WebSocket/SSE 连接时通过 ticket 查询参数携带
+// @Tags 实时推送
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/realtime/ticket [post]
+func (h *RealtimeHandler) IssueTicket(c *gin.Context) {
+	ticket, ttl, err := h.tickets.Issue(c.GetUint("user_id"), c.GetStringSlice("roles"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expires_in": int(ttl.Seconds())})
+}
+
+// WebSocket 建立实时推送的 WebSocket 连接
+// @Summary 实时推送（WebSocket）
+// @Description 订阅个人推送（派单）以及可选的紧急事件推送（状态变更、处理记录、安保人员位置）。浏览器通过 ticket 查询参数携带连接凭证，其他来源的网页需在 server.allowed_origins 中配置
+// @Tags 实时推送
+// @Param ticket query string false "连接凭证，未设置 Authorization 请求头时使用"
+// @Param emergency_id query int false "订阅的紧急事件ID"
+// @Success 101 {string} string "Switching Protocols"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/v1/realtime/ws [get]
+func (h *RealtimeHandler) WebSocket(c *gin.Context) {
+	sub, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// 客户端无需上行消息，读循环只用于感知连接关闭
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(realtimeHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(realtimeWriteTimeout))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// SSE 建立实时推送的 Server-Sent Events 连接，供不支持 WebSocket 的客户端使用
+// @Summary 实时推送（SSE）
+// @Description 与 WebSocket 推送内容相同，事件名为推送类型
+// @Tags 实时推送
+// @Produce text/event-stream
+// @Param ticket query string false "连接凭证，未设置 Authorization 请求头时使用"
+// @Param emergency_id query int false "订阅的紧急事件ID"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /api/v1/realtime/sse [get]
+func (h *RealtimeHandler) SSE(c *gin.Context) {
+	sub, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(realtimeHeartbeat)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-ticker.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// subscribe 根据请求订阅个人主题和可选的紧急事件主题，失败时写入错误响应
+func (h *RealtimeHandler) subscribe(c *gin.Context) (*realtime.Subscription, bool) {
+	userID := c.GetUint("user_id")
+	topics := []string{realtime.UserTopic(userID)}
+
+	if value := c.Query("emergency_id"); value != "" {
+		emergencyID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的事件ID"})
+			return nil, false
+		}
+
+		// 调度员和管理员可以订阅任意事件
+		allowed := auth.HasPermission(c.GetStringSlice("roles"), auth.PermEmergencyManage)
+		if !allowed {
+			allowed, err = h.emergencyService.CanSubscribe(uint(emergencyID), userID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return nil, false
+			}
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "无权订阅该事件"})
+			return nil, false
+		}
+		topics = append(topics, realtime.EmergencyTopic(uint(emergencyID)))
+	}
+
+	return h.hub.Subscribe(topics...), true
+}
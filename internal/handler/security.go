@@ -3,9 +3,12 @@ package handler
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/service"
+	"dididaren/pkg/auth"
 	"dididaren/pkg/response"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -54,7 +57,7 @@ func (h *SecurityHandler) CreateStaff(c *gin.Context) {
 
 // GetStaff godoc
 // @Summary      获取安保人员
-// @Description  根据ID获取安保人员详情
+// @Description  根据ID获取安保人员详情，没有查看敏感信息权限时手机号和身份证号脱敏
 // @Tags         安保人员
 // @Accept       json
 // @Produce      json
@@ -77,13 +80,16 @@ func (h *SecurityHandler) GetStaff(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !auth.HasPermission(c.GetStringSlice("roles"), auth.PermSensitiveView) {
+		staff.MaskSensitive()
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": staff})
 }
 
 // ListStaffs godoc
 // @Summary      获取安保人员列表
-// @Description  获取安保人员列表，支持分页和筛选
+// @Description  获取安保人员列表，支持分页和筛选，没有查看敏感信息权限时手机号和身份证号脱敏
 // @Tags         安保人员
 // @Accept       json
 // @Produce      json
@@ -115,6 +121,11 @@ func (h *SecurityHandler) ListStaffs(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !auth.HasPermission(c.GetStringSlice("roles"), auth.PermSensitiveView) {
+		for i := range staffs {
+			staffs[i].MaskSensitive()
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"data": gin.H{
@@ -124,43 +135,6 @@ func (h *SecurityHandler) ListStaffs(c *gin.Context) {
 	})
 }
 
-// UpdateStaffStatus godoc
-// @Summary      更新安保人员状态
-// @Description  更新安保人员的工作状态
-// @Tags         安保人员
-// @Accept       json
-// @Produce      json
-// @Security     BearerAuth
-// @Param        id     path      string  true  "安保人员ID"
-// @Param        status query     string  true  "状态"
-// @Success      200    {object}  map[string]interface{}
-// @Failure      400    {object}  map[string]interface{}
-// @Failure      401    {object}  map[string]interface{}
-// @Failure      404    {object}  map[string]interface{}
-// @Failure      500    {object}  map[string]interface{}
-// @Router       /security/staff/{id}/status [put]
-func (h *SecurityHandler) UpdateStaffStatus(c *gin.Context) {
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
-		return
-	}
-
-	status := c.Query("status")
-	if status == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "状态不能为空"})
-		return
-	}
-
-	err = h.service.UpdateStaffStatus(uint(id), status)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
-}
-
 // CreateRating godoc
 // @Summary      创建评价
 // @Description  为安保人员创建评价
@@ -222,12 +196,11 @@ func (h *SecurityHandler) ListRatings(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": ratings})
 }
 
-// ApplySecurityStaff 申请成为安保人员
-func (h *SecurityHandler) ApplySecurityStaff(c *gin.Context) {
+// UpdateLocation 更新位置
+func (h *SecurityHandler) UpdateLocation(c *gin.Context) {
 	var req struct {
-		Name   string `json:"name" binding:"required"`
-		Phone  string `json:"phone" binding:"required"`
-		IDCard string `json:"id_card" binding:"required"`
+		Lat float64 `json:"lat" binding:"required"`
+		Lng float64 `json:"lng" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "参数错误")
@@ -235,7 +208,7 @@ func (h *SecurityHandler) ApplySecurityStaff(c *gin.Context) {
 	}
 
 	userID := c.GetUint("user_id")
-	err := h.service.ApplySecurityStaff(userID, req.Name, req.Phone, req.IDCard)
+	err := h.service.UpdateLocation(userID, req.Lat, req.Lng)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -244,11 +217,97 @@ func (h *SecurityHandler) ApplySecurityStaff(c *gin.Context) {
 	response.Success(c, nil)
 }
 
-// UpdateLocation 更新位置
-func (h *SecurityHandler) UpdateLocation(c *gin.Context) {
+// maxNearbyStaffRadius 查询附近安保人员的最大半径（米），避免一次查出所有在线安保人员的位置
+const maxNearbyStaffRadius = 10000
+
+// GetNearbyStaff 获取附近的在线安保人员，radius 单位为米，结果按距离排序并附带距离。
+// 结果包含安保人员的实时位置，仅限调度人员调用
+func (h *SecurityHandler) GetNearbyStaff(c *gin.Context) {
+	latitude, err := strconv.ParseFloat(c.Query("latitude"), 64)
+	if err != nil {
+		response.BadRequest(c, "无效的纬度")
+		return
+	}
+
+	longitude, err := strconv.ParseFloat(c.Query("longitude"), 64)
+	if err != nil {
+		response.BadRequest(c, "无效的经度")
+		return
+	}
+
+	radius, err := strconv.ParseFloat(c.Query("radius"), 64)
+	if err != nil || radius <= 0 || radius > maxNearbyStaffRadius {
+		response.BadRequest(c, "无效的半径")
+		return
+	}
+
+	staff, err := h.service.GetNearbyStaff(latitude, longitude, radius)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, staff)
+}
+
+// AcceptEvent 接受事件
+func (h *SecurityHandler) AcceptEvent(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	err = h.service.AcceptEvent(userID, uint(eventID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// DeclineEvent 拒绝派单
+func (h *SecurityHandler) DeclineEvent(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	var req model.DeclineOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	err = h.service.DeclineEvent(userID, uint(eventID), req.Reason)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// ListPendingOffers 获取待响应的派单
+func (h *SecurityHandler) ListPendingOffers(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	offers, err := h.service.ListPendingOffers(userID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, offers)
+}
+
+// UpdateOnlineStatus 上线/下线
+func (h *SecurityHandler) UpdateOnlineStatus(c *gin.Context) {
 	var req struct {
-		Lat float64 `json:"lat" binding:"required"`
-		Lng float64 `json:"lng" binding:"required"`
+		IsOnline bool `json:"is_online"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "参数错误")
@@ -256,7 +315,7 @@ func (h *SecurityHandler) UpdateLocation(c *gin.Context) {
 	}
 
 	userID := c.GetUint("user_id")
-	err := h.service.UpdateLocation(userID, req.Lat, req.Lng)
+	err := h.service.UpdateOnlineStatus(userID, req.IsOnline)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -265,8 +324,8 @@ func (h *SecurityHandler) UpdateLocation(c *gin.Context) {
 	response.Success(c, nil)
 }
 
-// AcceptEvent 接受事件
-func (h *SecurityHandler) AcceptEvent(c *gin.Context) {
+// DepartEvent 出发前往现场
+func (h *SecurityHandler) DepartEvent(c *gin.Context) {
 	eventID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		response.BadRequest(c, "参数错误")
@@ -274,7 +333,25 @@ func (h *SecurityHandler) AcceptEvent(c *gin.Context) {
 	}
 
 	userID := c.GetUint("user_id")
-	err = h.service.AcceptEvent(userID, uint(eventID))
+	err = h.service.DepartEvent(userID, uint(eventID))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// ArriveEvent 到达现场
+func (h *SecurityHandler) ArriveEvent(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	err = h.service.ArriveEvent(userID, uint(eventID))
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -312,3 +389,100 @@ func (h *SecurityHandler) GetStaffInfo(c *gin.Context) {
 
 	response.Success(c, staff)
 }
+
+// GetStaffTrail godoc
+// @Summary      获取安保人员轨迹
+// @Description  获取安保人员在时间范围内的位置轨迹，可导出为 GPX 或 GeoJSON
+// @Tags         安保人员
+// @Produce      json
+// @Produce      application/gpx+xml
+// @Security     BearerAuth
+// @Param        id      path      uint    true   "安保人员ID"
+// @Param        from    query     string  false  "开始时间（RFC3339），默认为结束时间前24小时"
+// @Param        to      query     string  false  "结束时间（RFC3339），默认为当前时间"
+// @Param        format  query     string  false  "导出格式：json、gpx、geojson"  default(json)
+// @Success      200     {object}  map[string]interface{}
+// @Failure      400     {object}  map[string]interface{}
+// @Failure      401     {object}  map[string]interface{}
+// @Router       /security/staff/{id}/trail [get]
+func (h *SecurityHandler) GetStaffTrail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的结束时间"})
+			return
+		}
+	}
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的开始时间"})
+			return
+		}
+	}
+
+	locations, err := h.service.GetStaffTrail(uint(id), from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeTrail(c, fmt.Sprintf("staff-%d-trail", id), locations)
+}
+
+// GetEventTrail godoc
+// @Summary      获取事件处理轨迹
+// @Description  获取安保人员处理某事件期间的位置轨迹，用于纠纷复核和保险理赔，可导出为 GPX 或 GeoJSON
+// @Tags         安保人员
+// @Produce      json
+// @Produce      application/gpx+xml
+// @Security     BearerAuth
+// @Param        id      path      uint    true   "事件ID"
+// @Param        format  query     string  false  "导出格式：json、gpx、geojson"  default(json)
+// @Success      200     {object}  map[string]interface{}
+// @Failure      400     {object}  map[string]interface{}
+// @Failure      401     {object}  map[string]interface{}
+// @Router       /security/staff/events/{id}/trail [get]
+func (h *SecurityHandler) GetEventTrail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	locations, err := h.service.GetEventTrail(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeTrail(c, fmt.Sprintf("emergency-%d-trail", id), locations)
+}
+
+// writeTrail 按 format 参数输出轨迹，gpx 和 geojson 以附件形式下载
+func writeTrail(c *gin.Context, name string, locations []model.StaffLocation) {
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"data": locations})
+	case "gpx":
+		data, err := service.TrailToGPX(name, locations).Marshal()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.gpx", name))
+		c.Data(http.StatusOK, "application/gpx+xml", data)
+	case "geojson":
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.geojson", name))
+		c.Header("Content-Type", "application/geo+json")
+		c.JSON(http.StatusOK, service.TrailToGeoJSON(name, locations))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的导出格式"})
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"dididaren/internal/service"
+	"dididaren/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	service *service.SessionService
+}
+
+func NewSessionHandler(service *service.SessionService) *SessionHandler {
+	return &SessionHandler{service: service}
+}
+
+// ListSessions 获取当前用户的登录会话
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.List(c.GetUint("user_id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// RevokeSession 吊销当前用户的某个登录会话
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	err := h.service.Revoke(c.GetUint("user_id"), c.Param("id"))
+	if err != nil {
+		if err == errors.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已退出该设备"})
+}
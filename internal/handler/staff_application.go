@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/service"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StaffApplicationHandler struct {
+	service *service.StaffApplicationService
+}
+
+func NewStaffApplicationHandler(service *service.StaffApplicationService) *StaffApplicationHandler {
+	return &StaffApplicationHandler{service: service}
+}
+
+// ApplySecurityStaff 申请成为安保人员，被拒绝后可以修改资料重新申请
+func (h *StaffApplicationHandler) ApplySecurityStaff(c *gin.Context) {
+	var req model.ApplyStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	staff, err := h.service.Apply(c.GetUint("user_id"), &req)
+	if err != nil {
+		writeStaffApplicationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": staff})
+}
+
+// GetApplication 获取当前用户的申请详情，包括已上传的材料和审核记录
+func (h *StaffApplicationHandler) GetApplication(c *gin.Context) {
+	application, err := h.service.GetApplication(c.GetUint("user_id"))
+	if err != nil {
+		writeStaffApplicationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": application})
+}
+
+// UploadDocument 上传申请材料，表单字段 type 为材料类型，file 为文件
+func (h *StaffApplicationHandler) UploadDocument(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择要上传的文件"})
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer src.Close()
+
+	doc, err := h.service.UploadDocument(c.GetUint("user_id"), c.PostForm("type"), file.Filename, src)
+	if err != nil {
+		writeStaffApplicationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": doc})
+}
+
+// GetStaffApplication 审核人员查看申请详情，没有查看敏感信息权限时手机号和身份证号脱敏
+func (h *StaffApplicationHandler) GetStaffApplication(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	application, err := h.service.GetApplicationByStaffID(uint(id))
+	if err != nil {
+		writeStaffApplicationError(c, err)
+		return
+	}
+	if !auth.HasPermission(c.GetStringSlice("roles"), auth.PermSensitiveView) {
+		application.Staff.MaskSensitive()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": application})
+}
+
+// DownloadDocument 审核人员下载申请材料
+func (h *StaffApplicationHandler) DownloadDocument(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+	docID, err := strconv.ParseUint(c.Param("doc_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的材料ID"})
+		return
+	}
+
+	doc, file, err := h.service.OpenDocument(uint(id), uint(docID))
+	if err != nil {
+		writeStaffApplicationError(c, err)
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": doc.FileName}))
+	c.DataFromReader(http.StatusOK, doc.Size, doc.ContentType, file, nil)
+}
+
+// UpdateStaffStatus 审核通过、拒绝、停用或恢复安保人员，必须填写原因
+func (h *StaffApplicationHandler) UpdateStaffStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	var req model.UpdateStaffStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateStatus(uint(id), c.GetUint("user_id"), &req); err != nil {
+		writeStaffApplicationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
+// writeStaffApplicationError 将安保人员申请相关错误映射为 HTTP 状态码
+func writeStaffApplicationError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrStaffNotFound, errors.ErrStaffDocumentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.ErrStaffExists, errors.ErrStaffUnderReview, errors.ErrStaffStatusChanged,
+		errors.ErrStaffApplicationClosed, errors.ErrInvalidStaffStatus, errors.ErrStaffDocumentsMissing:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.ErrInvalidPhone, errors.ErrInvalidParameter, errors.ErrInvalidStaffDocument:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
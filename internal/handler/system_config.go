@@ -3,6 +3,7 @@ package handler
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/service"
+	"dididaren/pkg/errors"
 	"net/http"
 	"strconv"
 
@@ -36,9 +37,9 @@ func (h *SystemConfigHandler) Create(c *gin.Context) {
 		return
 	}
 
-	config, err := h.service.Create(&req)
+	config, err := h.service.Create(c.GetUint("user_id"), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeSystemConfigError(c, err)
 		return
 	}
 
@@ -137,9 +138,9 @@ func (h *SystemConfigHandler) Update(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Update(uint(id), &req)
+	err = h.service.Update(c.GetUint("user_id"), uint(id), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeSystemConfigError(c, err)
 		return
 	}
 
@@ -165,9 +166,9 @@ func (h *SystemConfigHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	err = h.service.Delete(uint(id))
+	err = h.service.Delete(c.GetUint("user_id"), uint(id))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeSystemConfigError(c, err)
 		return
 	}
 
@@ -175,8 +176,9 @@ func (h *SystemConfigHandler) Delete(c *gin.Context) {
 }
 
 // GetValue 获取配置值
+// 路由与 /system/configs/:id 共用通配符，这里的路径参数是配置键
 func (h *SystemConfigHandler) GetValue(c *gin.Context) {
-	key := c.Param("key")
+	key := c.Param("id")
 	if key == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "配置键不能为空"})
 		return
@@ -184,16 +186,16 @@ func (h *SystemConfigHandler) GetValue(c *gin.Context) {
 
 	value, err := h.service.GetValue(key)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeSystemConfigError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": gin.H{"value": value}})
 }
 
-// UpdateValue 更新配置值
+// UpdateValue 更新配置值，值必须符合配置声明的类型和取值范围
 func (h *SystemConfigHandler) UpdateValue(c *gin.Context) {
-	key := c.Param("key")
+	key := c.Param("id")
 	if key == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "配置键不能为空"})
 		return
@@ -207,10 +209,78 @@ func (h *SystemConfigHandler) UpdateValue(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.UpdateValue(key, req.Value); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.service.UpdateValue(c.GetUint("user_id"), key, req.Value); err != nil {
+		writeSystemConfigError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
 }
+
+// ListHistory 获取配置的变更记录
+// 路由与 /system/configs/:id 共用通配符，这里的路径参数是配置键
+func (h *SystemConfigHandler) ListHistory(c *gin.Context) {
+	key := c.Param("id")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "配置键不能为空"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的页码"})
+		return
+	}
+
+	size, err := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的每页数量"})
+		return
+	}
+
+	history, total, err := h.service.ListHistory(key, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  history,
+		"total": total,
+	})
+}
+
+// Rollback 将配置回滚到指定版本
+func (h *SystemConfigHandler) Rollback(c *gin.Context) {
+	key := c.Param("id")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "配置键不能为空"})
+		return
+	}
+
+	var req model.RollbackSystemConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	config, err := h.service.Rollback(c.GetUint("user_id"), key, req.Version)
+	if err != nil {
+		writeSystemConfigError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// writeSystemConfigError 将系统配置相关错误映射为 HTTP 状态码
+func writeSystemConfigError(c *gin.Context, err error) {
+	switch {
+	case err == errors.ErrConfigNotFound, err == errors.ErrConfigVersionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == errors.ErrConfigExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -3,7 +3,10 @@ package handler
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/service"
+	pkgerrors "dididaren/pkg/errors"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -41,13 +44,75 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.service.Login(&req)
+	tokens, err := h.service.Login(&req, clientInfo(c, req.DeviceName))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		writeLoginError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, tokens)
+}
+
+// SendCode 发送登录或注册验证码
+func (h *UserHandler) SendCode(c *gin.Context) {
+	var req model.SendCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ttl, err := h.service.SendCode(&req, c.ClientIP())
+	if err != nil {
+		switch err {
+		case pkgerrors.ErrVerifyCodeTooFrequent:
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case pkgerrors.ErrVerifyCodeSendFailed:
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "验证码已发送", "expires_in": int(ttl.Seconds())})
+}
+
+// VerifyCode 验证码登录
+func (h *UserHandler) VerifyCode(c *gin.Context) {
+	var req model.CodeLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.service.LoginByCode(&req, clientInfo(c, req.DeviceName))
+	if err != nil {
+		writeLoginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// UnlockUser 解除账号的登录锁定
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID"})
+		return
+	}
+
+	user, err := h.service.Unlock(uint(id))
+	if err != nil {
+		if err == pkgerrors.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "解锁成功", "data": user})
 }
 
 // GetUserInfo 获取用户信息
@@ -97,3 +162,12 @@ func (h *UserHandler) UpdatePassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "密码更新成功"})
 }
+
+// writeLoginError 登录失败的响应：锁定和限流返回 429，其余返回 401
+func writeLoginError(c *gin.Context, err error) {
+	if errors.Is(err, pkgerrors.ErrAccountLocked) || errors.Is(err, pkgerrors.ErrLoginTooFrequent) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
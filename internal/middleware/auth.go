@@ -1,16 +1,21 @@
 package middleware
 
 import (
-	"dididaren/pkg/response"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 )
 
+// SessionTracker 记录登录会话的最近活动
+type SessionTracker interface {
+	Touch(sessionID, ip string)
+}
+
 // Auth 认证中间件
-func Auth() gin.HandlerFunc {
+func Auth(tokens *auth.TokenManager, sessions SessionTracker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头中获取 token
 		authHeader := c.GetHeader("Authorization")
@@ -28,46 +33,84 @@ func Auth() gin.HandlerFunc {
 			return
 		}
 
-		// 验证 token
-		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-			// 这里应该使用配置中的密钥
-			return []byte("your-secret-key"), nil
-		})
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证信息"})
+		if !authenticate(c, tokens, sessions, parts[1]) {
 			c.Abort()
 			return
 		}
 
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "认证信息已过期"})
-			c.Abort()
+		c.Next()
+	}
+}
+
+// StreamTickets 兑换实时推送的一次性连接凭证
+type StreamTickets interface {
+	Redeem(ticket string) (userID uint, roles []string, sessionID string, ok bool)
+}
+
+// StreamAuth 实时推送连接的认证中间件。
+// 浏览器的 WebSocket/EventSource 无法设置请求头，通过 ticket 查询参数携带一次性连接凭证；
+// 能设置请求头的客户端也可以直接使用 Authorization 请求头。访问令牌不能放在查询参数中
+func StreamAuth(tokens *auth.TokenManager, sessions SessionTracker, tickets StreamTickets) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if !authenticate(c, tokens, sessions, strings.TrimPrefix(authHeader, "Bearer ")) {
+				c.Abort()
+				return
+			}
+			c.Next()
 			return
 		}
 
-		// 从 token 中获取用户信息
-		claims, ok := token.Claims.(jwt.MapClaims)
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供认证信息"})
+			c.Abort()
+			return
+		}
+		userID, roles, sessionID, ok := tickets.Redeem(ticket)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证信息"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "连接凭证无效或已过期"})
 			c.Abort()
 			return
 		}
 
-		// 将用户信息存储到上下文中
-		userID := uint(claims["user_id"].(float64))
 		c.Set("user_id", userID)
-
+		c.Set("roles", roles)
+		c.Set("session_id", sessionID)
+		sessions.Touch(sessionID, c.ClientIP())
 		c.Next()
 	}
 }
 
-// AdminAuth 管理员认证中间件
-func AdminAuth() gin.HandlerFunc {
+// authenticate 验证 token 并将用户信息写入上下文，失败时写入错误响应。
+// 所属会话已吊销的 token 由 TokenManager 拒绝
+func authenticate(c *gin.Context, tokens *auth.TokenManager, sessions SessionTracker, tokenString string) bool {
+	claims, err := tokens.ParseAccessToken(tokenString)
+	if err != nil {
+		switch err {
+		case errors.ErrTokenExpired:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "认证信息已过期"})
+		case errors.ErrTokenRevoked:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证信息"})
+		}
+		return false
+	}
+
+	// 将用户信息存储到上下文中
+	c.Set("user_id", claims.UserID)
+	c.Set("roles", claims.Roles)
+	c.Set("session_id", claims.SessionID)
+	sessions.Touch(claims.SessionID, c.ClientIP())
+	return true
+}
+
+// RequirePermission 权限中间件，须在 Auth 之后使用
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		isAdmin, exists := c.Get("is_admin")
-		if !exists || !isAdmin.(bool) {
-			response.Forbidden(c)
+		if !auth.HasPermission(c.GetStringSlice("roles"), permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": errors.ErrPermissionDenied.Error()})
 			c.Abort()
 			return
 		}
@@ -75,10 +118,14 @@ func AdminAuth() gin.HandlerFunc {
 	}
 }
 
-// Admin 管理员权限中间件
+// Admin 管理员权限中间件，须在 Auth 之后使用
 func Admin() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: 实现管理员权限验证
+		if !auth.HasRole(c.GetStringSlice("roles"), auth.RoleAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": errors.ErrPermissionDenied.Error()})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -2,11 +2,15 @@ package middleware
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// realtimePathPrefix 实时推送接口的路径前缀
+const realtimePathPrefix = "/api/v1/realtime/"
+
 // Logger 日志中间件
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -25,8 +29,11 @@ func Logger() gin.HandlerFunc {
 		// 请求方式
 		reqMethod := c.Request.Method
 
-		// 请求路由
-		reqUri := c.Request.RequestURI
+		// 请求路由，实时推送接口的查询参数中带有连接凭证，不记录
+		reqUri := c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" && !strings.HasPrefix(reqUri, realtimePathPrefix) {
+			reqUri += "?" + c.Request.URL.RawQuery
+		}
 
 		// 状态码
 		statusCode := c.Writer.Status()
@@ -0,0 +1,370 @@
+package migrations
+
+import (
+	"dididaren/pkg/migrate"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// baseline 引入版本迁移之前由 AutoMigrate 维护的表结构。
+// 这里的结构体是当时模型的快照，之后模型的变更通过新的迁移完成，不要修改这里。
+// 对已有数据库执行时只会补齐缺少的表和列，不会删除任何数据；不可回滚
+func baseline() migrate.Migration {
+	return migrate.Migration{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(
+				&v1User{},
+				&v1Session{},
+				&v1RefreshToken{},
+				&v1RevokedSession{},
+				&v1UserRole{},
+				&v1VerificationCode{},
+				&v1LoginAttempt{},
+				&v1Staff{},
+				&v1SecurityStaff{},
+				&v1StaffDocument{},
+				&v1StaffStatusHistory{},
+				&v1Emergency{},
+				&v1EmergencyContact{},
+				&v1NotificationDelivery{},
+				&v1HandlingRecord{},
+				&v1DangerZone{},
+				&v1DangerZoneHeatHistory{},
+				&v1Rating{},
+				&v1SystemConfig{},
+				&v1DispatchOffer{},
+				&v1StaffLocation{},
+				&v1GeofenceState{},
+				&v1GeofenceEvent{},
+			)
+		},
+	}
+}
+
+type v1User struct {
+	ID             uint   `gorm:"primaryKey"`
+	Phone          string `gorm:"uniqueIndex;not null"`
+	Password       string `gorm:"not null"`
+	Name           string
+	Avatar         string
+	IsAdmin        bool `gorm:"default:false"`
+	Status         int  `gorm:"default:1"`
+	LockedUntil    *time.Time
+	LocationAlerts bool `gorm:"default:false"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (v1User) TableName() string { return "users" }
+
+type v1Session struct {
+	ID         string `gorm:"primaryKey;size:32"`
+	UserID     uint   `gorm:"not null;index"`
+	DeviceName string `gorm:"size:100"`
+	IP         string `gorm:"size:64"`
+	UserAgent  string `gorm:"size:255"`
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+func (v1Session) TableName() string { return "sessions" }
+
+type v1RefreshToken struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	SessionID string `gorm:"size:32;not null;index"`
+	TokenHash string `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (v1RefreshToken) TableName() string { return "refresh_tokens" }
+
+type v1RevokedSession struct {
+	SessionID string    `gorm:"primaryKey;size:32"`
+	UserID    uint      `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time
+}
+
+func (v1RevokedSession) TableName() string { return "revoked_sessions" }
+
+type v1UserRole struct {
+	UserID    uint   `gorm:"primaryKey"`
+	Role      string `gorm:"primaryKey;size:32"`
+	GrantedBy uint
+	CreatedAt time.Time
+}
+
+func (v1UserRole) TableName() string { return "user_roles" }
+
+type v1VerificationCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	Phone     string `gorm:"size:20;not null;index:idx_verification_codes_phone"`
+	Purpose   string `gorm:"size:20;not null"`
+	CodeHash  string `gorm:"size:64;not null"`
+	IP        string `gorm:"size:64;index"`
+	Attempts  int    `gorm:"default:0"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"index:idx_verification_codes_phone"`
+}
+
+func (v1VerificationCode) TableName() string { return "verification_codes" }
+
+type v1LoginAttempt struct {
+	ID        uint   `gorm:"primaryKey"`
+	Phone     string `gorm:"size:20;index"`
+	IP        string `gorm:"size:64;index"`
+	Success   bool
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (v1LoginAttempt) TableName() string { return "login_attempts" }
+
+type v1Staff struct {
+	gorm.Model
+	UserID       uint    `gorm:"not null"`
+	Name         string  `gorm:"size:50;not null"`
+	Phone        string  `gorm:"size:20;not null"`
+	IDCard       string  `gorm:"size:18;not null"`
+	Status       string  `gorm:"size:20;not null;default:'pending'"`
+	ReviewReason string  `gorm:"size:255"`
+	Rating       float64 `gorm:"default:0"`
+	TotalOrders  int     `gorm:"default:0"`
+	Latitude     float64
+	Longitude    float64
+	LastActive   time.Time
+}
+
+func (v1Staff) TableName() string { return "staffs" }
+
+type v1SecurityStaff struct {
+	ID          uint   `gorm:"primarykey"`
+	UserID      uint   `gorm:"not null"`
+	Name        string `gorm:"size:50;not null"`
+	Phone       string `gorm:"size:20;not null"`
+	IDCard      string `gorm:"size:20;not null"`
+	Status      string `gorm:"size:20;not null"`
+	IsOnline    bool   `gorm:"default:false"`
+	LocationLat float64
+	LocationLng float64
+	OrderCount  int     `gorm:"default:0"`
+	Rating      float64 `gorm:"default:5.0"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (v1SecurityStaff) TableName() string { return "security_staff" }
+
+type v1StaffDocument struct {
+	ID          uint   `gorm:"primaryKey"`
+	StaffID     uint   `gorm:"not null;index"`
+	Type        string `gorm:"size:20;not null"`
+	FileKey     string `gorm:"size:255;not null"`
+	FileName    string `gorm:"size:255"`
+	ContentType string `gorm:"size:100"`
+	Size        int64
+	CreatedAt   time.Time
+}
+
+func (v1StaffDocument) TableName() string { return "staff_documents" }
+
+type v1StaffStatusHistory struct {
+	ID         uint   `gorm:"primaryKey"`
+	StaffID    uint   `gorm:"not null;index"`
+	FromStatus string `gorm:"size:20"`
+	ToStatus   string `gorm:"size:20;not null"`
+	OperatorID uint
+	Reason     string `gorm:"size:255"`
+	CreatedAt  time.Time
+}
+
+func (v1StaffStatusHistory) TableName() string { return "staff_status_histories" }
+
+type v1Emergency struct {
+	ID           uint `gorm:"primaryKey"`
+	UserID       uint
+	Type         string
+	Title        string
+	Description  string
+	Location     string
+	Latitude     float64
+	Longitude    float64
+	Status       int
+	StaffID      uint
+	DispatchedAt *time.Time
+	AcceptedAt   *time.Time
+	EnRouteAt    *time.Time
+	OnSceneAt    *time.Time
+	CompletedAt  *time.Time
+	CancelledAt  *time.Time
+	EscalatedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (v1Emergency) TableName() string { return "emergencies" }
+
+type v1EmergencyContact struct {
+	gorm.Model
+	UserID     uint   `gorm:"not null;index"`
+	Name       string `gorm:"size:50;not null"`
+	Phone      string `gorm:"size:20;not null"`
+	Email      string `gorm:"size:100"`
+	Relation   string `gorm:"size:50"`
+	IsDefault  bool   `gorm:"default:false"`
+	Status     string `gorm:"size:20;not null;default:'verified'"`
+	InvitedAt  *time.Time
+	VerifiedAt *time.Time
+}
+
+func (v1EmergencyContact) TableName() string { return "emergency_contacts" }
+
+type v1NotificationDelivery struct {
+	ID          uint   `gorm:"primaryKey"`
+	EmergencyID uint   `gorm:"not null;index"`
+	ContactID   uint   `gorm:"not null"`
+	ContactName string `gorm:"size:50"`
+	Channel     string `gorm:"size:20;not null"`
+	Recipient   string `gorm:"size:100;not null"`
+	Subject     string `gorm:"size:200"`
+	Content     string `gorm:"type:text"`
+	Priority    int
+	Status      string `gorm:"size:20;not null;index"`
+	Attempts    int
+	LastError   string `gorm:"type:text"`
+	SentAt      *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v1NotificationDelivery) TableName() string { return "notification_deliveries" }
+
+type v1HandlingRecord struct {
+	ID          uint `gorm:"primaryKey"`
+	EmergencyID uint
+	StaffID     uint
+	Action      string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v1HandlingRecord) TableName() string { return "handling_records" }
+
+type v1DangerZone struct {
+	gorm.Model
+	Name        string          `gorm:"size:100;not null"`
+	Description string          `gorm:"type:text"`
+	Level       string          `gorm:"size:20;not null"`
+	Latitude    float64         `gorm:"not null"`
+	Longitude   float64         `gorm:"not null"`
+	Radius      float64         `gorm:"not null"`
+	Shape       string          `gorm:"size:20;not null;default:circle"`
+	Geometry    json.RawMessage `gorm:"type:text"`
+	HeatLevel   int             `gorm:"default:0"`
+	IsActive    bool            `gorm:"default:true"`
+}
+
+func (v1DangerZone) TableName() string { return "danger_zones" }
+
+type v1DangerZoneHeatHistory struct {
+	ID        uint `gorm:"primaryKey"`
+	ZoneID    uint `gorm:"not null;index:idx_zone_created"`
+	HeatLevel int
+	Score     float64
+	Count24h  int       `gorm:"column:count_24h"`
+	Count7d   int       `gorm:"column:count_7d"`
+	Count30d  int       `gorm:"column:count_30d"`
+	Source    string    `gorm:"size:20;not null"`
+	CreatedAt time.Time `gorm:"index:idx_zone_created"`
+}
+
+func (v1DangerZoneHeatHistory) TableName() string { return "danger_zone_heat_histories" }
+
+type v1Rating struct {
+	ID        uint `gorm:"primaryKey"`
+	StaffID   uint
+	UserID    uint
+	Score     float32
+	Comment   string
+	IsPublic  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v1Rating) TableName() string { return "ratings" }
+
+type v1SystemConfig struct {
+	ID        uint   `gorm:"primaryKey"`
+	Key       string `gorm:"uniqueIndex;not null"`
+	Value     string `gorm:"not null"`
+	Type      string `gorm:"not null"`
+	Desc      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v1SystemConfig) TableName() string { return "system_configs" }
+
+type v1DispatchOffer struct {
+	ID          uint `gorm:"primaryKey"`
+	EmergencyID uint `gorm:"index;not null"`
+	StaffID     uint `gorm:"index;not null"`
+	Round       int
+	Radius      float64
+	Distance    float64
+	Status      string `gorm:"size:20;not null"`
+	Reason      string
+	ExpiresAt   time.Time
+	RespondedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (v1DispatchOffer) TableName() string { return "dispatch_offers" }
+
+type v1StaffLocation struct {
+	ID          uint `gorm:"primaryKey"`
+	StaffID     uint `gorm:"index:idx_staff_recorded;not null"`
+	EmergencyID uint `gorm:"index"`
+	Latitude    float64
+	Longitude   float64
+	RecordedAt  time.Time `gorm:"index:idx_staff_recorded"`
+	CreatedAt   time.Time
+}
+
+func (v1StaffLocation) TableName() string { return "staff_locations" }
+
+type v1GeofenceState struct {
+	UserID      uint `gorm:"primaryKey;autoIncrement:false"`
+	ZoneID      uint `gorm:"primaryKey;autoIncrement:false"`
+	Inside      bool
+	LastAlertAt *time.Time
+	UpdatedAt   time.Time
+}
+
+func (v1GeofenceState) TableName() string { return "geofence_states" }
+
+type v1GeofenceEvent struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	ZoneID    uint   `gorm:"not null;index:idx_geofence_events_zone_created"`
+	Event     string `gorm:"size:10;not null"`
+	Latitude  float64
+	Longitude float64
+	Alerted   bool
+	CreatedAt time.Time `gorm:"index:idx_geofence_events_zone_created"`
+}
+
+func (v1GeofenceEvent) TableName() string { return "geofence_events" }
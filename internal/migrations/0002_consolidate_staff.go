@@ -0,0 +1,239 @@
+package migrations
+
+import (
+	"dididaren/pkg/migrate"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// consolidateStaff 合并安保人员的两张表。
+// staffs 保存申请和审核资料，security_staff 保存派单使用的在线状态、实时位置和接单数，
+// 两者按 user_id 对应。合并后只保留 staffs，security_staff 中的数据写入 staffs 的对应列：
+// location_lat/location_lng 写入 latitude/longitude，order_count 写入 total_orders。
+// 没有对应 staffs 记录的 security_staff 按原状态补建记录，已软删除的 security_staff 不再迁移。
+// 同一用户有多条 security_staff 时，在线状态和位置取最近更新的一条，接单数取最大值
+// （旧代码按 user_id 同时更新所有记录）。security_staff 的评分列从未更新过，只是默认的 5.0，
+// 因此只有该用户确实收到过评价时才按评价重新计算评分。
+// 姓名、手机号、身份证号或状态与 staffs 不一致时迁移失败并列出冲突的用户，需人工核对后重新执行。
+// 回滚时为关联了用户的安保人员重建 security_staff 并删除新增的列
+func consolidateStaff() migrate.Migration {
+	return migrate.Migration{
+		Version: 2,
+		Name:    "consolidate_staff",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&v2Staff{}); err != nil {
+				return err
+			}
+			if !tx.Migrator().HasTable(&v1SecurityStaff{}) {
+				return nil
+			}
+
+			var legacy []v1SecurityStaff
+			if err := tx.Order("user_id, updated_at DESC, id DESC").Find(&legacy).Error; err != nil {
+				return err
+			}
+
+			var conflicts []string
+			for i := 0; i < len(legacy); {
+				// 同一用户的记录按更新时间倒序相邻，第一条为最近更新的
+				latest := legacy[i]
+				orders := latest.OrderCount
+				j := i + 1
+				for ; j < len(legacy) && legacy[j].UserID == latest.UserID; j++ {
+					if diff := legacyStaffConflict(legacy[j], latest.Name, latest.Phone, latest.IDCard, legacyStaffStatus(latest.Status)); diff != "" {
+						conflicts = append(conflicts, fmt.Sprintf("user_id=%d security_staff#%d 与 security_staff#%d 的%s不一致", latest.UserID, legacy[j].ID, latest.ID, diff))
+					}
+					if legacy[j].OrderCount > orders {
+						orders = legacy[j].OrderCount
+					}
+				}
+				i = j
+
+				var staff v2Staff
+				err := tx.Unscoped().Where("user_id = ?", latest.UserID).Order("id").Limit(1).Find(&staff).Error
+				if err != nil {
+					return err
+				}
+				if staff.ID != 0 {
+					if diff := legacyStaffConflict(latest, staff.Name, staff.Phone, staff.IDCard, staff.Status); diff != "" {
+						conflicts = append(conflicts, fmt.Sprintf("user_id=%d security_staff#%d 与 staffs#%d 的%s不一致", latest.UserID, latest.ID, staff.ID, diff))
+					}
+				}
+				if len(conflicts) > 0 {
+					// 继续检查以便一次列出所有冲突
+					continue
+				}
+
+				updates := map[string]interface{}{
+					"is_online":    latest.IsOnline,
+					"latitude":     latest.LocationLat,
+					"longitude":    latest.LocationLng,
+					"total_orders": orders,
+				}
+				rating, rated, err := legacyStaffRating(tx, latest.UserID)
+				if err != nil {
+					return err
+				}
+				if rated {
+					updates["rating"] = rating
+				}
+
+				if staff.ID != 0 {
+					if err := tx.Unscoped().Model(&v2Staff{}).Where("id = ?", staff.ID).Updates(updates).Error; err != nil {
+						return err
+					}
+					continue
+				}
+
+				staff = v2Staff{
+					UserID:      latest.UserID,
+					Name:        latest.Name,
+					Phone:       latest.Phone,
+					IDCard:      latest.IDCard,
+					Status:      legacyStaffStatus(latest.Status),
+					IsOnline:    latest.IsOnline,
+					Latitude:    latest.LocationLat,
+					Longitude:   latest.LocationLng,
+					TotalOrders: orders,
+					Rating:      rating,
+				}
+				staff.CreatedAt = latest.CreatedAt
+				staff.UpdatedAt = latest.UpdatedAt
+				if err := tx.Create(&staff).Error; err != nil {
+					return err
+				}
+			}
+			if len(conflicts) > 0 {
+				return fmt.Errorf("security_staff 与 staffs 的数据存在冲突，请人工核对后重新执行：\n%s", strings.Join(conflicts, "\n"))
+			}
+			return tx.Migrator().DropTable(&v1SecurityStaff{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&v1SecurityStaff{}); err != nil {
+				return err
+			}
+
+			var staffs []v2Staff
+			if err := tx.Unscoped().Where("user_id <> 0").Order("id").Find(&staffs).Error; err != nil {
+				return err
+			}
+			for _, staff := range staffs {
+				var count int64
+				if err := tx.Unscoped().Model(&v1SecurityStaff{}).Where("user_id = ?", staff.UserID).Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 {
+					continue
+				}
+				err := tx.Create(&v1SecurityStaff{
+					UserID:      staff.UserID,
+					Name:        staff.Name,
+					Phone:       staff.Phone,
+					IDCard:      staff.IDCard,
+					Status:      securityStaffStatus(staff.Status),
+					IsOnline:    staff.IsOnline,
+					LocationLat: staff.Latitude,
+					LocationLng: staff.Longitude,
+					OrderCount:  staff.TotalOrders,
+					Rating:      staff.Rating,
+					CreatedAt:   staff.CreatedAt,
+					UpdatedAt:   staff.UpdatedAt,
+					DeletedAt:   staff.DeletedAt,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+
+			if tx.Migrator().HasColumn(&v2Staff{}, "is_online") {
+				if err := tx.Migrator().DropColumn(&v2Staff{}, "is_online"); err != nil {
+					return err
+				}
+			}
+			if tx.Migrator().HasIndex(&v2Staff{}, "idx_staffs_user_id") {
+				return tx.Migrator().DropIndex(&v2Staff{}, "idx_staffs_user_id")
+			}
+			return nil
+		},
+	}
+}
+
+// v2Staff 合并后的安保人员表
+type v2Staff struct {
+	gorm.Model
+	UserID       uint   `gorm:"not null;index"`
+	Name         string `gorm:"size:50;not null"`
+	Phone        string `gorm:"size:20;not null"`
+	IDCard       string `gorm:"size:20;not null"` // 与 security_staff 一致，避免合并时截断
+	Status       string `gorm:"size:20;not null;default:'pending'"`
+	ReviewReason string `gorm:"size:255"`
+	IsOnline     bool   `gorm:"default:false"`
+	Latitude     float64
+	Longitude    float64
+	Rating       float64 `gorm:"default:0"`
+	TotalOrders  int     `gorm:"default:0"`
+	LastActive   time.Time
+}
+
+func (v2Staff) TableName() string { return "staffs" }
+
+// legacyStaffConflict 比较 security_staff 与另一条记录的身份信息和状态，返回不一致的字段，一致时返回空字符串
+func legacyStaffConflict(old v1SecurityStaff, name, phone, idCard, status string) string {
+	var fields []string
+	if old.Name != name {
+		fields = append(fields, "姓名")
+	}
+	if old.Phone != phone {
+		fields = append(fields, "手机号")
+	}
+	if old.IDCard != idCard {
+		fields = append(fields, "身份证号")
+	}
+	if legacyStaffStatus(old.Status) != status {
+		fields = append(fields, fmt.Sprintf("状态（%s/%s）", old.Status, status))
+	}
+	return strings.Join(fields, "、")
+}
+
+// legacyStaffRating 按收到的评价计算安保人员的评分，没有评价时 rated 为 false
+func legacyStaffRating(tx *gorm.DB, userID uint) (rating float64, rated bool, err error) {
+	var result struct {
+		Count   int64
+		Average float64
+	}
+	err = tx.Model(&v1Rating{}).
+		Where("staff_id = ?", userID).
+		Select("COUNT(*) AS count, COALESCE(AVG(score), 0) AS average").
+		Scan(&result).Error
+	if err != nil {
+		return 0, false, err
+	}
+	return result.Average, result.Count > 0, nil
+}
+
+// legacyStaffStatus security_staff 的状态对应的 staffs 状态
+func legacyStaffStatus(status string) string {
+	switch status {
+	case "approved":
+		return "active"
+	case "pending", "rejected":
+		return status
+	default:
+		return "inactive"
+	}
+}
+
+// securityStaffStatus staffs 的状态对应的 security_staff 状态
+func securityStaffStatus(status string) string {
+	switch status {
+	case "active":
+		return "approved"
+	case "pending", "rejected":
+		return status
+	default:
+		return "inactive"
+	}
+}
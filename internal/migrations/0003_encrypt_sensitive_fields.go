@@ -0,0 +1,231 @@
+package migrations
+
+import (
+	"database/sql"
+	"dididaren/pkg/migrate"
+	"dididaren/pkg/sensitive"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// encryptSensitiveFields 加密存储用户、安保人员和紧急联系人的手机号以及安保人员的身份证号。
+// 密文使用随机 nonce，无法按值查询，因此用户和紧急联系人增加手机号的盲索引列，
+// users.phone 上的唯一索引改为建在 phone_hash 上。
+// 执行前须配置加密密钥；已经是密文的值不会重复加密，中途失败后可以重新执行。
+// 回滚时解密为明文并恢复原来的列和索引
+func encryptSensitiveFields() migrate.Migration {
+	return migrate.Migration{
+		Version: 3,
+		Name:    "encrypt_sensitive_fields",
+		Up: func(tx *gorm.DB) error {
+			c, err := sensitive.Current()
+			if err != nil {
+				return err
+			}
+
+			m := tx.Migrator()
+			if m.HasIndex(&v1User{}, "idx_users_phone") {
+				if err := m.DropIndex(&v1User{}, "idx_users_phone"); err != nil {
+					return err
+				}
+			}
+			if err := m.AlterColumn(&v3User{}, "Phone"); err != nil {
+				return err
+			}
+			if !m.HasColumn(&v3User{}, "PhoneHash") {
+				if err := m.AddColumn(&v3User{}, "PhoneHash"); err != nil {
+					return err
+				}
+			}
+			if err := encryptColumns(tx, c, "users", "phone_hash", "phone"); err != nil {
+				return err
+			}
+			if !m.HasIndex(&v3User{}, "idx_users_phone_hash") {
+				if err := m.CreateIndex(&v3User{}, "idx_users_phone_hash"); err != nil {
+					return err
+				}
+			}
+
+			if err := m.AlterColumn(&v3Staff{}, "Phone"); err != nil {
+				return err
+			}
+			if err := m.AlterColumn(&v3Staff{}, "IDCard"); err != nil {
+				return err
+			}
+			if err := encryptColumns(tx, c, "staffs", "", "phone", "id_card"); err != nil {
+				return err
+			}
+
+			if err := m.AlterColumn(&v3EmergencyContact{}, "Phone"); err != nil {
+				return err
+			}
+			if !m.HasColumn(&v3EmergencyContact{}, "PhoneHash") {
+				if err := m.AddColumn(&v3EmergencyContact{}, "PhoneHash"); err != nil {
+					return err
+				}
+			}
+			return encryptColumns(tx, c, "emergency_contacts", "phone_hash", "phone")
+		},
+		Down: func(tx *gorm.DB) error {
+			c, err := sensitive.Current()
+			if err != nil {
+				return err
+			}
+
+			m := tx.Migrator()
+			if err := decryptColumns(tx, c, "users", "phone"); err != nil {
+				return err
+			}
+			if m.HasIndex(&v3User{}, "idx_users_phone_hash") {
+				if err := m.DropIndex(&v3User{}, "idx_users_phone_hash"); err != nil {
+					return err
+				}
+			}
+			if m.HasColumn(&v3User{}, "PhoneHash") {
+				if err := m.DropColumn(&v3User{}, "PhoneHash"); err != nil {
+					return err
+				}
+			}
+			if err := m.AlterColumn(&v1User{}, "Phone"); err != nil {
+				return err
+			}
+			if !m.HasIndex(&v1User{}, "idx_users_phone") {
+				if err := m.CreateIndex(&v1User{}, "idx_users_phone"); err != nil {
+					return err
+				}
+			}
+
+			if err := decryptColumns(tx, c, "staffs", "phone", "id_card"); err != nil {
+				return err
+			}
+			if err := m.AlterColumn(&v2Staff{}, "Phone"); err != nil {
+				return err
+			}
+			if err := m.AlterColumn(&v2Staff{}, "IDCard"); err != nil {
+				return err
+			}
+
+			if err := decryptColumns(tx, c, "emergency_contacts", "phone"); err != nil {
+				return err
+			}
+			if m.HasColumn(&v3EmergencyContact{}, "PhoneHash") {
+				if err := m.DropColumn(&v3EmergencyContact{}, "PhoneHash"); err != nil {
+					return err
+				}
+			}
+			return m.AlterColumn(&v1EmergencyContact{}, "Phone")
+		},
+	}
+}
+
+// v3User 只包含本次变更的列
+type v3User struct {
+	ID        uint   `gorm:"primaryKey"`
+	Phone     string `gorm:"size:255;not null"`
+	PhoneHash string `gorm:"size:64;uniqueIndex"`
+}
+
+func (v3User) TableName() string { return "users" }
+
+type v3Staff struct {
+	ID     uint   `gorm:"primaryKey"`
+	Phone  string `gorm:"size:255;not null"`
+	IDCard string `gorm:"size:255;not null"`
+}
+
+func (v3Staff) TableName() string { return "staffs" }
+
+type v3EmergencyContact struct {
+	ID        uint   `gorm:"primaryKey"`
+	Phone     string `gorm:"size:255;not null"`
+	PhoneHash string `gorm:"size:64"`
+}
+
+func (v3EmergencyContact) TableName() string { return "emergency_contacts" }
+
+// encryptColumns 加密表中所有行（包括软删除的行）的 columns 列，
+// hashColumn 不为空时写入第一列明文的盲索引
+func encryptColumns(tx *gorm.DB, c *sensitive.Cipher, table, hashColumn string, columns ...string) error {
+	rows, err := loadColumns(tx, table, columns)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		updates := make(map[string]interface{}, len(columns)+1)
+		for i, value := range row.values {
+			plaintext, err := c.Decrypt(value)
+			if err != nil {
+				return err
+			}
+			if !sensitive.IsEncrypted(value) {
+				if value, err = c.Encrypt(plaintext); err != nil {
+					return err
+				}
+			}
+			updates[columns[i]] = value
+			if i == 0 && hashColumn != "" {
+				updates[hashColumn] = c.BlindIndex(plaintext)
+			}
+		}
+		if err := tx.Table(table).Where("id = ?", row.id).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decryptColumns 将表中所有行的 columns 列解密为明文
+func decryptColumns(tx *gorm.DB, c *sensitive.Cipher, table string, columns ...string) error {
+	rows, err := loadColumns(tx, table, columns)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		updates := make(map[string]interface{}, len(columns))
+		for i, value := range row.values {
+			plaintext, err := c.Decrypt(value)
+			if err != nil {
+				return err
+			}
+			updates[columns[i]] = plaintext
+		}
+		if err := tx.Table(table).Where("id = ?", row.id).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type columnRow struct {
+	id     uint
+	values []string
+}
+
+// loadColumns 读取表中所有行的 id 和 columns 列，NULL 读作空字符串。
+// 先全部读出再更新，避免在同一个事务连接上边读边写
+func loadColumns(tx *gorm.DB, table string, columns []string) ([]columnRow, error) {
+	rows, err := tx.Table(table).Select("id, " + strings.Join(columns, ", ")).Order("id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []columnRow
+	for rows.Next() {
+		row := columnRow{values: make([]string, len(columns))}
+		values := make([]sql.NullString, len(columns))
+		dest := []interface{}{&row.id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, value := range values {
+			row.values[i] = value.String
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
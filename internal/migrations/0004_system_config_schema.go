@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"dididaren/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// systemConfigSchema 为系统配置增加 schema 列，保存值的取值范围。
+// 已有配置的 schema 为空，即不限制取值范围
+func systemConfigSchema() migrate.Migration {
+	return migrate.Migration{
+		Version: 4,
+		Name:    "system_config_schema",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasColumn(&v4SystemConfig{}, "Schema") {
+				return nil
+			}
+			return m.AddColumn(&v4SystemConfig{}, "Schema")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&v4SystemConfig{}, "Schema") {
+				return nil
+			}
+			return m.DropColumn(&v4SystemConfig{}, "Schema")
+		},
+	}
+}
+
+// v4SystemConfig 只包含本次变更的列
+type v4SystemConfig struct {
+	ID     uint   `gorm:"primaryKey"`
+	Schema string `gorm:"type:text"`
+}
+
+func (v4SystemConfig) TableName() string { return "system_configs" }
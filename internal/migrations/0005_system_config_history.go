@@ -0,0 +1,85 @@
+package migrations
+
+import (
+	"dididaren/pkg/migrate"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// systemConfigHistory 新建系统配置变更记录表，并为已有配置补录版本1，
+// 使配置在首次修改后仍能回滚到迁移时的值。补录记录的操作人为0。
+// 回滚时删除该表
+func systemConfigHistory() migrate.Migration {
+	return migrate.Migration{
+		Version: 5,
+		Name:    "system_config_history",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&v5SystemConfigHistory{}); err != nil {
+				return err
+			}
+
+			var configs []v5SystemConfig
+			if err := tx.Order("id").Find(&configs).Error; err != nil {
+				return err
+			}
+			for _, config := range configs {
+				var count int64
+				err := tx.Model(&v5SystemConfigHistory{}).Where("config_key = ?", config.Key).Count(&count).Error
+				if err != nil {
+					return err
+				}
+				if count > 0 {
+					continue
+				}
+				value := config.Value
+				err = tx.Create(&v5SystemConfigHistory{
+					ConfigKey: config.Key,
+					Version:   1,
+					Action:    "create",
+					NewValue:  &value,
+					Type:      config.Type,
+					Schema:    config.Schema,
+					Desc:      config.Desc,
+					CreatedAt: config.UpdatedAt,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v5SystemConfigHistory{})
+		},
+	}
+}
+
+type v5SystemConfig struct {
+	ID        uint `gorm:"primaryKey"`
+	Key       string
+	Value     string
+	Type      string
+	Schema    *string
+	Desc      string
+	UpdatedAt time.Time
+}
+
+func (v5SystemConfig) TableName() string { return "system_configs" }
+
+type v5SystemConfigHistory struct {
+	ID         uint    `gorm:"primaryKey"`
+	ConfigKey  string  `gorm:"size:100;not null;uniqueIndex:idx_config_key_version"`
+	Version    int     `gorm:"not null;uniqueIndex:idx_config_key_version"`
+	Action     string  `gorm:"size:20;not null"`
+	OldValue   *string `gorm:"type:text"`
+	NewValue   *string `gorm:"type:text"`
+	Type       string  `gorm:"size:20"`
+	Schema     *string `gorm:"type:text"`
+	Desc       string
+	RollbackTo int
+	OperatorID uint
+	CreatedAt  time.Time
+}
+
+func (v5SystemConfigHistory) TableName() string { return "system_config_histories" }
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"dididaren/pkg/migrate"
+	"dididaren/pkg/sensitive"
+
+	"gorm.io/gorm"
+)
+
+// encryptNotificationDeliveries 加密存储通知记录的接收方和通知内容。
+// 接收方是紧急联系人的手机号或邮箱，内容中包含报警人的手机号和位置，与 0003 加密的字段同样敏感。
+// 执行前须配置加密密钥；已经是密文的值不会重复加密，中途失败后可以重新执行。
+// 回滚时解密为明文并恢复原来的列长度
+func encryptNotificationDeliveries() migrate.Migration {
+	return migrate.Migration{
+		Version: 6,
+		Name:    "encrypt_notification_deliveries",
+		Up: func(tx *gorm.DB) error {
+			c, err := sensitive.Current()
+			if err != nil {
+				return err
+			}
+			if err := tx.Migrator().AlterColumn(&v6NotificationDelivery{}, "Recipient"); err != nil {
+				return err
+			}
+			return encryptColumns(tx, c, "notification_deliveries", "", "recipient", "content")
+		},
+		Down: func(tx *gorm.DB) error {
+			c, err := sensitive.Current()
+			if err != nil {
+				return err
+			}
+			if err := decryptColumns(tx, c, "notification_deliveries", "recipient", "content"); err != nil {
+				return err
+			}
+			return tx.Migrator().AlterColumn(&v1NotificationDelivery{}, "Recipient")
+		},
+	}
+}
+
+// v6NotificationDelivery 只包含本次变更的列
+type v6NotificationDelivery struct {
+	ID        uint   `gorm:"primaryKey"`
+	Recipient string `gorm:"size:255;not null"`
+}
+
+func (v6NotificationDelivery) TableName() string { return "notification_deliveries" }
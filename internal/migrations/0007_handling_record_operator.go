@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"dididaren/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// handlingRecordOperator 处理记录增加操作人列，记录手动添加处理记录的用户，
+// 已有的记录和系统自动生成的记录为0。回滚时删除该列
+func handlingRecordOperator() migrate.Migration {
+	return migrate.Migration{
+		Version: 7,
+		Name:    "handling_record_operator",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&v7HandlingRecord{}, "OperatorID") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&v7HandlingRecord{}, "OperatorID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&v7HandlingRecord{}, "OperatorID") {
+				return nil
+			}
+			return tx.Migrator().DropColumn(&v7HandlingRecord{}, "OperatorID")
+		},
+	}
+}
+
+// v7HandlingRecord 只包含本次变更的列
+type v7HandlingRecord struct {
+	ID         uint `gorm:"primaryKey"`
+	OperatorID uint `gorm:"not null;default:0"`
+}
+
+func (v7HandlingRecord) TableName() string { return "handling_records" }
@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"dididaren/pkg/migrate"
+	"dididaren/pkg/sensitive"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// hashLoginAttemptPhones 登录尝试记录不再保存明文手机号，改为保存手机号的盲索引，按盲索引统计失败次数。
+// 执行前须配置加密密钥。盲索引无法还原手机号，回滚时恢复 phone 列但已有记录的手机号为空，
+// 按手机号统计的失败次数从回滚时重新开始计算，按 IP 的统计不受影响
+func hashLoginAttemptPhones() migrate.Migration {
+	return migrate.Migration{
+		Version: 8,
+		Name:    "hash_login_attempt_phones",
+		Up: func(tx *gorm.DB) error {
+			c, err := sensitive.Current()
+			if err != nil {
+				return err
+			}
+
+			m := tx.Migrator()
+			if !m.HasColumn(&v8LoginAttempt{}, "PhoneHash") {
+				if err := m.AddColumn(&v8LoginAttempt{}, "PhoneHash"); err != nil {
+					return err
+				}
+			}
+			if m.HasColumn(&v1LoginAttempt{}, "Phone") {
+				rows, err := loadColumns(tx, "login_attempts", []string{"phone"})
+				if err != nil {
+					return err
+				}
+				for _, row := range rows {
+					err := tx.Table("login_attempts").Where("id = ?", row.id).
+						Update("phone_hash", c.BlindIndex(row.values[0])).Error
+					if err != nil {
+						return err
+					}
+				}
+
+				if m.HasIndex(&v1LoginAttempt{}, "idx_login_attempts_phone") {
+					if err := m.DropIndex(&v1LoginAttempt{}, "idx_login_attempts_phone"); err != nil {
+						return err
+					}
+				}
+				if err := m.DropColumn(&v1LoginAttempt{}, "Phone"); err != nil {
+					return err
+				}
+			}
+			return createMissingIndexes(m, &v8LoginAttempt{},
+				"idx_login_attempts_phone_hash", "idx_login_attempts_ip", "idx_login_attempts_created_at")
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasColumn(&v1LoginAttempt{}, "Phone") {
+				if err := m.AddColumn(&v1LoginAttempt{}, "Phone"); err != nil {
+					return err
+				}
+			}
+			if m.HasIndex(&v8LoginAttempt{}, "idx_login_attempts_phone_hash") {
+				if err := m.DropIndex(&v8LoginAttempt{}, "idx_login_attempts_phone_hash"); err != nil {
+					return err
+				}
+			}
+			if m.HasColumn(&v8LoginAttempt{}, "PhoneHash") {
+				if err := m.DropColumn(&v8LoginAttempt{}, "PhoneHash"); err != nil {
+					return err
+				}
+			}
+			return createMissingIndexes(m, &v1LoginAttempt{},
+				"idx_login_attempts_phone", "idx_login_attempts_ip", "idx_login_attempts_created_at")
+		},
+	}
+}
+
+// createMissingIndexes 补建表上缺少的索引。sqlite 删除列时会重建表，表上原有的索引随之丢失
+func createMissingIndexes(m gorm.Migrator, value interface{}, names ...string) error {
+	for _, name := range names {
+		if m.HasIndex(value, name) {
+			continue
+		}
+		if err := m.CreateIndex(value, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// v8LoginAttempt 变更后的登录尝试记录表
+type v8LoginAttempt struct {
+	ID        uint   `gorm:"primaryKey"`
+	PhoneHash string `gorm:"size:64;index"`
+	IP        string `gorm:"size:64;index"`
+	Success   bool
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func (v8LoginAttempt) TableName() string { return "login_attempts" }
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"dididaren/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// All 全部数据库迁移，新的迁移追加在末尾，版本号递增，已发布的迁移不要再修改
+func All() []migrate.Migration {
+	return []migrate.Migration{
+		baseline(),
+		consolidateStaff(),
+		encryptSensitiveFields(),
+		systemConfigSchema(),
+		systemConfigHistory(),
+		encryptNotificationDeliveries(),
+		handlingRecordOperator(),
+		hashLoginAttemptPhones(),
+	}
+}
+
+// Up 执行全部未执行的迁移，用于内存数据库等无法预先执行 cmd/migrate 的场景
+func Up(db *gorm.DB) ([]migrate.Migration, error) {
+	migrator, err := migrate.New(db, All())
+	if err != nil {
+		return nil, err
+	}
+	return migrator.Up()
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// AccountExport 用户个人数据导出
+type AccountExport struct {
+	ExportedAt      time.Time            `json:"exported_at"`
+	Profile         *User                `json:"profile"`
+	Roles           []string             `json:"roles"`
+	Staff           *Staff               `json:"staff,omitempty"`           // 申请过安保人员时的资料
+	StaffDocuments  []StaffDocument      `json:"staff_documents,omitempty"` // 安保人员申请材料，只导出文件信息
+	StaffHistory    []StaffStatusHistory `json:"staff_history,omitempty"`
+	Contacts        []EmergencyContact   `json:"contacts"`
+	Emergencies     []Emergency          `json:"emergencies"`
+	HandlingRecords []HandlingRecord     `json:"handling_records"` // 用户发起的事件和作为安保人员处理的事件的处理记录
+	RatingsGiven    []Rating             `json:"ratings_given"`
+	RatingsReceived []Rating             `json:"ratings_received"`
+}
+
+// DeleteAccountRequest 注销账号请求
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
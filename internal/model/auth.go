@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// RefreshToken 刷新令牌，每次使用后轮换为新令牌，数据库中只保存哈希
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	SessionID string     `json:"session_id" gorm:"size:32;not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`    // 已轮换，再次使用视为令牌泄露
+	RevokedAt *time.Time `json:"revoked_at"` // 所属会话已退出登录
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// Session 登录会话，一次登录对应一个会话，刷新令牌不会产生新会话
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:32"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	DeviceName string     `json:"device_name" gorm:"size:100"`
+	IP         string     `json:"ip" gorm:"size:64"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"` // 最新刷新令牌的过期时间，之后会话无法再刷新
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Current    bool       `json:"current" gorm:"-"` // 是否为发起请求的会话
+}
+
+// TableName 指定表名
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// ClientInfo 发起登录或刷新的客户端信息
+type ClientInfo struct {
+	DeviceName string
+	IP         string
+	UserAgent  string
+}
+
+// RevokedSession 已吊销的登录会话，在其访问令牌全部过期之前保留
+type RevokedSession struct {
+	SessionID string    `json:"session_id" gorm:"primaryKey;size:32"`
+	UserID    uint      `json:"user_id" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (RevokedSession) TableName() string {
+	return "revoked_sessions"
+}
+
+// TokenPair 登录或刷新后返回的令牌
+type TokenPair struct {
+	Token        string `json:"token"` // 访问令牌
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期（秒）
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
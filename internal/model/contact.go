@@ -0,0 +1,23 @@
+package model
+
+// CreateContactRequest 添加紧急联系人请求
+type CreateContactRequest struct {
+	Name      string `json:"name" binding:"required,max=50"`
+	Phone     string `json:"phone" binding:"required"`
+	Email     string `json:"email" binding:"omitempty,email,max=100"`
+	Relation  string `json:"relation" binding:"max=50"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// UpdateContactRequest 修改紧急联系人请求，修改手机号后需要重新确认
+type UpdateContactRequest struct {
+	Name     string `json:"name" binding:"required,max=50"`
+	Phone    string `json:"phone" binding:"required"`
+	Email    string `json:"email" binding:"omitempty,email,max=100"`
+	Relation string `json:"relation" binding:"max=50"`
+}
+
+// VerifyContactRequest 确认紧急联系人请求，验证码由联系人从邀请短信中告知用户
+type VerifyContactRequest struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -1,20 +1,31 @@
 package model
 
 import (
+	"encoding/json"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// 危险区域形状
+const (
+	DangerZoneShapeCircle  = "circle"  // 以中心点和半径表示的圆形区域
+	DangerZoneShapePolygon = "polygon" // 以 GeoJSON Polygon/MultiPolygon 表示的多边形区域
+)
+
 // DangerZone 危险区域模型
 type DangerZone struct {
 	gorm.Model
-	Name        string  `gorm:"size:100;not null" json:"name"`
-	Description string  `gorm:"type:text" json:"description"`
-	Level       string  `gorm:"size:20;not null" json:"level"` // low, medium, high
-	Latitude    float64 `gorm:"not null" json:"latitude"`
-	Longitude   float64 `gorm:"not null" json:"longitude"`
-	Radius      float64 `gorm:"not null" json:"radius"` // 半径（米）
-	HeatLevel   int     `gorm:"default:0" json:"heat_level"` // 热度等级
-	IsActive    bool    `gorm:"default:true" json:"is_active"`
+	Name        string          `gorm:"size:100;not null" json:"name"`
+	Description string          `gorm:"type:text" json:"description"`
+	Level       string          `gorm:"size:20;not null" json:"level"` // low, medium, high
+	Latitude    float64         `gorm:"not null" json:"latitude"`
+	Longitude   float64         `gorm:"not null" json:"longitude"`
+	Radius      float64         `gorm:"not null" json:"radius"` // 半径（米），多边形区域为外接圆半径
+	Shape       string          `gorm:"size:20;not null;default:circle" json:"shape"`
+	Geometry    json.RawMessage `gorm:"type:text" json:"geometry,omitempty"` // 多边形区域的 GeoJSON 几何对象
+	HeatLevel   int             `gorm:"default:0" json:"heat_level"`         // 热度等级
+	IsActive    bool            `gorm:"default:true" json:"is_active"`
 }
 
 // TableName 指定表名
@@ -22,23 +33,57 @@ func (DangerZone) TableName() string {
 	return "danger_zones"
 }
 
+// NearbyDangerZone 附近的危险区域
+type NearbyDangerZone struct {
+	DangerZone
+	Distance float64 `json:"distance"` // 到区域中心的距离（米）
+	Inside   bool    `json:"inside"`   // 是否位于区域范围内
+}
+
+// 热度来源
+const (
+	HeatSourceAuto   = "auto"   // 后台任务根据区域内的紧急事件计算
+	HeatSourceManual = "manual" // 管理员手动设置
+)
+
+// DangerZoneHeatHistory 危险区域热度历史
+type DangerZoneHeatHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ZoneID    uint      `json:"zone_id" gorm:"not null;index:idx_zone_created"`
+	HeatLevel int       `json:"heat_level"`
+	Score     float64   `json:"score"`                             // 加权得分
+	Count24h  int       `json:"count_24h" gorm:"column:count_24h"` // 近24小时区域内的事件数
+	Count7d   int       `json:"count_7d" gorm:"column:count_7d"`   // 近7天区域内的事件数
+	Count30d  int       `json:"count_30d" gorm:"column:count_30d"` // 近30天区域内的事件数
+	Source    string    `json:"source" gorm:"size:20;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_zone_created"`
+}
+
+// TableName 指定表名
+func (DangerZoneHeatHistory) TableName() string {
+	return "danger_zone_heat_histories"
+}
+
 // CreateDangerZoneRequest 创建危险区域请求
+// 传入 geometry（GeoJSON Polygon 或 MultiPolygon）时创建多边形区域，忽略中心点和半径
 type CreateDangerZoneRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	Level       string  `json:"level" binding:"required,oneof=low medium high"`
-	Latitude    float64 `json:"latitude" binding:"required"`
-	Longitude   float64 `json:"longitude" binding:"required"`
-	Radius      float64 `json:"radius" binding:"required,min=0"`
+	Name        string          `json:"name" binding:"required"`
+	Description string          `json:"description"`
+	Level       string          `json:"level" binding:"required,oneof=low medium high"`
+	Latitude    float64         `json:"latitude" binding:"required_without=Geometry"`
+	Longitude   float64         `json:"longitude" binding:"required_without=Geometry"`
+	Radius      float64         `json:"radius" binding:"required_without=Geometry,min=0"`
+	Geometry    json.RawMessage `json:"geometry"`
 }
 
 // UpdateDangerZoneRequest 更新危险区域请求
 type UpdateDangerZoneRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Level       string  `json:"level" binding:"omitempty,oneof=low medium high"`
-	Latitude    float64 `json:"latitude"`
-	Longitude   float64 `json:"longitude"`
-	Radius      float64 `json:"radius" binding:"omitempty,min=0"`
-	IsActive    bool    `json:"is_active"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Level       string          `json:"level" binding:"omitempty,oneof=low medium high"`
+	Latitude    float64         `json:"latitude"`
+	Longitude   float64         `json:"longitude"`
+	Radius      float64         `json:"radius" binding:"omitempty,min=0"`
+	IsActive    bool            `json:"is_active"`
+	Geometry    json.RawMessage `json:"geometry"` // 传入时将区域替换为该多边形
 }
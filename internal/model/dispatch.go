@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+)
+
+// 派单状态
+const (
+	OfferStatusPending   = "pending"   // 等待响应
+	OfferStatusAccepted  = "accepted"  // 已接受
+	OfferStatusDeclined  = "declined"  // 已拒绝
+	OfferStatusExpired   = "expired"   // 超时未响应
+	OfferStatusCancelled = "cancelled" // 已被他人接单或事件结束
+)
+
+// DispatchOffer 派单记录
+type DispatchOffer struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	EmergencyID uint       `json:"emergency_id" gorm:"index;not null"`
+	StaffID     uint       `json:"staff_id" gorm:"index;not null"` // 安保人员的用户ID
+	Round       int        `json:"round"`                          // 派单轮次，从1开始
+	Radius      float64    `json:"radius"`                         // 本轮搜索半径（米）
+	Distance    float64    `json:"distance"`                       // 安保人员与事件的距离（米）
+	Status      string     `json:"status" gorm:"size:20;not null"`
+	Reason      string     `json:"reason"` // 拒绝原因
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (DispatchOffer) TableName() string {
+	return "dispatch_offers"
+}
+
+// DeclineOfferRequest 拒绝派单请求
+type DeclineOfferRequest struct {
+	Reason string `json:"reason"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"dididaren/pkg/sensitive"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,17 +9,49 @@ import (
 
 // Emergency 紧急事件
 type Emergency struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	UserID      uint      `json:"user_id"`
-	Type        string    `json:"type"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Location    string    `json:"location"`
-	Latitude    float64   `json:"latitude"`
-	Longitude   float64   `json:"longitude"`
-	Status      int       `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id"`
+	Type         string     `json:"type"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	Location     string     `json:"location"`
+	Latitude     float64    `json:"latitude"`
+	Longitude    float64    `json:"longitude"`
+	Status       int        `json:"status"`
+	StaffID      uint       `json:"staff_id"` // 接单安保人员的用户ID
+	DispatchedAt *time.Time `json:"dispatched_at"`
+	AcceptedAt   *time.Time `json:"accepted_at"`
+	EnRouteAt    *time.Time `json:"en_route_at"`
+	OnSceneAt    *time.Time `json:"on_scene_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CancelledAt  *time.Time `json:"cancelled_at"`
+	EscalatedAt  *time.Time `json:"escalated_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// 紧急事件状态，数值已持久化，新增状态只能追加
+const (
+	EmergencyStatusPending    = 1 // 待处理
+	EmergencyStatusDispatched = 2 // 派单中
+	EmergencyStatusAccepted   = 3 // 已接单
+	EmergencyStatusCompleted  = 4 // 已完成
+	EmergencyStatusEscalated  = 5 // 已升级，转人工处理
+	EmergencyStatusEnRoute    = 6 // 安保人员赶往现场
+	EmergencyStatusOnScene    = 7 // 安保人员已到达现场
+	EmergencyStatusCancelled  = 8 // 已取消
+)
+
+// EmergencyStatusNames 紧急事件状态名称
+var EmergencyStatusNames = map[int]string{
+	EmergencyStatusPending:    "pending",
+	EmergencyStatusDispatched: "dispatched",
+	EmergencyStatusAccepted:   "accepted",
+	EmergencyStatusEnRoute:    "en_route",
+	EmergencyStatusOnScene:    "on_scene",
+	EmergencyStatusCompleted:  "completed",
+	EmergencyStatusCancelled:  "cancelled",
+	EmergencyStatusEscalated:  "escalated",
 }
 
 // TableName 指定表名
@@ -47,17 +80,36 @@ type UpdateEmergencyRequest struct {
 
 // UpdateEmergencyStatusRequest 更新紧急事件状态请求
 type UpdateEmergencyStatusRequest struct {
-	Status int `json:"status" binding:"required"`
+	Status int    `json:"status" binding:"required"`
+	Remark string `json:"remark"`
+}
+
+// AssignStaffRequest 人工指派安保人员请求
+type AssignStaffRequest struct {
+	StaffID uint `json:"staff_id" binding:"required"` // 安保人员的用户ID
 }
 
-// EmergencyContact 紧急联系人模型
+// 紧急联系人状态
+const (
+	ContactStatusPending  = "pending"  // 已邀请，等待联系人确认
+	ContactStatusVerified = "verified" // 联系人已确认，紧急求助时会收到通知
+)
+
+// EmergencyContact 紧急联系人模型。
+// 新添加的联系人需要凭邀请短信中的验证码确认后才会收到求助通知，
+// 引入确认流程之前添加的联系人按已确认处理。手机号加密存储
 type EmergencyContact struct {
 	gorm.Model
-	UserID    uint   `gorm:"not null" json:"user_id"`
-	Name      string `gorm:"size:50;not null" json:"name"`
-	Phone     string `gorm:"size:20;not null" json:"phone"`
-	Relation  string `gorm:"size:50" json:"relation"`
-	IsDefault bool   `gorm:"default:false" json:"is_default"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"size:50;not null" json:"name"`
+	Phone      string     `gorm:"size:255;not null;serializer:encrypted" json:"phone"`
+	PhoneHash  string     `gorm:"size:64" json:"-"` // 手机号盲索引，用于检查重复添加
+	Email      string     `gorm:"size:100" json:"email"`
+	Relation   string     `gorm:"size:50" json:"relation"`
+	IsDefault  bool       `gorm:"default:false" json:"is_default"`
+	Status     string     `gorm:"size:20;not null;default:'verified'" json:"status"`
+	InvitedAt  *time.Time `json:"invited_at"`
+	VerifiedAt *time.Time `json:"verified_at"`
 }
 
 // TableName 指定表名
@@ -65,11 +117,18 @@ func (EmergencyContact) TableName() string {
 	return "emergency_contacts"
 }
 
+// BeforeSave 写入前更新手机号盲索引
+func (c *EmergencyContact) BeforeSave(tx *gorm.DB) (err error) {
+	c.PhoneHash, err = sensitive.BlindIndex(c.Phone)
+	return err
+}
+
 // HandlingRecord 处理记录
 type HandlingRecord struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	EmergencyID uint      `json:"emergency_id"`
 	StaffID     uint      `json:"staff_id"`
+	OperatorID  uint      `json:"operator_id" gorm:"not null;default:0"` // 手动添加记录的用户，系统自动生成的记录为0
 	Action      string    `json:"action"`
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
@@ -86,3 +145,40 @@ type CreateHandlingRecordRequest struct {
 	Action      string `json:"action" binding:"required"`
 	Description string `json:"description" binding:"required"`
 }
+
+// 通知发送状态
+const (
+	DeliveryStatusPending = "pending" // 等待发送或重试
+	DeliveryStatusSent    = "sent"    // 已发送
+	DeliveryStatusFailed  = "failed"  // 重试次数用尽仍失败
+)
+
+// NotificationDelivery 紧急事件发给紧急联系人的通知，每个联系人的每个渠道一条
+type NotificationDelivery struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	EmergencyID uint       `json:"emergency_id" gorm:"not null;index"`
+	ContactID   uint       `json:"contact_id" gorm:"not null"`
+	ContactName string     `json:"contact_name" gorm:"size:50"`
+	Channel     string     `json:"channel" gorm:"size:20;not null"`
+	Recipient   string     `json:"recipient" gorm:"size:255;not null;serializer:encrypted"` // 加密存储
+	Subject     string     `json:"subject" gorm:"size:200"`
+	Content     string     `json:"content" gorm:"type:text;serializer:encrypted"` // 包含报警人的手机号和位置，加密存储
+	Priority    int        `json:"priority"`                                      // 发送顺序，默认联系人在前
+	Status      string     `json:"status" gorm:"size:20;not null;index"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error" gorm:"type:text"`
+	SentAt      *time.Time `json:"sent_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+// MaskSensitive 接收方和通知内容中的手机号脱敏，用于没有查看敏感信息权限的调用方
+func (d *NotificationDelivery) MaskSensitive() {
+	d.Recipient = sensitive.MaskContact(d.Recipient)
+	d.Content = sensitive.MaskPhonesIn(d.Content)
+}
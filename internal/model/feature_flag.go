@@ -0,0 +1,22 @@
+package model
+
+// FeatureFlagPrefix 功能开关在系统配置中的键前缀，如 feature.new_dispatch
+const FeatureFlagPrefix = "feature."
+
+// FeatureFlag 功能开关，以 json 类型的系统配置保存。
+// Enabled 为 false 时对所有用户关闭；为 true 且未设置任何灰度规则时对所有用户开启；
+// 设置了灰度规则时，命中任一规则（指定用户、角色、城市或比例）的用户开启
+type FeatureFlag struct {
+	Enabled    bool     `json:"enabled"`
+	Percentage *int     `json:"percentage,omitempty"` // 按用户ID稳定分桶后开启的比例，0-100
+	Users      []uint   `json:"users,omitempty"`      // 指定开启的用户ID
+	Roles      []string `json:"roles,omitempty"`      // 指定开启的角色
+	Cities     []string `json:"cities,omitempty"`     // 指定开启的城市，城市由客户端上报，仅供参考
+}
+
+// FeatureContext 计算功能开关所需的用户信息
+type FeatureContext struct {
+	UserID uint
+	Roles  []string
+	City   string
+}
@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// 地理围栏事件
+const (
+	GeofenceEventEnter = "enter" // 进入危险区域
+	GeofenceEventExit  = "exit"  // 离开危险区域
+)
+
+// GeofenceState 用户相对危险区域的当前状态，用于判断进出并对提醒去重
+type GeofenceState struct {
+	UserID      uint       `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	ZoneID      uint       `json:"zone_id" gorm:"primaryKey;autoIncrement:false"`
+	Inside      bool       `json:"inside"`
+	LastAlertAt *time.Time `json:"last_alert_at"` // 最近一次发出进入提醒的时间
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (GeofenceState) TableName() string {
+	return "geofence_states"
+}
+
+// GeofenceEvent 用户进出危险区域的记录
+type GeofenceEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	ZoneID    uint      `json:"zone_id" gorm:"not null;index:idx_geofence_events_zone_created"`
+	Event     string    `json:"event" gorm:"size:10;not null"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Alerted   bool      `json:"alerted"` // 是否向用户发出了提醒，冷却期内重复进入不再提醒
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_geofence_events_zone_created"`
+}
+
+// TableName 指定表名
+func (GeofenceEvent) TableName() string {
+	return "geofence_events"
+}
+
+// GeofenceAlert 推送给用户的危险区域提醒
+type GeofenceAlert struct {
+	ZoneID      uint    `json:"zone_id"`
+	Name        string  `json:"name"`
+	Level       string  `json:"level"`
+	Description string  `json:"description"`
+	Distance    float64 `json:"distance"` // 到区域中心的距离（米）
+}
+
+// GeofenceResult 位置上报的处理结果
+type GeofenceResult struct {
+	Inside  []uint          `json:"inside"`  // 当前所在的危险区域
+	Entered []uint          `json:"entered"` // 本次进入的危险区域
+	Exited  []uint          `json:"exited"`  // 本次离开的危险区域
+	Alerts  []GeofenceAlert `json:"alerts"`  // 本次发出的提醒
+}
+
+// GeofenceStat 危险区域的进出统计
+type GeofenceStat struct {
+	ZoneID  uint  `json:"zone_id"`
+	Entries int64 `json:"entries"`
+	Exits   int64 `json:"exits"`
+	Alerts  int64 `json:"alerts"`
+}
+
+// ReportLocationRequest 用户位置上报请求
+type ReportLocationRequest struct {
+	Latitude  float64 `json:"latitude" binding:"required"`
+	Longitude float64 `json:"longitude" binding:"required"`
+}
+
+// UpdateLocationAlertsRequest 开启或关闭危险区域提醒请求
+type UpdateLocationAlertsRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// HeatmapQuery 热力图查询条件
+type HeatmapQuery struct {
+	MinLat    float64
+	MaxLat    float64
+	MinLng    float64
+	MaxLng    float64
+	From      time.Time
+	To        time.Time
+	Precision int      // geohash 精度，0 表示按范围自动选择
+	Types     []string // 事件类型，为空时不过滤
+	Statuses  []int    // 事件状态，为空时不过滤
+}
+
+// HeatmapCell 热力图格子
+type HeatmapCell struct {
+	Geohash   string  `json:"geohash"`
+	Latitude  float64 `json:"latitude"`  // 格子中心纬度
+	Longitude float64 `json:"longitude"` // 格子中心经度
+	Count     int     `json:"count"`     // 事件数
+	Weight    float64 `json:"weight"`    // 按严重程度加权的事件数
+}
+
+// Heatmap 紧急事件热力图
+type Heatmap struct {
+	Precision int           `json:"precision"`
+	Cells     []HeatmapCell `json:"cells"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// StaffLocation 安保人员位置轨迹点，每次上报位置都会保存一条
+type StaffLocation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	StaffID     uint      `json:"staff_id" gorm:"index:idx_staff_recorded;not null"` // 安保人员的用户ID
+	EmergencyID uint      `json:"emergency_id" gorm:"index"`                         // 上报时正在处理的事件，空闲时为0
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	RecordedAt  time.Time `json:"recorded_at" gorm:"index:idx_staff_recorded"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (StaffLocation) TableName() string {
+	return "staff_locations"
+}
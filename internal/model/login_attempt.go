@@ -0,0 +1,29 @@
+package model
+
+import (
+	"dididaren/pkg/sensitive"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttempt 登录尝试记录，用于按手机号和 IP 统计失败次数
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Phone     string    `json:"-" gorm:"-"`             // 只用于计算盲索引，不落库
+	PhoneHash string    `json:"-" gorm:"size:64;index"` // 手机号盲索引，用于按手机号统计
+	IP        string    `json:"ip" gorm:"size:64;index"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// BeforeCreate 写入前计算手机号盲索引
+func (a *LoginAttempt) BeforeCreate(tx *gorm.DB) (err error) {
+	a.PhoneHash, err = sensitive.BlindIndex(a.Phone)
+	return err
+}
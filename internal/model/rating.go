@@ -19,7 +19,7 @@ type Rating struct {
 // CreateRatingRequest 创建评价请求
 type CreateRatingRequest struct {
 	StaffID  uint    `json:"staff_id" binding:"required"`
-	UserID   uint    `json:"user_id" binding:"required"`
+	UserID   uint    `json:"-"` // 评价人，取自登录用户
 	Score    float32 `json:"score" binding:"required,min=0,max=5"`
 	Comment  string  `json:"comment" binding:"required,min=1,max=500"`
 	IsPublic bool    `json:"is_public"`
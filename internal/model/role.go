@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// UserRole 授予用户的角色。普通用户角色、系统管理员标记和通过审核的安保人员角色由系统推导，不在此表中
+type UserRole struct {
+	UserID    uint      `json:"user_id" gorm:"primaryKey"`
+	Role      string    `json:"role" gorm:"primaryKey;size:32"`
+	GrantedBy uint      `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// GrantRoleRequest 授予角色请求
+type GrantRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// UserRoles 用户当前拥有的全部角色
+type UserRoles struct {
+	UserID  uint     `json:"user_id"`
+	Roles   []string `json:"roles"`   // 全部角色，包括系统推导的角色
+	Granted []string `json:"granted"` // 其中由管理员授予、可以撤销的角色
+}
@@ -1,24 +1,36 @@
 package model
 
 import (
+	"dididaren/pkg/sensitive"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// Staff 安保人员模型
+// 安保人员申请状态
+const (
+	StaffStatusPending  = "pending"  // 待审核
+	StaffStatusActive   = "active"   // 审核通过，可以上线接单
+	StaffStatusRejected = "rejected" // 审核未通过，可补充材料后重新申请
+	StaffStatusInactive = "inactive" // 已停用
+)
+
+// Staff 安保人员，包括申请审核资料和派单使用的在线状态、实时位置。
+// 手机号和身份证号加密存储
 type Staff struct {
 	gorm.Model
-	UserID      uint   `gorm:"not null" json:"user_id"`
-	Name        string `gorm:"size:50;not null" json:"name"`
-	Phone       string `gorm:"size:20;not null" json:"phone"`
-	IDCard      string `gorm:"size:18;not null" json:"id_card"`
-	Status      string `gorm:"size:20;not null;default:'pending'" json:"status"` // pending, active, inactive
-	Rating      float64 `gorm:"default:0" json:"rating"`
-	TotalOrders int    `gorm:"default:0" json:"total_orders"`
-	Latitude    float64 `json:"latitude"`
-	Longitude   float64 `json:"longitude"`
-	LastActive  time.Time `json:"last_active"`
+	UserID       uint      `gorm:"not null;index" json:"user_id"`
+	Name         string    `gorm:"size:50;not null" json:"name"`
+	Phone        string    `gorm:"size:255;not null;serializer:encrypted" json:"phone"`
+	IDCard       string    `gorm:"size:255;not null;serializer:encrypted" json:"id_card"`
+	Status       string    `gorm:"size:20;not null;default:'pending'" json:"status"` // pending, active, rejected, inactive
+	ReviewReason string    `gorm:"size:255" json:"review_reason"`                    // 最近一次审核或状态变更的原因
+	IsOnline     bool      `gorm:"default:false" json:"is_online"`
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	Rating       float64   `gorm:"default:0" json:"rating"`
+	TotalOrders  int       `gorm:"default:0" json:"total_orders"`
+	LastActive   time.Time `json:"last_active"`
 }
 
 // TableName 指定表名
@@ -26,9 +38,75 @@ func (Staff) TableName() string {
 	return "staffs"
 }
 
+// MaskSensitive 手机号和身份证号脱敏，用于没有查看敏感信息权限的调用方
+func (s *Staff) MaskSensitive() {
+	s.Phone = sensitive.MaskPhone(s.Phone)
+	s.IDCard = sensitive.MaskIDCard(s.IDCard)
+}
+
 // CreateStaffRequest 创建安保人员请求
 type CreateStaffRequest struct {
 	Name   string `json:"name" binding:"required"`
 	Phone  string `json:"phone" binding:"required"`
 	IDCard string `json:"id_card" binding:"required"`
-} 
\ No newline at end of file
+}
+
+// ApplyStaffRequest 申请成为安保人员请求
+type ApplyStaffRequest struct {
+	Name   string `json:"name" binding:"required,max=50"`
+	Phone  string `json:"phone" binding:"required"`
+	IDCard string `json:"id_card" binding:"required,len=18"`
+}
+
+// UpdateStaffStatusRequest 审核或变更安保人员状态请求，原因必填并通知申请人
+type UpdateStaffStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active rejected inactive"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// 申请材料类型
+const (
+	StaffDocumentIDCardFront = "id_card_front" // 身份证人像面
+	StaffDocumentIDCardBack  = "id_card_back"  // 身份证国徽面
+	StaffDocumentLicence     = "licence"       // 保安员证
+)
+
+// StaffDocument 安保人员申请材料，文件内容保存在文件存储中
+type StaffDocument struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	StaffID     uint      `gorm:"not null;index" json:"staff_id"`
+	Type        string    `gorm:"size:20;not null" json:"type"`
+	FileKey     string    `gorm:"size:255;not null" json:"-"`
+	FileName    string    `gorm:"size:255" json:"file_name"`
+	ContentType string    `gorm:"size:100" json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (StaffDocument) TableName() string {
+	return "staff_documents"
+}
+
+// StaffStatusHistory 安保人员状态变更记录，申请、审核和停用都会留下一条
+type StaffStatusHistory struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	StaffID    uint      `gorm:"not null;index" json:"staff_id"`
+	FromStatus string    `gorm:"size:20" json:"from_status"` // 首次申请时为空
+	ToStatus   string    `gorm:"size:20;not null" json:"to_status"`
+	OperatorID uint      `json:"operator_id"` // 操作人用户ID，申请人提交申请时为申请人本人
+	Reason     string    `gorm:"size:255" json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (StaffStatusHistory) TableName() string {
+	return "staff_status_histories"
+}
+
+// StaffApplication 安保人员申请详情
+type StaffApplication struct {
+	Staff     *Staff               `json:"staff"`
+	Documents []StaffDocument      `json:"documents"`
+	History   []StaffStatusHistory `json:"history"`
+}
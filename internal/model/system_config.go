@@ -4,28 +4,85 @@ import (
 	"time"
 )
 
+// 配置值类型
+const (
+	ConfigTypeString     = "string"      // 字符串
+	ConfigTypeInt        = "int"         // 整数
+	ConfigTypeFloat      = "float"       // 小数
+	ConfigTypeBool       = "bool"        // true 或 false
+	ConfigTypeDuration   = "duration"    // 时长，如 30s、5m、1h30m
+	ConfigTypeJSON       = "json"        // 任意 JSON
+	ConfigTypeStringList = "string_list" // 字符串数组，JSON 格式，如 ["sms","email"]
+)
+
 // SystemConfig 系统配置模型
 type SystemConfig struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Key       string    `json:"key" gorm:"uniqueIndex;not null"`
-	Value     string    `json:"value" gorm:"not null"`
-	Type      string    `json:"type" gorm:"not null"`
-	Desc      string    `json:"desc"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	Key       string        `json:"key" gorm:"uniqueIndex;not null"`
+	Value     string        `json:"value" gorm:"not null"`
+	Type      string        `json:"type" gorm:"not null"`
+	Schema    *ConfigSchema `json:"schema,omitempty" gorm:"type:text;serializer:json"` // 值的取值范围，为空表示不限制
+	Desc      string        `json:"desc"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// ConfigSchema 配置值的取值范围。
+// Min、Max 用于 int、float 和 duration（单位为秒），Enum 用于 string、int 和 string_list 的每一项
+type ConfigSchema struct {
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+	Enum []string `json:"enum,omitempty"`
 }
 
 // CreateSystemConfigRequest 创建系统配置请求
 type CreateSystemConfigRequest struct {
-	Key   string `json:"key" binding:"required"`
-	Value string `json:"value" binding:"required"`
-	Type  string `json:"type" binding:"required"`
-	Desc  string `json:"desc"`
+	Key    string        `json:"key" binding:"required"`
+	Value  string        `json:"value" binding:"required"`
+	Type   string        `json:"type" binding:"required"`
+	Schema *ConfigSchema `json:"schema"`
+	Desc   string        `json:"desc"`
 }
 
 // UpdateSystemConfigRequest 更新系统配置请求
 type UpdateSystemConfigRequest struct {
-	Value string `json:"value" binding:"required"`
-	Type  string `json:"type" binding:"required"`
-	Desc  string `json:"desc"`
+	Value  string        `json:"value" binding:"required"`
+	Type   string        `json:"type" binding:"required"`
+	Schema *ConfigSchema `json:"schema"`
+	Desc   string        `json:"desc"`
+}
+
+// 配置变更类型
+const (
+	ConfigActionCreate   = "create"
+	ConfigActionUpdate   = "update"
+	ConfigActionDelete   = "delete"
+	ConfigActionRollback = "rollback"
+)
+
+// SystemConfigHistory 系统配置变更记录，每次创建、修改、删除和回滚都会留下一条。
+// Type、Schema、Desc 为变更后的配置，用于回滚；删除记录中为删除前的配置
+type SystemConfigHistory struct {
+	ID         uint          `gorm:"primaryKey" json:"id"`
+	ConfigKey  string        `gorm:"size:100;not null;uniqueIndex:idx_config_key_version" json:"key"`
+	Version    int           `gorm:"not null;uniqueIndex:idx_config_key_version" json:"version"` // 同一配置键从1开始递增
+	Action     string        `gorm:"size:20;not null" json:"action"`
+	OldValue   *string       `gorm:"type:text" json:"old_value"` // 创建时为空
+	NewValue   *string       `gorm:"type:text" json:"new_value"` // 删除时为空
+	Type       string        `gorm:"size:20" json:"type"`
+	Schema     *ConfigSchema `gorm:"type:text;serializer:json" json:"schema,omitempty"`
+	Desc       string        `json:"desc"`
+	RollbackTo int           `json:"rollback_to,omitempty"` // 回滚时为回滚到的版本号
+	OperatorID uint          `json:"operator_id"`           // 操作人用户ID，迁移时补录的记录为0
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// TableName 指定表名
+func (SystemConfigHistory) TableName() string {
+	return "system_config_histories"
+}
+
+// RollbackSystemConfigRequest 回滚系统配置请求
+type RollbackSystemConfigRequest struct {
+	Version int `json:"version" binding:"required,min=1"`
 }
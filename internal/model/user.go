@@ -1,20 +1,39 @@
 package model
 
 import (
+	"dididaren/pkg/sensitive"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// 用户状态
+const (
+	UserStatusNormal  = 1 // 正常
+	UserStatusLocked  = 2 // 登录失败次数过多，临时锁定
+	UserStatusDeleted = 3 // 已注销，个人信息已匿名化
 )
 
 // User 用户模型
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Phone     string    `json:"phone" gorm:"uniqueIndex;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	Name      string    `json:"name"`
-	Avatar    string    `json:"avatar"`
-	IsAdmin   bool      `json:"is_admin" gorm:"default:false"`
-	Status    int       `json:"status" gorm:"default:1"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Phone          string     `json:"phone" gorm:"size:255;not null;serializer:encrypted"` // 加密存储
+	PhoneHash      string     `json:"-" gorm:"size:64;uniqueIndex"`                        // 手机号盲索引，用于按手机号查询
+	Password       string     `json:"-" gorm:"not null"`
+	Name           string     `json:"name"`
+	Avatar         string     `json:"avatar"`
+	IsAdmin        bool       `json:"is_admin" gorm:"default:false"`
+	Status         int        `json:"status" gorm:"default:1"`
+	LockedUntil    *time.Time `json:"locked_until"`                         // 临时锁定的解除时间
+	LocationAlerts bool       `json:"location_alerts" gorm:"default:false"` // 是否开启后台位置上报和危险区域提醒
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BeforeSave 写入前更新手机号盲索引
+func (u *User) BeforeSave(tx *gorm.DB) (err error) {
+	u.PhoneHash, err = sensitive.BlindIndex(u.Phone)
+	return err
 }
 
 // RegisterRequest 注册请求
@@ -22,12 +41,14 @@ type RegisterRequest struct {
 	Phone    string `json:"phone" binding:"required"`
 	Password string `json:"password" binding:"required,min=6"`
 	Name     string `json:"name" binding:"required"`
+	Code     string `json:"code" binding:"required"` // 注册验证码
 }
 
 // LoginRequest 登录请求
 type LoginRequest struct {
-	Phone    string `json:"phone" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Phone      string `json:"phone" binding:"required"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name"` // 设备名称，显示在登录会话列表中
 }
 
 // LoginResponse 登录响应
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// 验证码用途，不同用途的验证码不能混用
+const (
+	VerifyCodePurposeLogin    = "login"
+	VerifyCodePurposeRegister = "register"
+)
+
+// VerificationCode 短信验证码，数据库中只保存哈希
+type VerificationCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Phone     string     `json:"phone" gorm:"size:20;not null;index:idx_verification_codes_phone"`
+	Purpose   string     `json:"purpose" gorm:"size:20;not null"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	IP        string     `json:"ip" gorm:"size:64;index"`
+	Attempts  int        `json:"attempts" gorm:"default:0"` // 校验失败次数
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index:idx_verification_codes_phone"`
+}
+
+// TableName 指定表名
+func (VerificationCode) TableName() string {
+	return "verification_codes"
+}
+
+// SendCodeRequest 发送验证码请求
+type SendCodeRequest struct {
+	Phone   string `json:"phone" binding:"required"`
+	Purpose string `json:"purpose" binding:"required,oneof=login register"`
+}
+
+// CodeLoginRequest 验证码登录请求
+type CodeLoginRequest struct {
+	Phone      string `json:"phone" binding:"required"`
+	Code       string `json:"code" binding:"required"`
+	DeviceName string `json:"device_name"`
+}
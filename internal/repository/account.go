@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+	"dididaren/pkg/sensitive"
+
+	"gorm.io/gorm"
+)
+
+// AccountRepository 跨表读取和匿名化用户的个人数据
+type AccountRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRepository(db *gorm.DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+// Export 读取用户的个人数据，export.Profile 须已填充
+func (r *AccountRepository) Export(export *model.AccountExport) error {
+	userID := export.Profile.ID
+
+	var staff model.Staff
+	if err := r.db.Where("user_id = ?", userID).Limit(1).Find(&staff).Error; err != nil {
+		return err
+	}
+	if staff.ID != 0 {
+		export.Staff = &staff
+		if err := r.db.Where("staff_id = ?", staff.ID).Order("id").Find(&export.StaffDocuments).Error; err != nil {
+			return err
+		}
+		if err := r.db.Where("staff_id = ?", staff.ID).Order("id").Find(&export.StaffHistory).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&export.Contacts).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&export.Emergencies).Error; err != nil {
+		return err
+	}
+
+	emergencyIDs := make([]uint, len(export.Emergencies))
+	for i, emergency := range export.Emergencies {
+		emergencyIDs[i] = emergency.ID
+	}
+	err := r.db.Where("emergency_id IN ? OR staff_id = ?", emergencyIDs, userID).
+		Order("emergency_id, id").
+		Find(&export.HandlingRecords).Error
+	if err != nil {
+		return err
+	}
+
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&export.RatingsGiven).Error; err != nil {
+		return err
+	}
+	return r.db.Where("staff_id = ?", userID).Order("id").Find(&export.RatingsReceived).Error
+}
+
+// CountActiveEmergencies 统计用户作为报警人或接单安保人员的进行中事件
+func (r *AccountRepository) CountActiveEmergencies(userID uint, statuses []int) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Emergency{}).
+		Where("(user_id = ? OR staff_id = ?) AND status IN ?", userID, userID, statuses).
+		Count(&count).Error
+	return count, err
+}
+
+// Anonymize 在一个事务中匿名化用户的个人数据。
+// 紧急事件、处理记录和处理事件期间的轨迹作为事件档案保留，只通过用户ID关联到已匿名化的用户；
+// 空闲时上报的轨迹、紧急联系人等第三方信息、登录凭据和各类辅助记录直接删除
+// user 为已匿名化的用户，originalPhone 为匿名化前的手机号。
+// 返回已删除的安保人员申请材料的文件 key，由调用方在事务提交后删除文件
+func (r *AccountRepository) Anonymize(user *model.User, originalPhone string) ([]string, error) {
+	phoneHash, err := sensitive.BlindIndex(originalPhone)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileKeys []string
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+
+		// 紧急联系人及发给联系人的通知中的联系方式
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&model.EmergencyContact{}).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&model.NotificationDelivery{}).
+			Where("emergency_id IN (?)", tx.Model(&model.Emergency{}).Select("id").Where("user_id = ?", user.ID)).
+			Updates(map[string]interface{}{"contact_name": "", "recipient": "", "content": ""}).Error
+		if err != nil {
+			return err
+		}
+
+		// 评价保留分数，删除评价内容
+		if err := tx.Model(&model.Rating{}).Where("user_id = ?", user.ID).Update("comment", "").Error; err != nil {
+			return err
+		}
+
+		// 安保人员资料和申请材料，状态变更记录作为审核档案保留
+		err = tx.Model(&model.Staff{}).Where("user_id = ?", user.ID).
+			Updates(map[string]interface{}{"name": user.Name, "phone": "", "id_card": "", "status": model.StaffStatusInactive, "is_online": false}).Error
+		if err != nil {
+			return err
+		}
+		staffIDs := tx.Model(&model.Staff{}).Select("id").Where("user_id = ?", user.ID)
+		err = tx.Model(&model.StaffDocument{}).Where("staff_id IN (?)", staffIDs).Pluck("file_key", &fileKeys).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("staff_id IN (?)", staffIDs).Delete(&model.StaffDocument{}).Error; err != nil {
+			return err
+		}
+
+		// 空闲时上报的轨迹与任何事件无关，只是安保人员的行踪
+		err = tx.Where("staff_id = ? AND emergency_id = ?", user.ID, 0).Delete(&model.StaffLocation{}).Error
+		if err != nil {
+			return err
+		}
+
+		// 登录凭据、角色和辅助记录
+		deletes := []struct {
+			query string
+			arg   interface{}
+			model interface{}
+		}{
+			{"user_id = ?", user.ID, &model.Session{}},
+			{"user_id = ?", user.ID, &model.RefreshToken{}},
+			{"user_id = ?", user.ID, &model.UserRole{}},
+			{"user_id = ?", user.ID, &model.GeofenceState{}},
+			{"phone_hash = ?", phoneHash, &model.LoginAttempt{}},
+			{"phone = ?", originalPhone, &model.VerificationCode{}},
+		}
+		for _, d := range deletes {
+			if err := tx.Where(d.query, d.arg).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fileKeys, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+	"dididaren/pkg/sensitive"
+
+	"gorm.io/gorm"
+)
+
+type ContactRepository struct {
+	db *gorm.DB
+}
+
+func NewContactRepository(db *gorm.DB) *ContactRepository {
+	return &ContactRepository{db: db}
+}
+
+// ListByUser 获取用户的紧急联系人，默认联系人在前
+func (r *ContactRepository) ListByUser(userID uint) ([]model.EmergencyContact, error) {
+	var contacts []model.EmergencyContact
+	err := r.db.Where("user_id = ?", userID).
+		Order("is_default DESC, id").
+		Find(&contacts).Error
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// ListVerifiedByUser 获取用户已确认的紧急联系人，默认联系人在前
+func (r *ContactRepository) ListVerifiedByUser(userID uint) ([]model.EmergencyContact, error) {
+	var contacts []model.EmergencyContact
+	err := r.db.Where("user_id = ? AND status = ?", userID, model.ContactStatusVerified).
+		Order("is_default DESC, id").
+		Find(&contacts).Error
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// GetByID 根据ID获取紧急联系人，不存在时返回 nil
+func (r *ContactRepository) GetByID(id uint) (*model.EmergencyContact, error) {
+	var contact model.EmergencyContact
+	err := r.db.First(&contact, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// CountByUser 统计用户的紧急联系人数量
+func (r *ContactRepository) CountByUser(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.EmergencyContact{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// ExistsByPhone 检查用户是否已添加该手机号，excludeID 为修改中的联系人
+func (r *ContactRepository) ExistsByPhone(userID uint, phone string, excludeID uint) (bool, error) {
+	phoneHash, err := sensitive.BlindIndex(phone)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	err = r.db.Model(&model.EmergencyContact{}).
+		Where("user_id = ? AND phone_hash = ? AND id <> ?", userID, phoneHash, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Create 添加紧急联系人，设为默认时同时取消其他联系人的默认标记
+func (r *ContactRepository) Create(contact *model.EmergencyContact) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if contact.IsDefault {
+			if err := clearDefaultContact(tx, contact.UserID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(contact).Error
+	})
+}
+
+// Update 保存紧急联系人，默认标记只通过 SetDefault 修改
+func (r *ContactRepository) Update(contact *model.EmergencyContact) error {
+	return r.db.Omit("is_default").Save(contact).Error
+}
+
+// SetDefault 将联系人设为用户唯一的默认联系人
+func (r *ContactRepository) SetDefault(userID, id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := clearDefaultContact(tx, userID); err != nil {
+			return err
+		}
+		return tx.Model(&model.EmergencyContact{}).
+			Where("id = ? AND user_id = ?", id, userID).
+			Update("is_default", true).Error
+	})
+}
+
+// Delete 删除紧急联系人。删除的是默认联系人时，
+// 将剩余联系人中最早添加的一个设为默认，已确认的优先
+func (r *ContactRepository) Delete(contact *model.EmergencyContact) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(contact).Error; err != nil {
+			return err
+		}
+		if !contact.IsDefault {
+			return nil
+		}
+
+		var next model.EmergencyContact
+		err := tx.Where("user_id = ?", contact.UserID).
+			Order(gorm.Expr("CASE WHEN status = ? THEN 0 ELSE 1 END, id", model.ContactStatusVerified)).
+			First(&next).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+}
+
+// clearDefaultContact 取消用户所有联系人的默认标记
+func clearDefaultContact(tx *gorm.DB, userID uint) error {
+	return tx.Model(&model.EmergencyContact{}).
+		Where("user_id = ? AND is_default = ?", userID, true).
+		Update("is_default", false).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"dididaren/internal/model"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -57,24 +58,51 @@ func (r *DangerZoneRepository) DeleteDangerZone(id uint) error {
 	return r.db.Delete(&model.DangerZone{}, id).Error
 }
 
-// GetNearbyDangerZones 获取附近的危险区域
-func (r *DangerZoneRepository) GetNearbyDangerZones(lat, lng float64) ([]model.DangerZone, error) {
+// ListByIDs 根据ID批量获取危险区域
+func (r *DangerZoneRepository) ListByIDs(ids []uint) ([]model.DangerZone, error) {
 	var zones []model.DangerZone
-	// 这里使用简单的经纬度范围查询，实际项目中可能需要使用更复杂的空间查询
-	// 例如使用 MySQL 的空间索引和 ST_Distance_Sphere 函数
-	err := r.db.Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
-		lat-0.1, lat+0.1, lng-0.1, lng+0.1).
-		Find(&zones).Error
+	if len(ids) == 0 {
+		return zones, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&zones).Error
 	if err != nil {
 		return nil, err
 	}
 	return zones, nil
 }
 
+// ListAll 获取所有危险区域
+func (r *DangerZoneRepository) ListAll() ([]*model.DangerZone, error) {
+	var zones []*model.DangerZone
+	err := r.db.Order("id").Find(&zones).Error
+	if err != nil {
+		return nil, err
+	}
+	return zones, nil
+}
+
+// SaveZones 在一个事务中批量保存危险区域，ID 为0的新建，其余更新
+func (r *DangerZoneRepository) SaveZones(zones []*model.DangerZone) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, zone := range zones {
+			var err error
+			if zone.ID == 0 {
+				err = tx.Create(zone).Error
+			} else {
+				err = tx.Save(zone).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // GetAllActiveZones 获取所有活跃的危险区域
 func (r *DangerZoneRepository) GetAllActiveZones() ([]*model.DangerZone, error) {
 	var zones []*model.DangerZone
-	err := r.db.Where("status = ?", 1).Find(&zones).Error
+	err := r.db.Where("is_active = ?", true).Find(&zones).Error
 	if err != nil {
 		return nil, err
 	}
@@ -87,3 +115,23 @@ func (r *DangerZoneRepository) UpdateHeatLevel(id uint, heatLevel int) error {
 		Where("id = ?", id).
 		Update("heat_level", heatLevel).Error
 }
+
+// CreateHeatHistories 批量写入热度历史
+func (r *DangerZoneRepository) CreateHeatHistories(histories []*model.DangerZoneHeatHistory) error {
+	if len(histories) == 0 {
+		return nil
+	}
+	return r.db.Create(&histories).Error
+}
+
+// ListHeatHistory 获取危险区域在时间范围内的热度历史，按时间排序
+func (r *DangerZoneRepository) ListHeatHistory(zoneID uint, from, to time.Time) ([]model.DangerZoneHeatHistory, error) {
+	var histories []model.DangerZoneHeatHistory
+	err := r.db.Where("zone_id = ? AND created_at BETWEEN ? AND ?", zoneID, from, to).
+		Order("created_at").
+		Find(&histories).Error
+	if err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
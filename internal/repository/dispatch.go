@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DispatchRepository struct {
+	db *gorm.DB
+}
+
+func NewDispatchRepository(db *gorm.DB) *DispatchRepository {
+	return &DispatchRepository{db: db}
+}
+
+// CreateOffer 创建派单记录
+func (r *DispatchRepository) CreateOffer(offer *model.DispatchOffer) error {
+	return r.db.Create(offer).Error
+}
+
+// GetPendingOffer 获取安保人员在某事件上等待响应的派单
+func (r *DispatchRepository) GetPendingOffer(emergencyID, staffID uint) (*model.DispatchOffer, error) {
+	var offer model.DispatchOffer
+	err := r.db.Where("emergency_id = ? AND staff_id = ? AND status = ?",
+		emergencyID, staffID, model.OfferStatusPending).
+		First(&offer).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// ListOffers 获取事件的所有派单记录
+func (r *DispatchRepository) ListOffers(emergencyID uint) ([]model.DispatchOffer, error) {
+	var offers []model.DispatchOffer
+	err := r.db.Where("emergency_id = ?", emergencyID).Order("id").Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// ListPendingOffersByStaff 获取安保人员所有等待响应的派单
+func (r *DispatchRepository) ListPendingOffersByStaff(staffID uint) ([]model.DispatchOffer, error) {
+	var offers []model.DispatchOffer
+	err := r.db.Where("staff_id = ? AND status = ? AND expires_at > ?",
+		staffID, model.OfferStatusPending, time.Now()).
+		Order("id").Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// ListPendingOffersByRound 获取事件某一轮中仍在等待响应的派单
+func (r *DispatchRepository) ListPendingOffersByRound(emergencyID uint, round int) ([]model.DispatchOffer, error) {
+	var offers []model.DispatchOffer
+	err := r.db.Where("emergency_id = ? AND round = ? AND status = ?",
+		emergencyID, round, model.OfferStatusPending).
+		Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// ListOfferedStaffIDs 获取 afterRound 轮之后已经向其派过该事件的安保人员
+func (r *DispatchRepository) ListOfferedStaffIDs(emergencyID uint, afterRound int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.DispatchOffer{}).
+		Where("emergency_id = ? AND round > ?", emergencyID, afterRound).
+		Distinct().Pluck("staff_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ListEngagedStaffIDs 获取当前有其他事件派单等待响应的安保人员
+func (r *DispatchRepository) ListEngagedStaffIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.DispatchOffer{}).
+		Where("status = ? AND expires_at > ?", model.OfferStatusPending, time.Now()).
+		Distinct().Pluck("staff_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetLatestRound 获取事件当前的派单轮次，尚未派单时返回0
+func (r *DispatchRepository) GetLatestRound(emergencyID uint) (int, error) {
+	var round int
+	err := r.db.Model(&model.DispatchOffer{}).
+		Where("emergency_id = ?", emergencyID).
+		Select("COALESCE(MAX(round), 0)").
+		Scan(&round).Error
+	if err != nil {
+		return 0, err
+	}
+	return round, nil
+}
+
+// GetLatestRoundBefore 获取事件在 before 之前发起的最后一轮派单，没有时返回0
+func (r *DispatchRepository) GetLatestRoundBefore(emergencyID uint, before time.Time) (int, error) {
+	var round int
+	err := r.db.Model(&model.DispatchOffer{}).
+		Where("emergency_id = ? AND created_at < ?", emergencyID, before).
+		Select("COALESCE(MAX(round), 0)").
+		Scan(&round).Error
+	if err != nil {
+		return 0, err
+	}
+	return round, nil
+}
+
+// RespondOffer 将等待响应的派单置为指定状态，返回是否更新成功
+func (r *DispatchRepository) RespondOffer(id uint, status, reason string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&model.DispatchOffer{}).
+		Where("id = ? AND status = ?", id, model.OfferStatusPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"reason":       reason,
+			"responded_at": &now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CloseRound 将事件某一轮中仍在等待响应的派单置为指定状态
+func (r *DispatchRepository) CloseRound(emergencyID uint, round int, status string) ([]model.DispatchOffer, error) {
+	offers, err := r.ListPendingOffersByRound(emergencyID, round)
+	if err != nil {
+		return nil, err
+	}
+
+	var closed []model.DispatchOffer
+	for _, offer := range offers {
+		ok, err := r.RespondOffer(offer.ID, status, "")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			offer.Status = status
+			closed = append(closed, offer)
+		}
+	}
+	return closed, nil
+}
+
+// CancelPendingOffers 取消事件所有等待响应的派单，返回被取消的派单
+func (r *DispatchRepository) CancelPendingOffers(emergencyID uint) ([]model.DispatchOffer, error) {
+	var offers []model.DispatchOffer
+	err := r.db.Where("emergency_id = ? AND status = ?", emergencyID, model.OfferStatusPending).
+		Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var cancelled []model.DispatchOffer
+	for _, offer := range offers {
+		ok, err := r.RespondOffer(offer.ID, model.OfferStatusCancelled, "")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			offer.Status = model.OfferStatusCancelled
+			cancelled = append(cancelled, offer)
+		}
+	}
+	return cancelled, nil
+}
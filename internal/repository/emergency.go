@@ -2,6 +2,7 @@ package repository
 
 import (
 	"dididaren/internal/model"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -47,9 +48,9 @@ func (r *EmergencyRepository) List(page, size int) ([]model.Emergency, int64, er
 	return emergencies, total, nil
 }
 
-// Update 更新紧急事件
-func (r *EmergencyRepository) Update(emergency *model.Emergency) error {
-	return r.db.Save(emergency).Error
+// UpdateDetails 更新紧急事件的指定字段
+func (r *EmergencyRepository) UpdateDetails(id uint, fields map[string]interface{}) error {
+	return r.db.Model(&model.Emergency{}).Where("id = ?", id).Updates(fields).Error
 }
 
 // Delete 删除紧急事件
@@ -71,3 +72,70 @@ func (r *EmergencyRepository) ListHandlingRecords(emergencyID uint) ([]model.Han
 	}
 	return records, nil
 }
+
+// ListByStatus 获取指定状态的紧急事件
+func (r *EmergencyRepository) ListByStatus(statuses ...int) ([]model.Emergency, error) {
+	var emergencies []model.Emergency
+	err := r.db.Where("status IN ?", statuses).Find(&emergencies).Error
+	if err != nil {
+		return nil, err
+	}
+	return emergencies, nil
+}
+
+// TransitionStatus 仅当事件处于 from 状态时更新状态及附带字段，返回是否更新成功
+func (r *EmergencyRepository) TransitionStatus(id uint, from, to int, fields map[string]interface{}) (bool, error) {
+	updates := map[string]interface{}{"status": to}
+	for column, value := range fields {
+		updates[column] = value
+	}
+
+	result := r.db.Model(&model.Emergency{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListStaffIDsByStatus 获取正在处理指定状态事件的安保人员
+func (r *EmergencyRepository) ListStaffIDsByStatus(statuses ...int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.Emergency{}).
+		Where("staff_id <> 0 AND status IN ?", statuses).
+		Distinct().Pluck("staff_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ListCreatedSince 获取指定时间之后创建的紧急事件
+func (r *EmergencyRepository) ListCreatedSince(since time.Time) ([]model.Emergency, error) {
+	var emergencies []model.Emergency
+	err := r.db.Where("created_at >= ?", since).Find(&emergencies).Error
+	if err != nil {
+		return nil, err
+	}
+	return emergencies, nil
+}
+
+// ListForHeatmap 获取范围和时间段内符合条件的紧急事件，只查询热力图需要的字段
+func (r *EmergencyRepository) ListForHeatmap(query *model.HeatmapQuery) ([]model.Emergency, error) {
+	db := r.db.Select("id", "type", "status", "latitude", "longitude").
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", query.MinLat, query.MaxLat, query.MinLng, query.MaxLng).
+		Where("created_at >= ? AND created_at < ?", query.From, query.To)
+	if len(query.Types) > 0 {
+		db = db.Where("type IN ?", query.Types)
+	}
+	if len(query.Statuses) > 0 {
+		db = db.Where("status IN ?", query.Statuses)
+	}
+
+	var emergencies []model.Emergency
+	if err := db.Find(&emergencies).Error; err != nil {
+		return nil, err
+	}
+	return emergencies, nil
+}
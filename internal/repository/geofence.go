@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GeofenceRepository struct {
+	db *gorm.DB
+}
+
+func NewGeofenceRepository(db *gorm.DB) *GeofenceRepository {
+	return &GeofenceRepository{db: db}
+}
+
+// ListStates 获取用户相对各危险区域的状态
+func (r *GeofenceRepository) ListStates(userID uint) ([]model.GeofenceState, error) {
+	var states []model.GeofenceState
+	err := r.db.Where("user_id = ?", userID).Find(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// SaveState 保存用户相对危险区域的状态
+func (r *GeofenceRepository) SaveState(state *model.GeofenceState) error {
+	return r.db.Save(state).Error
+}
+
+// DeleteStates 删除用户的所有状态，关闭提醒时调用
+func (r *GeofenceRepository) DeleteStates(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.GeofenceState{}).Error
+}
+
+// CreateEvent 记录进出危险区域事件
+func (r *GeofenceRepository) CreateEvent(event *model.GeofenceEvent) error {
+	return r.db.Create(event).Error
+}
+
+// CountEvents 按危险区域统计时间范围内的进入、离开和提醒次数
+func (r *GeofenceRepository) CountEvents(from, to time.Time) ([]model.GeofenceStat, error) {
+	var stats []model.GeofenceStat
+	err := r.db.Model(&model.GeofenceEvent{}).
+		Select("zone_id, "+
+			"SUM(CASE WHEN event = ? THEN 1 ELSE 0 END) AS entries, "+
+			"SUM(CASE WHEN event = ? THEN 1 ELSE 0 END) AS exits, "+
+			"SUM(CASE WHEN alerted THEN 1 ELSE 0 END) AS alerts",
+			model.GeofenceEventEnter, model.GeofenceEventExit).
+		Where("created_at BETWEEN ? AND ?", from, to).
+		Group("zone_id").
+		Order("zone_id").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
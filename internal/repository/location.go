@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LocationRepository struct {
+	db *gorm.DB
+}
+
+func NewLocationRepository(db *gorm.DB) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+// Create 保存位置轨迹点
+func (r *LocationRepository) Create(location *model.StaffLocation) error {
+	return r.db.Create(location).Error
+}
+
+// ListByStaff 获取安保人员在时间范围内的轨迹，按时间排序
+func (r *LocationRepository) ListByStaff(staffID uint, from, to time.Time) ([]model.StaffLocation, error) {
+	var locations []model.StaffLocation
+	err := r.db.Where("staff_id = ? AND recorded_at BETWEEN ? AND ?", staffID, from, to).
+		Order("recorded_at").
+		Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// ListByEmergency 获取处理某事件期间的轨迹，按时间排序
+func (r *LocationRepository) ListByEmergency(emergencyID uint) ([]model.StaffLocation, error) {
+	var locations []model.StaffLocation
+	err := r.db.Where("emergency_id = ?", emergencyID).
+		Order("recorded_at").
+		Find(&locations).Error
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+	"dididaren/pkg/sensitive"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// Create 记录登录尝试
+func (r *LoginAttemptRepository) Create(attempt *model.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// ListPhoneFailuresSince 获取手机号在指定时间之后、最近一次成功登录之后的失败记录，按时间升序
+func (r *LoginAttemptRepository) ListPhoneFailuresSince(phone string, since time.Time) ([]model.LoginAttempt, error) {
+	phoneHash, err := sensitive.BlindIndex(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastSuccess model.LoginAttempt
+	err = r.db.Where("phone_hash = ? AND success = ? AND created_at > ?", phoneHash, true, since).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&lastSuccess).Error
+	if err != nil {
+		return nil, err
+	}
+	if lastSuccess.ID != 0 {
+		since = lastSuccess.CreatedAt
+	}
+
+	var attempts []model.LoginAttempt
+	err = r.db.Where("phone_hash = ? AND success = ? AND created_at > ?", phoneHash, false, since).
+		Order("created_at").
+		Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// CountIPFailuresSince 统计 IP 在指定时间之后的失败次数
+func (r *LoginAttemptRepository) CountIPFailuresSince(ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.LoginAttempt{}).
+		Where("ip = ? AND success = ? AND created_at > ?", ip, false, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ClearPhoneFailures 清除手机号的失败记录，账号解锁时调用
+func (r *LoginAttemptRepository) ClearPhoneFailures(phone string) error {
+	phoneHash, err := sensitive.BlindIndex(phone)
+	if err != nil {
+		return err
+	}
+	return r.db.Where("phone_hash = ? AND success = ?", phoneHash, false).Delete(&model.LoginAttempt{}).Error
+}
+
+// DeleteCreatedBefore 清理指定时间之前的记录
+func (r *LoginAttemptRepository) DeleteCreatedBefore(before time.Time) error {
+	return r.db.Where("created_at < ?", before).Delete(&model.LoginAttempt{}).Error
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// CreateDeliveries 批量创建通知记录
+func (r *NotificationRepository) CreateDeliveries(deliveries []*model.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+// UpdateDelivery 保存通知的发送结果
+func (r *NotificationRepository) UpdateDelivery(delivery *model.NotificationDelivery) error {
+	return r.db.Model(delivery).Updates(map[string]interface{}{
+		"status":     delivery.Status,
+		"attempts":   delivery.Attempts,
+		"last_error": delivery.LastError,
+		"sent_at":    delivery.SentAt,
+	}).Error
+}
+
+// ListByEmergency 获取紧急事件的通知记录，按发送顺序排列
+func (r *NotificationRepository) ListByEmergency(emergencyID uint) ([]model.NotificationDelivery, error) {
+	var deliveries []model.NotificationDelivery
+	err := r.db.Where("emergency_id = ?", emergencyID).
+		Order("priority, id").
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ListPending 获取所有待发送的通知，用于服务重启后恢复
+func (r *NotificationRepository) ListPending() ([]model.NotificationDelivery, error) {
+	var deliveries []model.NotificationDelivery
+	err := r.db.Where("status = ?", model.DeliveryStatusPending).
+		Order("emergency_id, priority, id").
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// ListByUser 获取授予用户的角色
+func (r *RoleRepository) ListByUser(userID uint) ([]string, error) {
+	var roles []string
+	err := r.db.Model(&model.UserRole{}).Where("user_id = ?", userID).
+		Order("role").Pluck("role", &roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// Grant 授予角色，已拥有时不做修改
+func (r *RoleRepository) Grant(role *model.UserRole) error {
+	return r.db.Where(model.UserRole{UserID: role.UserID, Role: role.Role}).FirstOrCreate(role).Error
+}
+
+// Revoke 撤销角色，返回用户此前是否拥有该角色
+func (r *RoleRepository) Revoke(userID uint, role string) (bool, error) {
+	result := r.db.Where("user_id = ? AND role = ?", userID, role).Delete(&model.UserRole{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -65,11 +65,6 @@ func (r *SecurityRepository) ListStaffs(page, size int, status string) ([]model.
 	return staffs, total, nil
 }
 
-// UpdateStaffStatus 更新安保人员状态
-func (r *SecurityRepository) UpdateStaffStatus(id uint, status string) error {
-	return r.db.Model(&model.Staff{}).Where("id = ?", id).Update("status", status).Error
-}
-
 // CreateRating 创建评价
 func (r *SecurityRepository) CreateRating(rating *model.Rating) error {
 	return r.db.Create(rating).Error
@@ -85,50 +80,34 @@ func (r *SecurityRepository) ListRatings(staffID uint) ([]model.Rating, error) {
 	return ratings, nil
 }
 
-// UpdateLocation 更新安保人员位置
-func (r *SecurityRepository) UpdateLocation(staffID uint, lat, lng float64) error {
-	return r.db.Model(&model.Staff{}).Where("id = ?", staffID).Updates(map[string]interface{}{
+// UpdateLocation 根据用户ID更新安保人员的实时位置
+func (r *SecurityRepository) UpdateLocation(userID uint, lat, lng float64) error {
+	return r.db.Model(&model.Staff{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
 		"latitude":    lat,
 		"longitude":   lng,
 		"last_active": time.Now(),
 	}).Error
 }
 
-// Create 创建安保人员
-func (r *SecurityRepository) Create(staff *model.SecurityStaff) error {
-	return r.db.Create(staff).Error
-}
-
-// GetByID 根据ID获取安保人员
-func (r *SecurityRepository) GetByID(id uint) (*model.SecurityStaff, error) {
-	var staff model.SecurityStaff
-	if err := r.db.First(&staff, id).Error; err != nil {
-		return nil, err
-	}
-	return &staff, nil
-}
-
-// GetByUserID 根据用户ID获取安保人员
-func (r *SecurityRepository) GetByUserID(userID uint) (*model.SecurityStaff, error) {
-	var staff model.SecurityStaff
-	if err := r.db.Where("user_id = ?", userID).First(&staff).Error; err != nil {
+// GetEventByID 根据ID获取事件
+func (r *SecurityRepository) GetEventByID(id uint) (*model.Emergency, error) {
+	var event model.Emergency
+	if err := r.db.First(&event, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &staff, nil
-}
-
-// Update 更新安保人员
-func (r *SecurityRepository) Update(staff *model.SecurityStaff) error {
-	return r.db.Save(staff).Error
+	return &event, nil
 }
 
-// GetEventByID 根据ID获取事件
-func (r *SecurityRepository) GetEventByID(id uint) (*model.Emergency, error) {
+// GetActiveEventByStaff 获取安保人员正在处理的事件
+func (r *SecurityRepository) GetActiveEventByStaff(userID uint, statuses ...int) (*model.Emergency, error) {
 	var event model.Emergency
-	if err := r.db.First(&event, id).Error; err != nil {
+	err := r.db.Where("staff_id = ? AND status IN ?", userID, statuses).
+		Order("id DESC").
+		First(&event).Error
+	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -149,20 +128,28 @@ func (r *SecurityRepository) CreateHandlingRecord(record *model.HandlingRecord)
 
 // UpdateOnlineStatus 更新安保人员在线状态
 func (r *SecurityRepository) UpdateOnlineStatus(userID uint, isOnline bool) error {
-	return r.db.Model(&model.SecurityStaff{}).
+	return r.db.Model(&model.Staff{}).
 		Where("user_id = ?", userID).
 		Update("is_online", isOnline).Error
 }
 
-// GetNearbyStaff 获取附近的安保人员
-func (r *SecurityRepository) GetNearbyStaff(latitude, longitude float64, radius float64) ([]*model.SecurityStaff, error) {
-	var staff []*model.SecurityStaff
-	// 使用简单的经纬度范围查询，实际项目中可能需要使用更复杂的空间查询
-	err := r.db.Where("is_online = ? AND latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
-		true,
-		latitude-radius, latitude+radius,
-		longitude-radius, longitude+radius,
-	).Find(&staff).Error
+// ListOnlineStaff 获取所有在线的安保人员
+func (r *SecurityRepository) ListOnlineStaff() ([]*model.Staff, error) {
+	var staff []*model.Staff
+	err := r.db.Where("is_online = ?", true).Find(&staff).Error
+	if err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// ListByUserIDs 根据用户ID批量获取安保人员
+func (r *SecurityRepository) ListByUserIDs(userIDs []uint) ([]*model.Staff, error) {
+	var staff []*model.Staff
+	if len(userIDs) == 0 {
+		return staff, nil
+	}
+	err := r.db.Where("user_id IN ?", userIDs).Find(&staff).Error
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +158,7 @@ func (r *SecurityRepository) GetNearbyStaff(latitude, longitude float64, radius
 
 // IncrementOrderCount 增加安保人员接单数
 func (r *SecurityRepository) IncrementOrderCount(userID uint) error {
-	return r.db.Model(&model.SecurityStaff{}).
+	return r.db.Model(&model.Staff{}).
 		Where("user_id = ?", userID).
-		UpdateColumn("order_count", gorm.Expr("order_count + ?", 1)).Error
+		UpdateColumn("total_orders", gorm.Expr("total_orders + ?", 1)).Error
 }
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// StaffApplicationRepository 安保人员申请材料和状态变更记录
+type StaffApplicationRepository struct {
+	db *gorm.DB
+}
+
+func NewStaffApplicationRepository(db *gorm.DB) *StaffApplicationRepository {
+	return &StaffApplicationRepository{db: db}
+}
+
+// Apply 提交申请：新建安保人员记录，或将被拒绝的申请改回待审核，同时记录状态变更。
+// 重新申请时只有当前状态仍为 fromStatus 才会更新，返回是否提交成功
+func (r *StaffApplicationRepository) Apply(staff *model.Staff, fromStatus string) (bool, error) {
+	applied := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if staff.ID == 0 {
+			if err := tx.Create(staff).Error; err != nil {
+				return err
+			}
+		} else {
+			// 使用结构体更新，手机号和身份证号才会经过加密序列化
+			result := tx.Model(&model.Staff{}).
+				Where("id = ? AND status = ?", staff.ID, fromStatus).
+				Select("name", "phone", "id_card", "status", "review_reason").
+				Updates(&model.Staff{
+					Name:   staff.Name,
+					Phone:  staff.Phone,
+					IDCard: staff.IDCard,
+					Status: staff.Status,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+		}
+		applied = true
+		return tx.Create(&model.StaffStatusHistory{
+			StaffID:    staff.ID,
+			FromStatus: fromStatus,
+			ToStatus:   staff.Status,
+			OperatorID: staff.UserID,
+		}).Error
+	})
+	return applied, err
+}
+
+// UpdateStatus 变更安保人员状态并记录操作人和原因。
+// 只有当前状态仍为 fromStatus 时才会更新，返回是否更新成功，避免并发审核互相覆盖
+func (r *StaffApplicationRepository) UpdateStatus(staffID uint, fromStatus, toStatus string, operatorID uint, reason string) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Staff{}).
+			Where("id = ? AND status = ?", staffID, fromStatus).
+			Updates(map[string]interface{}{"status": toStatus, "review_reason": reason})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		updated = true
+		return tx.Create(&model.StaffStatusHistory{
+			StaffID:    staffID,
+			FromStatus: fromStatus,
+			ToStatus:   toStatus,
+			OperatorID: operatorID,
+			Reason:     reason,
+		}).Error
+	})
+	return updated, err
+}
+
+// ListHistory 获取安保人员的状态变更记录，按时间先后排列
+func (r *StaffApplicationRepository) ListHistory(staffID uint) ([]model.StaffStatusHistory, error) {
+	var history []model.StaffStatusHistory
+	err := r.db.Where("staff_id = ?", staffID).Order("id").Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// ReplaceDocument 保存申请材料记录，并删除该安保人员同类型的旧材料记录，返回被替换的旧记录
+func (r *StaffApplicationRepository) ReplaceDocument(doc *model.StaffDocument) ([]model.StaffDocument, error) {
+	var replaced []model.StaffDocument
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("staff_id = ? AND type = ?", doc.StaffID, doc.Type).Find(&replaced).Error; err != nil {
+			return err
+		}
+		if len(replaced) > 0 {
+			ids := make([]uint, len(replaced))
+			for i, old := range replaced {
+				ids[i] = old.ID
+			}
+			if err := tx.Delete(&model.StaffDocument{}, ids).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(doc).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return replaced, nil
+}
+
+// GetDocument 获取申请材料，不存在时返回 nil
+func (r *StaffApplicationRepository) GetDocument(id uint) (*model.StaffDocument, error) {
+	var doc model.StaffDocument
+	err := r.db.First(&doc, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// ListDocuments 获取安保人员的申请材料，按上传时间先后排列
+func (r *StaffApplicationRepository) ListDocuments(staffID uint) ([]model.StaffDocument, error) {
+	var docs []model.StaffDocument
+	err := r.db.Where("staff_id = ?", staffID).Order("id").Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
@@ -4,8 +4,18 @@ import (
 	"dididaren/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// configKeyColumn 配置键列。key 在 MySQL 等数据库中是保留字，
+// 通过 clause 构造条件由 GORM 按方言加引号，不能直接写在 SQL 字符串中
+var configKeyColumn = clause.Column{Name: "key"}
+
+// configKeyIs 按配置键查询的条件
+func configKeyIs(key string) clause.Expression {
+	return clause.Eq{Column: configKeyColumn, Value: key}
+}
+
 type SystemConfigRepository struct {
 	db *gorm.DB
 }
@@ -16,9 +26,14 @@ func NewSystemConfigRepository(db *gorm.DB) *SystemConfigRepository {
 	}
 }
 
-// Create 创建系统配置
-func (r *SystemConfigRepository) Create(config *model.SystemConfig) error {
-	return r.db.Create(config).Error
+// Create 创建系统配置并记录变更
+func (r *SystemConfigRepository) Create(config *model.SystemConfig, operatorID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(config).Error; err != nil {
+			return err
+		}
+		return recordConfigChange(tx, model.ConfigActionCreate, nil, config, operatorID, 0)
+	})
 }
 
 // GetByID 根据ID获取系统配置
@@ -50,37 +65,181 @@ func (r *SystemConfigRepository) List(page, size int) ([]model.SystemConfig, int
 	return configs, total, nil
 }
 
-// Update 更新系统配置
-func (r *SystemConfigRepository) Update(config *model.SystemConfig) error {
-	return r.db.Save(config).Error
+// Update 更新系统配置并记录变更，返回更新前的配置
+func (r *SystemConfigRepository) Update(config *model.SystemConfig, operatorID uint) (*model.SystemConfig, error) {
+	var old model.SystemConfig
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, config.ID).Error; err != nil {
+			return err
+		}
+		config.Key = old.Key
+		config.CreatedAt = old.CreatedAt
+		if err := tx.Save(config).Error; err != nil {
+			return err
+		}
+		return recordConfigChange(tx, model.ConfigActionUpdate, &old, config, operatorID, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &old, nil
 }
 
-// Delete 删除系统配置
-func (r *SystemConfigRepository) Delete(id uint) error {
-	return r.db.Delete(&model.SystemConfig{}, id).Error
+// Delete 删除系统配置并记录变更，返回删除前的配置
+func (r *SystemConfigRepository) Delete(id uint, operatorID uint) (*model.SystemConfig, error) {
+	var old model.SystemConfig
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&old, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.SystemConfig{}, id).Error; err != nil {
+			return err
+		}
+		return recordConfigChange(tx, model.ConfigActionDelete, &old, nil, operatorID, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &old, nil
 }
 
 // GetByKey 根据key获取配置
 func (r *SystemConfigRepository) GetByKey(key string) (*model.SystemConfig, error) {
 	var config model.SystemConfig
-	err := r.db.Where("key = ?", key).First(&config).Error
+	err := r.db.Where(configKeyIs(key)).First(&config).Error
 	if err != nil {
 		return nil, err
 	}
 	return &config, nil
 }
 
+// ListByKeyPrefix 获取键以 prefix 开头的全部配置，按键排序
+func (r *SystemConfigRepository) ListByKeyPrefix(prefix string) ([]model.SystemConfig, error) {
+	var configs []model.SystemConfig
+	err := r.db.Where(clause.Like{Column: configKeyColumn, Value: prefix + "%"}).
+		Order(clause.OrderByColumn{Column: configKeyColumn}).
+		Find(&configs).Error
+	if err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
 // GetValue 获取配置值
 func (r *SystemConfigRepository) GetValue(key string) (string, error) {
 	var config model.SystemConfig
-	err := r.db.Where("key = ?", key).First(&config).Error
+	err := r.db.Where(configKeyIs(key)).First(&config).Error
 	if err != nil {
 		return "", err
 	}
 	return config.Value, nil
 }
 
-// UpdateValue 更新配置值
-func (r *SystemConfigRepository) UpdateValue(key string, value string) error {
-	return r.db.Model(&model.SystemConfig{}).Where("key = ?", key).Update("value", value).Error
+// UpdateValue 更新配置值并记录变更。
+// validate 在锁定当前配置后调用，用于按最新的类型和取值范围校验新值
+func (r *SystemConfigRepository) UpdateValue(key string, value string, operatorID uint, validate func(*model.SystemConfig) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var old model.SystemConfig
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(configKeyIs(key)).First(&old).Error; err != nil {
+			return err
+		}
+		if err := validate(&old); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.SystemConfig{}).Where("id = ?", old.ID).Update("value", value).Error; err != nil {
+			return err
+		}
+		current := old
+		current.Value = value
+		return recordConfigChange(tx, model.ConfigActionUpdate, &old, &current, operatorID, 0)
+	})
+}
+
+// Restore 将配置恢复为 target 记录中的内容并记录变更，配置已被删除时重新创建
+func (r *SystemConfigRepository) Restore(target *model.SystemConfigHistory, operatorID uint) (*model.SystemConfig, error) {
+	var config model.SystemConfig
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var old *model.SystemConfig
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(configKeyIs(target.ConfigKey)).First(&config).Error
+		switch err {
+		case nil:
+			prev := config
+			old = &prev
+		case gorm.ErrRecordNotFound:
+			config = model.SystemConfig{Key: target.ConfigKey}
+		default:
+			return err
+		}
+
+		config.Value = *target.NewValue
+		config.Type = target.Type
+		config.Schema = target.Schema
+		config.Desc = target.Desc
+		if err := tx.Save(&config).Error; err != nil {
+			return err
+		}
+		return recordConfigChange(tx, model.ConfigActionRollback, old, &config, operatorID, target.Version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// ListHistory 获取配置的变更记录，按版本从新到旧排列
+func (r *SystemConfigRepository) ListHistory(key string, page, size int) ([]model.SystemConfigHistory, int64, error) {
+	var history []model.SystemConfigHistory
+	var total int64
+
+	if err := r.db.Model(&model.SystemConfigHistory{}).Where("config_key = ?", key).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := r.db.Where("config_key = ?", key).Order("version DESC").Offset(offset).Limit(size).Find(&history).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return history, total, nil
+}
+
+// GetHistory 获取配置指定版本的变更记录
+func (r *SystemConfigRepository) GetHistory(key string, version int) (*model.SystemConfigHistory, error) {
+	var history model.SystemConfigHistory
+	err := r.db.Where("config_key = ? AND version = ?", key, version).First(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// recordConfigChange 在事务中追加一条变更记录，版本号为该配置键已有的最大版本号加一。
+// 并发写入同一版本号时唯一索引冲突，事务整体回滚
+func recordConfigChange(tx *gorm.DB, action string, old, current *model.SystemConfig, operatorID uint, rollbackTo int) error {
+	history := model.SystemConfigHistory{
+		Action:     action,
+		RollbackTo: rollbackTo,
+		OperatorID: operatorID,
+	}
+	if old != nil {
+		history.ConfigKey = old.Key
+		history.OldValue = &old.Value
+		history.Type, history.Schema, history.Desc = old.Type, old.Schema, old.Desc
+	}
+	if current != nil {
+		history.ConfigKey = current.Key
+		history.NewValue = &current.Value
+		history.Type, history.Schema, history.Desc = current.Type, current.Schema, current.Desc
+	}
+
+	var latest int
+	err := tx.Model(&model.SystemConfigHistory{}).
+		Where("config_key = ?", history.ConfigKey).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+	history.Version = latest + 1
+	return tx.Create(&history).Error
 }
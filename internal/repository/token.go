@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+// CreateRefreshToken 保存刷新令牌
+func (r *TokenRepository) CreateRefreshToken(token *model.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// GetRefreshTokenByHash 根据哈希获取刷新令牌，不存在时返回 nil
+func (r *TokenRepository) GetRefreshTokenByHash(hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed 标记刷新令牌已轮换，以未使用为条件，并发刷新时只有一个会成功
+func (r *TokenRepository) MarkRefreshTokenUsed(id uint) (bool, error) {
+	result := r.db.Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeSession 标记会话及其所有刷新令牌已吊销
+func (r *TokenRepository) RevokeSession(sessionID string) error {
+	now := time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Session{}).
+			Where("id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.RefreshToken{}).
+			Where("session_id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// CreateSession 保存登录会话
+func (r *TokenRepository) CreateSession(session *model.Session) error {
+	return r.db.Create(session).Error
+}
+
+// GetSession 获取登录会话，不存在时返回 nil
+func (r *TokenRepository) GetSession(id string) (*model.Session, error) {
+	var session model.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListSessions 获取用户未吊销且未过期的登录会话，最近活动的在前
+func (r *TokenRepository) ListSessions(userID uint) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// UpdateSession 更新会话的最近活动信息，fields 为要更新的列
+func (r *TokenRepository) UpdateSession(id string, fields map[string]interface{}) error {
+	return r.db.Model(&model.Session{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// ListActiveSessionIDs 获取用户仍可刷新的会话
+func (r *TokenRepository) ListActiveSessionIDs(userID uint) ([]string, error) {
+	var ids []string
+	err := r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Distinct().
+		Pluck("session_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CreateRevokedSession 记录已吊销的会话
+func (r *TokenRepository) CreateRevokedSession(session *model.RevokedSession) error {
+	return r.db.Save(session).Error
+}
+
+// ListRevokedSessions 获取仍在有效期内的已吊销会话
+func (r *TokenRepository) ListRevokedSessions() ([]model.RevokedSession, error) {
+	var sessions []model.RevokedSession
+	err := r.db.Where("expires_at > ?", time.Now()).Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// DeleteExpired 清理过期的吊销记录、刷新令牌和会话
+func (r *TokenRepository) DeleteExpired() error {
+	now := time.Now()
+	if err := r.db.Where("expires_at <= ?", now).Delete(&model.RevokedSession{}).Error; err != nil {
+		return err
+	}
+	if err := r.db.Where("expires_at <= ?", now).Delete(&model.Session{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("expires_at <= ?", now).Delete(&model.RefreshToken{}).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"dididaren/internal/model"
+	"dididaren/pkg/sensitive"
 	"time"
 
 	"gorm.io/gorm"
@@ -32,8 +33,12 @@ func (r *UserRepository) GetByID(id uint) (*model.User, error) {
 
 // GetByPhone 根据手机号获取用户
 func (r *UserRepository) GetByPhone(phone string) (*model.User, error) {
+	phoneHash, err := sensitive.BlindIndex(phone)
+	if err != nil {
+		return nil, err
+	}
 	var user model.User
-	err := r.db.Where("phone = ?", phone).First(&user).Error
+	err = r.db.Where("phone_hash = ?", phoneHash).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -42,8 +47,12 @@ func (r *UserRepository) GetByPhone(phone string) (*model.User, error) {
 
 // ExistsByPhone 检查手机号是否存在
 func (r *UserRepository) ExistsByPhone(phone string) bool {
+	phoneHash, err := sensitive.BlindIndex(phone)
+	if err != nil {
+		return false
+	}
 	var count int64
-	r.db.Model(&model.User{}).Where("phone = ?", phone).Count(&count)
+	r.db.Model(&model.User{}).Where("phone_hash = ?", phoneHash).Count(&count)
 	return count > 0
 }
 
@@ -52,15 +61,16 @@ func (r *UserRepository) Update(user *model.User) error {
 	return r.db.Save(user).Error
 }
 
+// UpdateLocationAlerts 更新用户的危险区域提醒开关
+func (r *UserRepository) UpdateLocationAlerts(id uint, enabled bool) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("location_alerts", enabled).Error
+}
+
 // UpdateLastLogin 更新最后登录时间
 func (r *UserRepository) UpdateLastLogin(id uint) error {
 	return r.db.Model(&model.User{}).Where("id = ?", id).Update("last_login", time.Now()).Error
 }
 
-func (r *UserRepository) Delete(id uint) error {
-	return r.db.Delete(&model.User{}, id).Error
-}
-
 func (r *UserRepository) List(page, size int) ([]model.User, int64, error) {
 	var users []model.User
 	var total int64
@@ -77,3 +87,9 @@ func (r *UserRepository) List(page, size int) ([]model.User, int64, error) {
 
 	return users, total, nil
 }
+
+// UpdateLock 更新用户的锁定状态
+func (r *UserRepository) UpdateLock(id uint, status int, lockedUntil *time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "locked_until": lockedUntil}).Error
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"dididaren/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type VerifyCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewVerifyCodeRepository(db *gorm.DB) *VerifyCodeRepository {
+	return &VerifyCodeRepository{db: db}
+}
+
+// Create 保存验证码
+func (r *VerifyCodeRepository) Create(code *model.VerificationCode) error {
+	return r.db.Create(code).Error
+}
+
+// GetLatest 获取手机号最近一条指定用途的验证码，不存在时返回 nil
+func (r *VerifyCodeRepository) GetLatest(phone, purpose string) (*model.VerificationCode, error) {
+	var code model.VerificationCode
+	err := r.db.Where("phone = ? AND purpose = ?", phone, purpose).
+		Order("created_at DESC, id DESC").
+		First(&code).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// IncrementAttempts 校验失败次数加一
+func (r *VerifyCodeRepository) IncrementAttempts(id uint) error {
+	return r.db.Model(&model.VerificationCode{}).Where("id = ?", id).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// MarkUsed 标记验证码已使用，返回是否由本次调用标记，并发校验同一验证码时只有一个成功
+func (r *VerifyCodeRepository) MarkUsed(id uint) (bool, error) {
+	result := r.db.Model(&model.VerificationCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountByPhoneSince 统计手机号在指定时间之后发送的验证码数量
+func (r *VerifyCodeRepository) CountByPhoneSince(phone string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.VerificationCode{}).
+		Where("phone = ? AND created_at > ?", phone, since).
+		Count(&count).Error
+	return count, err
+}
+
+// CountByIPSince 统计 IP 在指定时间之后发送的验证码数量
+func (r *VerifyCodeRepository) CountByIPSince(ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.VerificationCode{}).
+		Where("ip = ? AND created_at > ?", ip, since).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteCreatedBefore 清理指定时间之前创建的验证码
+func (r *VerifyCodeRepository) DeleteCreatedBefore(before time.Time) error {
+	return r.db.Where("created_at < ?", before).Delete(&model.VerificationCode{}).Error
+}
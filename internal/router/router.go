@@ -1,26 +1,43 @@
 package router
 
 import (
+	"dididaren/docs"
 	"dididaren/internal/handler"
 	"dididaren/internal/middleware"
+	"dididaren/pkg/auth"
 
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 func SetupRouter(
+	tokens *auth.TokenManager,
+	sessions middleware.SessionTracker,
+	streamTickets middleware.StreamTickets,
 	userHandler *handler.UserHandler,
+	authHandler *handler.AuthHandler,
+	sessionHandler *handler.SessionHandler,
+	roleHandler *handler.RoleHandler,
+	accountHandler *handler.AccountHandler,
+	contactHandler *handler.ContactHandler,
 	emergencyHandler *handler.EmergencyHandler,
 	securityHandler *handler.SecurityHandler,
+	staffApplicationHandler *handler.StaffApplicationHandler,
 	dangerZoneHandler *handler.DangerZoneHandler,
 	ratingHandler *handler.RatingHandler,
 	systemConfigHandler *handler.SystemConfigHandler,
+	featureFlagHandler *handler.FeatureFlagHandler,
+	realtimeHandler *handler.RealtimeHandler,
+	heatmapHandler *handler.HeatmapHandler,
+	geofenceHandler *handler.GeofenceHandler,
 ) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(middleware.Logger(), gin.Recovery())
 
-	// 中间件
-	r.Use(middleware.Cors())
-	r.Use(middleware.Logger())
-	r.Use(middleware.Recovery())
+	// 配置 swagger
+	docs.SwaggerInfo.BasePath = "/api/v1"
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// 公开路由
 	public := r.Group("/api/v1")
@@ -28,67 +45,146 @@ func SetupRouter(
 		// 用户相关
 		public.POST("/users/register", userHandler.Register)
 		public.POST("/users/login", userHandler.Login)
+		public.POST("/users/send-code", userHandler.SendCode)
 		public.POST("/users/verify-code", userHandler.VerifyCode)
+		public.POST("/users/refresh", authHandler.Refresh)
 
-		// 系统配置
-		public.GET("/configs", systemConfigHandler.GetAllConfigs)
-		public.GET("/configs/:key", systemConfigHandler.GetConfigValue)
+		// 实时推送，浏览器无法为 WebSocket/EventSource 设置请求头，通过一次性连接凭证认证
+		public.GET("/realtime/ws", middleware.StreamAuth(tokens, sessions, streamTickets), realtimeHandler.WebSocket)
+		public.GET("/realtime/sse", middleware.StreamAuth(tokens, sessions, streamTickets), realtimeHandler.SSE)
 	}
 
-	// 需要认证的路由
+	// 需要认证的路由，未标注权限的接口所有登录用户均可访问
 	authorized := r.Group("/api/v1")
-	authorized.Use(middleware.Auth())
+	authorized.Use(middleware.Auth(tokens, sessions))
 	{
 		// 用户相关
-		authorized.GET("/users/profile", userHandler.GetProfile)
-		authorized.PUT("/users/profile", userHandler.UpdateProfile)
-		authorized.POST("/users/emergency-contacts", userHandler.AddEmergencyContact)
-		authorized.GET("/users/emergency-contacts", userHandler.GetEmergencyContacts)
-		authorized.DELETE("/users/emergency-contacts/:id", userHandler.DeleteEmergencyContact)
+		authorized.GET("/users/info", userHandler.GetUserInfo)
+		authorized.POST("/realtime/ticket", realtimeHandler.IssueTicket)
+		authorized.PUT("/users/info", userHandler.UpdateUserInfo)
+		authorized.PUT("/users/password", userHandler.UpdatePassword)
+		authorized.POST("/users/logout", authHandler.Logout)
+		authorized.POST("/users/logout-all", authHandler.LogoutAll)
+		authorized.GET("/users/sessions", sessionHandler.ListSessions)
+		authorized.DELETE("/users/sessions/:id", sessionHandler.RevokeSession)
+		authorized.GET("/users/export", accountHandler.Export)
+		authorized.DELETE("/users/account", accountHandler.DeleteAccount)
+		authorized.GET("/users/emergency-contacts", contactHandler.GetEmergencyContacts)
+		authorized.POST("/users/emergency-contacts", contactHandler.AddEmergencyContact)
+		authorized.PUT("/users/emergency-contacts/:id", contactHandler.UpdateEmergencyContact)
+		authorized.DELETE("/users/emergency-contacts/:id", contactHandler.DeleteEmergencyContact)
+		authorized.PUT("/users/emergency-contacts/:id/default", contactHandler.SetDefaultEmergencyContact)
+		authorized.POST("/users/emergency-contacts/:id/invite", contactHandler.InviteEmergencyContact)
+		authorized.POST("/users/emergency-contacts/:id/verify", contactHandler.VerifyEmergencyContact)
+		authorized.PUT("/users/location-alerts", geofenceHandler.UpdateLocationAlerts)
+		authorized.POST("/users/location", geofenceHandler.ReportLocation)
+
+		// 安保人员相关
+		authorized.POST("/security/ratings", securityHandler.CreateRating)
+		authorized.GET("/security/ratings", securityHandler.ListRatings)
+		authorized.GET("/security/staff/info", securityHandler.GetStaffInfo)
+		authorized.POST("/security/staff/apply", staffApplicationHandler.ApplySecurityStaff)
+		authorized.GET("/security/staff/application", staffApplicationHandler.GetApplication)
+		authorized.POST("/security/staff/documents", staffApplicationHandler.UploadDocument)
+
+		// 安保人员接单和处理事件
+		staff := authorized.Group("", middleware.RequirePermission(auth.PermStaffRespond))
+		staff.PUT("/security/staff/location", securityHandler.UpdateLocation)
+		staff.PUT("/security/staff/online", securityHandler.UpdateOnlineStatus)
+		staff.GET("/security/staff/offers", securityHandler.ListPendingOffers)
+		staff.POST("/security/staff/events/:id/accept", securityHandler.AcceptEvent)
+		staff.POST("/security/staff/events/:id/decline", securityHandler.DeclineEvent)
+		staff.POST("/security/staff/events/:id/depart", securityHandler.DepartEvent)
+		staff.POST("/security/staff/events/:id/arrive", securityHandler.ArriveEvent)
+		staff.POST("/security/staff/events/:id/complete", securityHandler.CompleteEvent)
+
+		// 安保人员管理
+		staffManage := authorized.Group("", middleware.RequirePermission(auth.PermStaffManage))
+		staffManage.POST("/security/staff", securityHandler.CreateStaff)
+		staffManage.GET("/security/staff/:id", securityHandler.GetStaff)
+		staffManage.GET("/security/staff", securityHandler.ListStaffs)
+		staffManage.PUT("/security/staff/:id/status", staffApplicationHandler.UpdateStaffStatus)
+		staffManage.GET("/security/staff/:id/application", staffApplicationHandler.GetStaffApplication)
+		staffManage.GET("/security/staff/:id/documents/:doc_id", staffApplicationHandler.DownloadDocument)
+		staffManage.GET("/security/staff/:id/trail", securityHandler.GetStaffTrail)
 
 		// 紧急事件相关
-		authorized.POST("/emergencies", emergencyHandler.CreateEmergency)
-		authorized.GET("/emergencies/:id", emergencyHandler.GetEmergency)
-		authorized.PUT("/emergencies/:id/status", emergencyHandler.UpdateStatus)
-		authorized.GET("/emergencies/history", emergencyHandler.GetHistory)
-		authorized.POST("/emergencies/:id/handling-records", emergencyHandler.CreateHandlingRecord)
-		authorized.GET("/emergencies/:id/handling-records", emergencyHandler.GetHandlingRecords)
+		authorized.POST("/emergency", emergencyHandler.Create)
+		authorized.GET("/emergency/:id", emergencyHandler.GetByID)
+		authorized.GET("/emergency/heatmap", heatmapHandler.GetHeatmap)
+		authorized.PUT("/emergency/:id", emergencyHandler.Update)
+		authorized.GET("/emergency/:id/handling", emergencyHandler.ListHandlingRecords)
+		authorized.GET("/emergency/:id/notifications", emergencyHandler.ListNotifications)
 
-		// 安保人员相关
-		authorized.POST("/security/apply", securityHandler.ApplySecurityStaff)
-		authorized.PUT("/security/location", securityHandler.UpdateLocation)
-		authorized.POST("/security/events/:id/accept", securityHandler.AcceptEvent)
-		authorized.PUT("/security/events/:id/complete", securityHandler.CompleteEvent)
-		authorized.GET("/security/profile", securityHandler.GetStaffInfo)
+		// 记录事件处理过程
+		handling := authorized.Group("", middleware.RequirePermission(auth.PermEmergencyHandle))
+		handling.POST("/emergency/:id/handling", emergencyHandler.CreateHandlingRecord)
+
+		// 事件调度和管理
+		emergencyManage := authorized.Group("", middleware.RequirePermission(auth.PermEmergencyManage))
+		emergencyManage.GET("/emergency", emergencyHandler.List)
+		emergencyManage.DELETE("/emergency/:id", emergencyHandler.Delete)
+		emergencyManage.PUT("/emergency/:id/status", emergencyHandler.UpdateStatus)
+		emergencyManage.PUT("/emergency/:id/assign", emergencyHandler.AssignStaff)
+		emergencyManage.GET("/emergency/:id/offers", emergencyHandler.ListDispatchOffers)
+		emergencyManage.GET("/security/staff/nearby", securityHandler.GetNearbyStaff)
+		emergencyManage.GET("/security/staff/events/:id/trail", securityHandler.GetEventTrail)
 
 		// 危险区域相关
-		authorized.POST("/danger-zones", dangerZoneHandler.CreateDangerZone)
 		authorized.GET("/danger-zones/:id", dangerZoneHandler.GetDangerZone)
-		authorized.PUT("/danger-zones/:id", dangerZoneHandler.UpdateDangerZone)
-		authorized.DELETE("/danger-zones/:id", dangerZoneHandler.DeleteDangerZone)
+		authorized.GET("/danger-zones", dangerZoneHandler.ListDangerZones)
 		authorized.GET("/danger-zones/nearby", dangerZoneHandler.GetNearbyZones)
 		authorized.GET("/danger-zones/active", dangerZoneHandler.GetAllActiveZones)
-		authorized.PUT("/danger-zones/:id/heat-level", dangerZoneHandler.UpdateHeatLevel)
+		authorized.GET("/danger-zones/export", dangerZoneHandler.ExportGeoJSON)
+		authorized.GET("/danger-zones/:id/heat-history", dangerZoneHandler.GetHeatHistory)
+
+		// 危险区域管理
+		zoneManage := authorized.Group("", middleware.RequirePermission(auth.PermZoneManage))
+		zoneManage.POST("/danger-zones", dangerZoneHandler.CreateDangerZone)
+		zoneManage.PUT("/danger-zones/:id", dangerZoneHandler.UpdateDangerZone)
+		zoneManage.DELETE("/danger-zones/:id", dangerZoneHandler.DeleteDangerZone)
+		zoneManage.POST("/danger-zones/import", dangerZoneHandler.ImportGeoJSON)
+		zoneManage.PUT("/danger-zones/:id/heat-level", dangerZoneHandler.UpdateHeatLevel)
+		zoneManage.GET("/danger-zones/geofence-stats", geofenceHandler.GetStats)
 
 		// 评价相关
 		authorized.POST("/ratings", ratingHandler.CreateRating)
 		authorized.GET("/ratings/:id", ratingHandler.GetRating)
-		authorized.GET("/ratings/event/:event_id", ratingHandler.GetEventRating)
-		authorized.GET("/ratings/staff/:staff_id", ratingHandler.GetStaffRatings)
-		authorized.GET("/ratings/staff/:staff_id/average", ratingHandler.GetStaffAverageRating)
+		authorized.GET("/ratings", ratingHandler.ListRatings)
 		authorized.PUT("/ratings/:id", ratingHandler.UpdateRating)
 		authorized.DELETE("/ratings/:id", ratingHandler.DeleteRating)
+
+		// 系统配置
+		authorized.GET("/system/configs/:id/value", systemConfigHandler.GetValue)
+
+		configManage := authorized.Group("", middleware.RequirePermission(auth.PermConfigManage))
+		configManage.POST("/system/configs", systemConfigHandler.Create)
+		configManage.GET("/system/configs/:id", systemConfigHandler.GetByID)
+		configManage.GET("/system/configs", systemConfigHandler.List)
+		configManage.PUT("/system/configs/:id", systemConfigHandler.Update)
+		configManage.DELETE("/system/configs/:id", systemConfigHandler.Delete)
+		configManage.PUT("/system/configs/:id/value", systemConfigHandler.UpdateValue)
+		configManage.GET("/system/configs/:id/history", systemConfigHandler.ListHistory)
+		configManage.POST("/system/configs/:id/rollback", systemConfigHandler.Rollback)
+
+		// 功能开关
+		authorized.GET("/features", featureFlagHandler.List)
 	}
 
 	// 管理员路由
 	admin := r.Group("/api/v1/admin")
-	admin.Use(middleware.Auth(), middleware.Admin())
+	admin.Use(middleware.Auth(tokens, sessions))
 	{
-		// 系统配置管理
-		admin.POST("/configs", systemConfigHandler.CreateConfig)
-		admin.PUT("/configs", systemConfigHandler.UpdateConfig)
-		admin.DELETE("/configs/:key", systemConfigHandler.DeleteConfig)
-		admin.PUT("/configs/:key/value", systemConfigHandler.UpdateConfigValue)
+		// 角色管理
+		roleManage := admin.Group("", middleware.RequirePermission(auth.PermRoleManage))
+		roleManage.GET("/users/:id/roles", roleHandler.GetUserRoles)
+		roleManage.POST("/users/:id/roles", roleHandler.GrantRole)
+		roleManage.DELETE("/users/:id/roles/:role", roleHandler.RevokeRole)
+
+		// 用户账号管理
+		userManage := admin.Group("", middleware.RequirePermission(auth.PermUserManage))
+		userManage.POST("/users/:id/unlock", userHandler.UnlockUser)
+		userManage.DELETE("/users/:id", accountHandler.DeleteUser)
 	}
 
 	return r
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"archive/zip"
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/logger"
+	"dididaren/pkg/storage"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// deletedUserName 注销后用户的显示名称
+const deletedUserName = "已注销用户"
+
+// AccountService 用户个人数据的导出和账号注销
+type AccountService struct {
+	repo        *repository.AccountRepository
+	userRepo    *repository.UserRepository
+	roles       *RoleService
+	revocations *RevocationService
+	geo         *GeoService
+	storage     storage.Storage
+	logger      *logger.Logger
+}
+
+func NewAccountService(
+	repo *repository.AccountRepository,
+	userRepo *repository.UserRepository,
+	roles *RoleService,
+	revocations *RevocationService,
+	geo *GeoService,
+	storage storage.Storage,
+	logger *logger.Logger,
+) *AccountService {
+	return &AccountService{
+		repo:        repo,
+		userRepo:    userRepo,
+		roles:       roles,
+		revocations: revocations,
+		geo:         geo,
+		storage:     storage,
+		logger:      logger,
+	}
+}
+
+// Export 导出用户的个人数据
+func (s *AccountService) Export(userID uint) (*model.AccountExport, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	roles, err := s.roles.Roles(user)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &model.AccountExport{
+		ExportedAt: time.Now(),
+		Profile:    user,
+		Roles:      roles,
+	}
+	if err := s.repo.Export(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// DeleteSelf 用户注销自己的账号，需要验证密码
+func (s *AccountService) DeleteSelf(userID uint, sessionID, password string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+	if !auth.VerifyPassword(password, user.Password) {
+		return errors.ErrInvalidPassword
+	}
+	if err := s.delete(user); err != nil {
+		return err
+	}
+	// 当前会话的刷新令牌可能已过期，单独吊销以确保当前访问令牌失效
+	if sessionID != "" {
+		return s.revocations.Revoke(userID, sessionID)
+	}
+	return nil
+}
+
+// Delete 管理员注销用户账号
+func (s *AccountService) Delete(userID uint) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+	return s.delete(user)
+}
+
+// delete 注销账号：吊销所有登录会话，匿名化个人信息，事件记录作为档案保留。
+// 用户有进行中的紧急事件时不能注销
+func (s *AccountService) delete(user *model.User) error {
+	if user.Status == model.UserStatusDeleted {
+		return nil
+	}
+
+	var active []int
+	for status := range model.EmergencyStatusNames {
+		if !IsEmergencyFinished(status) {
+			active = append(active, status)
+		}
+	}
+	count, err := s.repo.CountActiveEmergencies(user.ID, active)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.ErrAccountBusy
+	}
+
+	if err := s.revocations.RevokeUser(user.ID); err != nil {
+		return err
+	}
+
+	originalPhone := user.Phone
+	user.Phone = fmt.Sprintf("deleted_%d", user.ID)
+	user.Password = ""
+	user.Name = deletedUserName
+	user.Avatar = ""
+	user.IsAdmin = false
+	user.Status = model.UserStatusDeleted
+	user.LockedUntil = nil
+	user.LocationAlerts = false
+	fileKeys, err := s.repo.Anonymize(user, originalPhone)
+	if err != nil {
+		return err
+	}
+	for _, key := range fileKeys {
+		if err := s.storage.Delete(key); err != nil {
+			s.logger.Error("删除安保人员申请材料失败: user_id=%d key=%s err=%v", user.ID, key, err)
+		}
+	}
+
+	s.geo.SyncStaff(user.ID, 0, 0, false)
+	s.logger.Info("用户账号已注销: user_id=%d", user.ID)
+	return nil
+}
+
+// WriteExportArchive 把导出数据写为 ZIP 压缩包，每类数据一个 JSON 文件
+func WriteExportArchive(w io.Writer, export *model.AccountExport) error {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", map[string]interface{}{
+			"exported_at": export.ExportedAt,
+			"profile":     export.Profile,
+			"roles":       export.Roles,
+			"staff":       export.Staff,
+		}},
+		{"staff_application.json", map[string]interface{}{
+			"documents": export.StaffDocuments,
+			"history":   export.StaffHistory,
+		}},
+		{"contacts.json", export.Contacts},
+		{"emergencies.json", export.Emergencies},
+		{"handling_records.json", export.HandlingRecords},
+		{"ratings_given.json", export.RatingsGiven},
+		{"ratings_received.json", export.RatingsReceived},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
+	"time"
+)
+
+// AuthService 管理登录会话的令牌：签发、刷新轮换和退出登录。
+// 刷新令牌每次使用后作废并换发新令牌，已作废的令牌被再次使用说明可能已泄露，此时吊销整个会话
+type AuthService struct {
+	userRepo    *repository.UserRepository
+	tokenRepo   *repository.TokenRepository
+	tokens      *auth.TokenManager
+	revocations *RevocationService
+	roles       *RoleService
+}
+
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	tokenRepo *repository.TokenRepository,
+	tokens *auth.TokenManager,
+	revocations *RevocationService,
+	roles *RoleService,
+) *AuthService {
+	return &AuthService{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		tokens:      tokens,
+		revocations: revocations,
+		roles:       roles,
+	}
+}
+
+// IssueTokens 为新的登录会话签发令牌
+func (s *AuthService) IssueTokens(user *model.User, client *model.ClientInfo) (*model.TokenPair, error) {
+	return s.issue(user, auth.NewSessionID(), client, true)
+}
+
+// Refresh 使用刷新令牌换取新的令牌
+func (s *AuthService) Refresh(refreshToken string, client *model.ClientInfo) (*model.TokenPair, error) {
+	token, err := s.tokenRepo.GetRefreshTokenByHash(auth.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, errors.ErrInvalidRefreshToken
+	}
+
+	ok, err := s.tokenRepo.MarkRefreshTokenUsed(token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 已轮换过的令牌被再次使用，吊销整个会话
+		if err := s.revocations.Revoke(token.UserID, token.SessionID); err != nil {
+			return nil, err
+		}
+		return nil, errors.ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	return s.issue(user, token.SessionID, client, false)
+}
+
+// Logout 退出当前登录会话
+func (s *AuthService) Logout(userID uint, sessionID string) error {
+	if sessionID == "" {
+		return errors.ErrInvalidToken
+	}
+	return s.revocations.Revoke(userID, sessionID)
+}
+
+// LogoutAll 退出用户的所有登录会话，用于手机丢失等情况
+func (s *AuthService) LogoutAll(userID uint, currentSessionID string) error {
+	if err := s.revocations.RevokeUser(userID); err != nil {
+		return err
+	}
+	// 当前会话的刷新令牌可能已过期，单独吊销以确保当前访问令牌失效
+	if currentSessionID != "" {
+		return s.revocations.Revoke(userID, currentSessionID)
+	}
+	return nil
+}
+
+// issue 签发令牌并记录会话，newSession 为 false 时更新已有会话的最近活动信息
+func (s *AuthService) issue(user *model.User, sessionID string, client *model.ClientInfo, newSession bool) (*model.TokenPair, error) {
+	// 每次签发都重新读取角色，授予的角色在下次刷新时生效
+	roles, err := s.roles.Roles(user)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := s.tokens.IssueAccessToken(user.ID, user.Phone, roles, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, hash, expiresAt := s.tokens.NewRefreshToken()
+	if err := s.tokenRepo.CreateRefreshToken(&model.RefreshToken{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		TokenHash: hash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if newSession {
+		err = s.tokenRepo.CreateSession(&model.Session{
+			ID:         sessionID,
+			UserID:     user.ID,
+			DeviceName: truncateRunes(client.DeviceName, maxDeviceNameLength),
+			IP:         client.IP,
+			UserAgent:  truncateRunes(client.UserAgent, maxUserAgentLength),
+			LastSeenAt: now,
+			ExpiresAt:  expiresAt,
+		})
+	} else {
+		err = s.tokenRepo.UpdateSession(sessionID, map[string]interface{}{
+			"ip":           client.IP,
+			"last_seen_at": now,
+			"expires_at":   expiresAt,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.tokens.AccessTTL() / time.Second),
+	}, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/pkg/errors"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// parseConfigValue 按类型解析配置值，int 返回 int64，float 返回 float64，bool 返回 bool，
+// duration 返回 time.Duration，string_list 返回 []string，json 返回解码后的值
+func parseConfigValue(typ, value string) (interface{}, error) {
+	switch typ {
+	case model.ConfigTypeString:
+		return value, nil
+	case model.ConfigTypeInt:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q 不是整数", errors.ErrInvalidConfig, value)
+		}
+		return v, nil
+	case model.ConfigTypeFloat:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q 不是数字", errors.ErrInvalidConfig, value)
+		}
+		return v, nil
+	case model.ConfigTypeBool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q 不是 true 或 false", errors.ErrInvalidConfig, value)
+		}
+		return v, nil
+	case model.ConfigTypeDuration:
+		v, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q 不是有效的时长，格式如 30s、5m、1h30m", errors.ErrInvalidConfig, value)
+		}
+		return v, nil
+	case model.ConfigTypeJSON:
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf("%w: 不是有效的 JSON", errors.ErrInvalidConfig)
+		}
+		return v, nil
+	case model.ConfigTypeStringList:
+		var v []string
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf("%w: 不是字符串数组，格式如 [\"a\",\"b\"]", errors.ErrInvalidConfig)
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w: 不支持的类型 %q", errors.ErrInvalidConfig, typ)
+	}
+}
+
+// validateConfigSchema 校验取值范围是否适用于该类型
+func validateConfigSchema(typ string, schema *model.ConfigSchema) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.Min != nil || schema.Max != nil {
+		if typ != model.ConfigTypeInt && typ != model.ConfigTypeFloat && typ != model.ConfigTypeDuration {
+			return fmt.Errorf("%w: %s 类型不支持 min、max", errors.ErrInvalidConfig, typ)
+		}
+		if schema.Min != nil && schema.Max != nil && *schema.Min > *schema.Max {
+			return fmt.Errorf("%w: min 不能大于 max", errors.ErrInvalidConfig)
+		}
+	}
+	if len(schema.Enum) > 0 {
+		switch typ {
+		case model.ConfigTypeString, model.ConfigTypeStringList:
+		case model.ConfigTypeInt:
+			for _, option := range schema.Enum {
+				if _, err := strconv.ParseInt(option, 10, 64); err != nil {
+					return fmt.Errorf("%w: enum 中的 %q 不是整数", errors.ErrInvalidConfig, option)
+				}
+			}
+		default:
+			return fmt.Errorf("%w: %s 类型不支持 enum", errors.ErrInvalidConfig, typ)
+		}
+	}
+	return nil
+}
+
+// validateConfigValue 校验配置值符合类型和取值范围
+func validateConfigValue(typ, value string, schema *model.ConfigSchema) error {
+	parsed, err := parseConfigValue(typ, value)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		return nil
+	}
+
+	var number float64
+	switch v := parsed.(type) {
+	case int64:
+		number = float64(v)
+	case float64:
+		number = v
+	case time.Duration:
+		number = v.Seconds()
+	}
+	if schema.Min != nil && number < *schema.Min {
+		return fmt.Errorf("%w: 不能小于 %v", errors.ErrInvalidConfig, *schema.Min)
+	}
+	if schema.Max != nil && number > *schema.Max {
+		return fmt.Errorf("%w: 不能大于 %v", errors.ErrInvalidConfig, *schema.Max)
+	}
+
+	if len(schema.Enum) > 0 {
+		items := []string{value}
+		if list, ok := parsed.([]string); ok {
+			items = list
+		}
+		for _, item := range items {
+			if !containsString(schema.Enum, item) {
+				return fmt.Errorf("%w: %q 不在可选值 %v 中", errors.ErrInvalidConfig, item, schema.Enum)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/logger"
+	"strings"
+	"time"
+)
+
+// 紧急联系人配置项
+const (
+	configContactMaxCount  = "contact.max_count" // 每个用户最多添加的紧急联系人数量
+	defaultContactMaxCount = 5
+)
+
+// ContactService 紧急联系人管理。
+// 联系人添加后会收到邀请短信，用户填入联系人告知的验证码后联系人才生效，
+// 避免把求助通知发给填错号码的陌生人
+type ContactService struct {
+	repo          *repository.ContactRepository
+	userRepo      *repository.UserRepository
+	verifyCodes   *VerifyCodeService
+	configService *SystemConfigService
+	logger        *logger.Logger
+}
+
+func NewContactService(
+	repo *repository.ContactRepository,
+	userRepo *repository.UserRepository,
+	verifyCodes *VerifyCodeService,
+	configService *SystemConfigService,
+	logger *logger.Logger,
+) *ContactService {
+	return &ContactService{
+		repo:          repo,
+		userRepo:      userRepo,
+		verifyCodes:   verifyCodes,
+		configService: configService,
+		logger:        logger,
+	}
+}
+
+// List 获取用户的紧急联系人
+func (s *ContactService) List(userID uint) ([]model.EmergencyContact, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// Create 添加紧急联系人并发送邀请短信。
+// 用户的第一个联系人自动设为默认；邀请短信发送失败不影响添加，InvitedAt 为空时可重新发送
+func (s *ContactService) Create(userID uint, req *model.CreateContactRequest, ip string) (*model.EmergencyContact, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	phone := strings.TrimSpace(req.Phone)
+	if err := s.checkPhone(user, phone, 0); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= int64(s.configService.GetInt(configContactMaxCount, defaultContactMaxCount)) {
+		return nil, errors.ErrContactLimit
+	}
+
+	contact := &model.EmergencyContact{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Phone:     phone,
+		Email:     req.Email,
+		Relation:  req.Relation,
+		IsDefault: req.IsDefault || count == 0,
+		Status:    model.ContactStatusPending,
+	}
+	if err := s.repo.Create(contact); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.invite(user, contact, ip); err != nil {
+		s.logger.Error("发送紧急联系人邀请失败: contact_id=%d err=%v", contact.ID, err)
+	}
+	return contact, nil
+}
+
+// Update 修改紧急联系人，修改手机号后联系人回到待确认状态并重新发送邀请
+func (s *ContactService) Update(userID, id uint, req *model.UpdateContactRequest, ip string) (*model.EmergencyContact, error) {
+	contact, err := s.get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	contact.Name = strings.TrimSpace(req.Name)
+	contact.Email = req.Email
+	contact.Relation = req.Relation
+
+	phone := strings.TrimSpace(req.Phone)
+	if phone == contact.Phone {
+		if err := s.repo.Update(contact); err != nil {
+			return nil, err
+		}
+		return contact, nil
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPhone(user, phone, contact.ID); err != nil {
+		return nil, err
+	}
+	contact.Phone = phone
+	contact.Status = model.ContactStatusPending
+	contact.InvitedAt = nil
+	contact.VerifiedAt = nil
+	if err := s.repo.Update(contact); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.invite(user, contact, ip); err != nil {
+		s.logger.Error("发送紧急联系人邀请失败: contact_id=%d err=%v", contact.ID, err)
+	}
+	return contact, nil
+}
+
+// Delete 删除紧急联系人
+func (s *ContactService) Delete(userID, id uint) error {
+	contact, err := s.get(userID, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(contact)
+}
+
+// SetDefault 设置默认紧急联系人，同一用户只有一个默认联系人
+func (s *ContactService) SetDefault(userID, id uint) error {
+	if _, err := s.get(userID, id); err != nil {
+		return err
+	}
+	return s.repo.SetDefault(userID, id)
+}
+
+// Invite 重新发送邀请短信，返回验证码有效期
+func (s *ContactService) Invite(userID, id uint, ip string) (time.Duration, error) {
+	contact, err := s.get(userID, id)
+	if err != nil {
+		return 0, err
+	}
+	if contact.Status == model.ContactStatusVerified {
+		return 0, errors.ErrContactVerified
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	return s.invite(user, contact, ip)
+}
+
+// Verify 用联系人告知的验证码确认紧急联系人
+func (s *ContactService) Verify(userID, id uint, code string) (*model.EmergencyContact, error) {
+	contact, err := s.get(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if contact.Status == model.ContactStatusVerified {
+		return nil, errors.ErrContactVerified
+	}
+	if err := s.verifyCodes.Verify(contact.Phone, ContactInvitePurpose(contact.ID), code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	contact.Status = model.ContactStatusVerified
+	contact.VerifiedAt = &now
+	if err := s.repo.Update(contact); err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+// get 获取属于该用户的紧急联系人
+func (s *ContactService) get(userID, id uint) (*model.EmergencyContact, error) {
+	contact, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil || contact.UserID != userID {
+		return nil, errors.ErrContactNotFound
+	}
+	return contact, nil
+}
+
+// checkPhone 校验联系人手机号：格式有效、不是用户本人、未重复添加
+func (s *ContactService) checkPhone(user *model.User, phone string, excludeID uint) error {
+	if !ValidPhone(phone) {
+		return errors.ErrInvalidPhone
+	}
+	if phone == user.Phone {
+		return errors.ErrContactIsSelf
+	}
+	exists, err := s.repo.ExistsByPhone(user.ID, phone, excludeID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.ErrContactExists
+	}
+	return nil
+}
+
+// invite 发送邀请短信并记录发送时间
+func (s *ContactService) invite(user *model.User, contact *model.EmergencyContact, ip string) (time.Duration, error) {
+	inviter := user.Name
+	if inviter == "" {
+		inviter = user.Phone
+	}
+	ttl, err := s.verifyCodes.SendContactInvite(contact.ID, contact.Phone, inviter, ip)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	contact.InvitedAt = &now
+	if err := s.repo.Update(contact); err != nil {
+		return 0, err
+	}
+	return ttl, nil
+}
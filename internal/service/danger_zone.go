@@ -3,14 +3,22 @@ package service
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/geo"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type DangerZoneService struct {
 	repo *repository.DangerZoneRepository
+	geo  *GeoService
 }
 
-func NewDangerZoneService(repo *repository.DangerZoneRepository) *DangerZoneService {
-	return &DangerZoneService{repo: repo}
+func NewDangerZoneService(repo *repository.DangerZoneRepository, geo *GeoService) *DangerZoneService {
+	return &DangerZoneService{repo: repo, geo: geo}
 }
 
 // CreateDangerZone 创建危险区域
@@ -22,9 +30,21 @@ func (s *DangerZoneService) CreateDangerZone(req *model.CreateDangerZoneRequest)
 		Latitude:    req.Latitude,
 		Longitude:   req.Longitude,
 		Radius:      req.Radius,
+		Shape:       model.DangerZoneShapeCircle,
+		IsActive:    true,
+	}
+	if req.Geometry != nil {
+		if err := applyZoneGeometry(zone, req.Geometry); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.CreateDangerZone(zone); err != nil {
+		return err
 	}
 
-	return s.repo.CreateDangerZone(zone)
+	s.geo.SyncZone(zone)
+	return nil
 }
 
 // GetDangerZoneByID 根据ID获取危险区域
@@ -53,6 +73,14 @@ func (s *DangerZoneService) UpdateDangerZone(id uint, req *model.UpdateDangerZon
 	if req.Level != "" {
 		zone.Level = req.Level
 	}
+	if req.Geometry != nil {
+		if err := applyZoneGeometry(zone, req.Geometry); err != nil {
+			return err
+		}
+	} else if zone.Shape == model.DangerZoneShapePolygon && (req.Latitude != 0 || req.Longitude != 0 || req.Radius != 0) {
+		// 多边形区域的中心点和半径由几何对象计算得出，不能单独修改
+		return errors.ErrInvalidParameter
+	}
 	if req.Latitude != 0 {
 		zone.Latitude = req.Latitude
 	}
@@ -63,31 +91,37 @@ func (s *DangerZoneService) UpdateDangerZone(id uint, req *model.UpdateDangerZon
 		zone.Radius = req.Radius
 	}
 
-	return s.repo.UpdateDangerZone(zone)
+	if err := s.repo.UpdateDangerZone(zone); err != nil {
+		return err
+	}
+
+	s.geo.SyncZone(zone)
+	return nil
 }
 
 // DeleteDangerZone 删除危险区域
 func (s *DangerZoneService) DeleteDangerZone(id uint) error {
-	return s.repo.DeleteDangerZone(id)
+	if err := s.repo.DeleteDangerZone(id); err != nil {
+		return err
+	}
+
+	s.geo.RemoveZone(id)
+	return nil
 }
 
-// CheckLocationInDangerZone 检查位置是否在危险区域内
-func (s *DangerZoneService) CheckLocationInDangerZone(lat, lng float64) (bool, []model.DangerZone, error) {
-	zones, err := s.repo.GetNearbyDangerZones(lat, lng)
+// CheckLocationInDangerZone 检查位置是否在危险区域内，圆形区域按自身半径判断，多边形区域按点是否在多边形内判断
+func (s *DangerZoneService) CheckLocationInDangerZone(lat, lng float64) (bool, []model.NearbyDangerZone, error) {
+	zones, err := s.geo.ZonesContaining(lat, lng)
 	if err != nil {
 		return false, nil, err
 	}
 
-	if len(zones) > 0 {
-		return true, zones, nil
-	}
-
-	return false, nil, nil
+	return len(zones) > 0, zones, nil
 }
 
-// GetNearbyZones 获取附近的危险区域
-func (s *DangerZoneService) GetNearbyZones(latitude, longitude, radius float64) ([]model.DangerZone, error) {
-	return s.repo.GetNearbyDangerZones(latitude, longitude)
+// GetNearbyZones 获取与半径范围相交的危险区域，按距离由近到远排序
+func (s *DangerZoneService) GetNearbyZones(latitude, longitude, radius float64) ([]model.NearbyDangerZone, error) {
+	return s.geo.NearbyZones(latitude, longitude, radius)
 }
 
 // GetAllActiveZones 获取所有活跃的危险区域
@@ -104,7 +138,201 @@ func (s *DangerZoneService) GetAllActiveZones() ([]model.DangerZone, error) {
 	return result, nil
 }
 
-// UpdateHeatLevel 更新危险区域的热度等级
+// UpdateHeatLevel 手动更新危险区域的热度等级，后台热度计算会在下一轮覆盖该值
 func (s *DangerZoneService) UpdateHeatLevel(id uint, heatLevel int) error {
-	return s.repo.UpdateHeatLevel(id, heatLevel)
+	if heatLevel < 0 || heatLevel > maxHeatLevel {
+		return errors.ErrInvalidParameter
+	}
+	if err := s.repo.UpdateHeatLevel(id, heatLevel); err != nil {
+		return err
+	}
+
+	return s.repo.CreateHeatHistories([]*model.DangerZoneHeatHistory{{
+		ZoneID:    id,
+		HeatLevel: heatLevel,
+		Source:    model.HeatSourceManual,
+	}})
+}
+
+// ListHeatHistory 获取危险区域的热度历史
+func (s *DangerZoneService) ListHeatHistory(id uint, from, to time.Time) ([]model.DangerZoneHeatHistory, error) {
+	if !from.Before(to) {
+		return nil, errors.ErrInvalidParameter
+	}
+	return s.repo.ListHeatHistory(id, from, to)
+}
+
+// ExportGeoJSON 将危险区域导出为 GeoJSON 要素集合，圆形区域导出为带 radius 属性的点
+func (s *DangerZoneService) ExportGeoJSON(activeOnly bool) (*geo.FeatureCollection, error) {
+	var zones []*model.DangerZone
+	var err error
+	if activeOnly {
+		zones, err = s.repo.GetAllActiveZones()
+	} else {
+		zones, err = s.repo.ListAll()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	features := make([]*geo.Feature, 0, len(zones))
+	for _, zone := range zones {
+		properties := map[string]interface{}{
+			"id":          zone.ID,
+			"name":        zone.Name,
+			"description": zone.Description,
+			"level":       zone.Level,
+			"heat_level":  zone.HeatLevel,
+			"is_active":   zone.IsActive,
+		}
+
+		var geometry *geo.Geometry
+		if zone.Shape == model.DangerZoneShapePolygon {
+			geometry = &geo.Geometry{}
+			if err := json.Unmarshal(zone.Geometry, geometry); err != nil {
+				return nil, err
+			}
+		} else {
+			geometry = geo.NewPoint(zone.Latitude, zone.Longitude)
+			properties["radius"] = zone.Radius
+		}
+
+		feature := geo.NewFeature(geometry, properties)
+		feature.ID = zone.ID
+		features = append(features, feature)
+	}
+	return geo.NewFeatureCollection(features...), nil
+}
+
+// ImportGeoJSON 从 GeoJSON 要素集合批量导入危险区域，全部成功或全部失败。
+// 要素属性中带 id 的更新对应区域，否则新建；点要素需在属性中提供 radius（米）
+func (s *DangerZoneService) ImportGeoJSON(collection *geo.FeatureCollection) (created, updated int, err error) {
+	if collection.Type != geo.TypeFeatureCollection || len(collection.Features) == 0 {
+		return 0, 0, errors.ErrInvalidParameter
+	}
+
+	zones := make([]*model.DangerZone, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		zone, err := s.zoneFromFeature(feature)
+		if err != nil {
+			return 0, 0, fmt.Errorf("第%d个要素：%w", i+1, err)
+		}
+		zones = append(zones, zone)
+	}
+
+	for _, zone := range zones {
+		if zone.ID == 0 {
+			created++
+		} else {
+			updated++
+		}
+	}
+	if err := s.repo.SaveZones(zones); err != nil {
+		return 0, 0, err
+	}
+
+	for _, zone := range zones {
+		s.geo.SyncZone(zone)
+	}
+	return created, updated, nil
+}
+
+// zoneFromFeature 将 GeoJSON 要素转换为危险区域，带 id 属性时在已有区域上修改
+func (s *DangerZoneService) zoneFromFeature(feature *geo.Feature) (*model.DangerZone, error) {
+	if feature == nil || feature.Type != geo.TypeFeature || feature.Geometry == nil {
+		return nil, errors.ErrInvalidParameter
+	}
+
+	zone := &model.DangerZone{IsActive: true}
+	if id, ok := feature.Properties["id"].(float64); ok {
+		existing, err := s.repo.GetDangerZoneByID(uint(id))
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("危险区域 %d 不存在", uint(id))
+		}
+		if err != nil {
+			return nil, err
+		}
+		zone = existing
+	}
+
+	if name, ok := feature.Properties["name"].(string); ok && name != "" {
+		zone.Name = name
+	}
+	if description, ok := feature.Properties["description"].(string); ok {
+		zone.Description = description
+	}
+	if level, ok := feature.Properties["level"].(string); ok {
+		zone.Level = level
+	}
+	if isActive, ok := feature.Properties["is_active"].(bool); ok {
+		zone.IsActive = isActive
+	}
+	if zone.Name == "" {
+		return nil, fmt.Errorf("缺少 name 属性")
+	}
+	if zone.Level != "low" && zone.Level != "medium" && zone.Level != "high" {
+		return nil, fmt.Errorf("level 属性必须为 low、medium 或 high")
+	}
+
+	if feature.Geometry.Type == geo.TypePoint {
+		var coordinates [2]float64
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &coordinates); err != nil {
+			return nil, errors.ErrInvalidLocation
+		}
+		radius, ok := feature.Properties["radius"].(float64)
+		if !ok || radius <= 0 {
+			return nil, fmt.Errorf("点要素缺少有效的 radius 属性")
+		}
+		zone.Shape = model.DangerZoneShapeCircle
+		zone.Geometry = nil
+		zone.Longitude, zone.Latitude, zone.Radius = coordinates[0], coordinates[1], radius
+		return zone, nil
+	}
+
+	raw, err := json.Marshal(feature.Geometry)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyZoneGeometry(zone, raw); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// applyZoneGeometry 将多边形几何对象写入危险区域，并以其外接圆作为中心点和半径，供空间索引粗筛
+func applyZoneGeometry(zone *model.DangerZone, raw json.RawMessage) error {
+	var geometry geo.Geometry
+	if err := json.Unmarshal(raw, &geometry); err != nil {
+		return geo.ErrInvalidPolygon
+	}
+	shape, err := geo.ParseMultiPolygon(&geometry)
+	if err != nil {
+		return err
+	}
+
+	normalized, err := json.Marshal(shape.Geometry())
+	if err != nil {
+		return err
+	}
+	zone.Shape = model.DangerZoneShapePolygon
+	zone.Geometry = normalized
+	zone.Latitude, zone.Longitude, zone.Radius = shape.Circumcircle()
+	return nil
+}
+
+// zoneContains 判断位置是否位于危险区域内，distance 为位置到区域中心的距离（米）
+func zoneContains(zone *model.DangerZone, lat, lng, distance float64) bool {
+	if zone.Shape != model.DangerZoneShapePolygon {
+		return distance <= zone.Radius
+	}
+
+	var geometry geo.Geometry
+	if err := json.Unmarshal(zone.Geometry, &geometry); err != nil {
+		return false
+	}
+	shape, err := geo.ParseMultiPolygon(&geometry)
+	if err != nil {
+		return false
+	}
+	return shape.Contains(lat, lng)
 }
@@ -0,0 +1,591 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/geo"
+	"dididaren/pkg/logger"
+	"dididaren/pkg/realtime"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// 派单相关的系统配置项，未配置时使用默认值
+const (
+	configDispatchInitialRadius  = "dispatch.initial_radius"   // 首轮搜索半径（米）
+	configDispatchMaxRadius      = "dispatch.max_radius"       // 最大搜索半径（米）
+	configDispatchAcceptTimeout  = "dispatch.accept_timeout"   // 每轮等待接单的时间（秒）
+	configDispatchOffersPerRound = "dispatch.offers_per_round" // 每轮最多派给几名安保人员
+	configDispatchMaxRounds      = "dispatch.max_rounds"       // 最多派单轮数
+
+	defaultDispatchInitialRadius  = 1000
+	defaultDispatchMaxRadius      = 8000
+	defaultDispatchAcceptTimeout  = 30
+	defaultDispatchOffersPerRound = 3
+	defaultDispatchMaxRounds      = 6
+)
+
+// 处理记录中的派单动作
+const (
+	actionDispatchOffer   = "dispatch_offer"
+	actionDispatchDecline = "dispatch_decline"
+	actionDispatchTimeout = "dispatch_timeout"
+)
+
+type dispatchSettings struct {
+	initialRadius  float64
+	maxRadius      float64
+	acceptTimeout  time.Duration
+	offersPerRound int
+	maxRounds      int
+}
+
+// DispatchService 就近派单引擎：按真实距离为新事件挑选在线安保人员，
+// 超时无人接单时扩大搜索半径进入下一轮，所有派单和拒单都会写入处理记录
+type DispatchService struct {
+	emergencyRepo *repository.EmergencyRepository
+	securityRepo  *repository.SecurityRepository
+	dispatchRepo  *repository.DispatchRepository
+	geo           *GeoService
+	stateMachine  *EmergencyStateMachine
+	configService *SystemConfigService
+	hub           *realtime.Hub
+	logger        *logger.Logger
+
+	// 单节点部署，同一事件的派单状态变更在该事件的锁内完成，不同事件互不阻塞；
+	// mu 只保护 locks 和 timers，持有期间不访问数据库
+	mu     sync.Mutex
+	locks  map[uint]*emergencyLock
+	timers map[uint]*roundTimer
+}
+
+// emergencyLock 单个事件的派单锁，refs 为正在使用或等待该锁的协程数，归零后回收
+type emergencyLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// roundTimer 某一轮派单的超时定时器
+type roundTimer struct {
+	timer *time.Timer
+	round int
+}
+
+func NewDispatchService(
+	emergencyRepo *repository.EmergencyRepository,
+	securityRepo *repository.SecurityRepository,
+	dispatchRepo *repository.DispatchRepository,
+	geo *GeoService,
+	stateMachine *EmergencyStateMachine,
+	configService *SystemConfigService,
+	hub *realtime.Hub,
+	logger *logger.Logger,
+) *DispatchService {
+	return &DispatchService{
+		emergencyRepo: emergencyRepo,
+		securityRepo:  securityRepo,
+		dispatchRepo:  dispatchRepo,
+		geo:           geo,
+		stateMachine:  stateMachine,
+		configService: configService,
+		hub:           hub,
+		logger:        logger,
+		locks:         make(map[uint]*emergencyLock),
+		timers:        make(map[uint]*roundTimer),
+	}
+}
+
+// Dispatch 为紧急事件发起派单，已有进行中的派单时不重复发起。
+// 转人工后重新派单会开始新的派单周期，从首轮半径重新搜索，此前未接单的安保人员也会再次收到派单
+func (s *DispatchService) Dispatch(emergencyID uint) error {
+	defer s.lock(emergencyID)()
+
+	if s.hasTimer(emergencyID) {
+		return nil
+	}
+	return s.nextRound(emergencyID)
+}
+
+// DispatchAsync 在后台为紧急事件发起派单，失败时记录日志
+func (s *DispatchService) DispatchAsync(emergencyID uint) {
+	go func() {
+		if err := s.Dispatch(emergencyID); err != nil {
+			s.logger.Error("事件 %d 派单失败: %v", emergencyID, err)
+		}
+	}()
+}
+
+// Resume 服务启动时恢复未完成的派单
+func (s *DispatchService) Resume() error {
+	emergencies, err := s.emergencyRepo.ListByStatus(model.EmergencyStatusPending, model.EmergencyStatusDispatched)
+	if err != nil {
+		return err
+	}
+
+	for _, emergency := range emergencies {
+		if err := s.resume(emergency.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resume 恢复单个事件的派单：本轮未超时则继续计时，否则结束本轮进入下一轮
+func (s *DispatchService) resume(emergencyID uint) error {
+	defer s.lock(emergencyID)()
+
+	round, err := s.dispatchRepo.GetLatestRound(emergencyID)
+	if err != nil {
+		return err
+	}
+
+	offers, err := s.dispatchRepo.ListPendingOffersByRound(emergencyID, round)
+	if err != nil {
+		return err
+	}
+
+	// 本轮仍有未超时的派单，按剩余时间重新计时
+	var expiresAt time.Time
+	for _, offer := range offers {
+		if offer.ExpiresAt.After(expiresAt) {
+			expiresAt = offer.ExpiresAt
+		}
+	}
+	if remaining := time.Until(expiresAt); remaining > 0 {
+		s.schedule(emergencyID, round, remaining)
+		return nil
+	}
+
+	if err := s.expireRound(emergencyID, round); err != nil {
+		return err
+	}
+	if err := s.nextRound(emergencyID); err != nil {
+		s.logger.Error("事件 %d 恢复派单失败: %v", emergencyID, err)
+	}
+	return nil
+}
+
+// Accept 安保人员接受派单，同一事件只有第一个接单的人会成功
+func (s *DispatchService) Accept(staffID, emergencyID uint) error {
+	defer s.lock(emergencyID)()
+
+	offer, err := s.dispatchRepo.GetPendingOffer(emergencyID, staffID)
+	if err != nil {
+		return err
+	}
+	if offer == nil {
+		return errors.ErrOfferNotFound
+	}
+	if time.Now().After(offer.ExpiresAt) {
+		if _, err := s.dispatchRepo.RespondOffer(offer.ID, model.OfferStatusExpired, ""); err != nil {
+			return err
+		}
+		return errors.ErrOfferNotFound
+	}
+
+	// 不同事件并行派单，同一安保人员可能同时收到多个事件的派单，只能接其中一个
+	busy, err := s.emergencyRepo.ListStaffIDsByStatus(
+		model.EmergencyStatusAccepted,
+		model.EmergencyStatusEnRoute,
+		model.EmergencyStatusOnScene,
+	)
+	if err != nil {
+		return err
+	}
+	for _, id := range busy {
+		if id == staffID {
+			return errors.ErrStaffBusy
+		}
+	}
+
+	_, err = s.stateMachine.Transit(emergencyID, model.EmergencyStatusAccepted, staffID,
+		fmt.Sprintf("安保人员接单（第%d轮，距离%.0f米）", offer.Round, offer.Distance),
+		map[string]interface{}{"staff_id": staffID})
+	if err != nil {
+		return err
+	}
+
+	s.stopTimer(emergencyID)
+	if _, err := s.dispatchRepo.RespondOffer(offer.ID, model.OfferStatusAccepted, ""); err != nil {
+		return err
+	}
+	if err := s.cancelPendingOffers(emergencyID); err != nil {
+		return err
+	}
+	return s.securityRepo.IncrementOrderCount(staffID)
+}
+
+// Stop 停止事件的派单，取消所有等待响应的派单
+func (s *DispatchService) Stop(emergencyID uint) error {
+	defer s.lock(emergencyID)()
+
+	s.stopTimer(emergencyID)
+	return s.cancelPendingOffers(emergencyID)
+}
+
+// Decline 安保人员拒绝派单，本轮所有人都拒绝后立即进入下一轮
+func (s *DispatchService) Decline(staffID, emergencyID uint, reason string) error {
+	defer s.lock(emergencyID)()
+
+	offer, err := s.dispatchRepo.GetPendingOffer(emergencyID, staffID)
+	if err != nil {
+		return err
+	}
+	if offer == nil {
+		return errors.ErrOfferNotFound
+	}
+
+	ok, err := s.dispatchRepo.RespondOffer(offer.ID, model.OfferStatusDeclined, reason)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.ErrOfferNotFound
+	}
+
+	description := "安保人员拒绝派单"
+	if reason != "" {
+		description += "：" + reason
+	}
+	if err := s.record(emergencyID, staffID, actionDispatchDecline, description); err != nil {
+		return err
+	}
+
+	pending, err := s.dispatchRepo.ListPendingOffersByRound(emergencyID, offer.Round)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return nil
+	}
+
+	latest, err := s.dispatchRepo.GetLatestRound(emergencyID)
+	if err != nil {
+		return err
+	}
+	if offer.Round != latest {
+		return nil
+	}
+
+	s.stopTimer(emergencyID)
+	return s.nextRound(emergencyID)
+}
+
+// ListOffers 获取事件的派单记录
+func (s *DispatchService) ListOffers(emergencyID uint) ([]model.DispatchOffer, error) {
+	return s.dispatchRepo.ListOffers(emergencyID)
+}
+
+// ListPendingOffers 获取安保人员待响应的派单
+func (s *DispatchService) ListPendingOffers(staffID uint) ([]model.DispatchOffer, error) {
+	return s.dispatchRepo.ListPendingOffersByStaff(staffID)
+}
+
+// nextRound 扩大半径发起下一轮派单，调用方需持有事件的锁
+func (s *DispatchService) nextRound(emergencyID uint) error {
+	emergency, err := s.emergencyRepo.GetByID(emergencyID)
+	if err != nil {
+		return err
+	}
+	if emergency.Status != model.EmergencyStatusPending && emergency.Status != model.EmergencyStatusDispatched {
+		return nil
+	}
+
+	round, err := s.dispatchRepo.GetLatestRound(emergencyID)
+	if err != nil {
+		return err
+	}
+	base, err := s.cycleStart(emergency)
+	if err != nil {
+		return err
+	}
+
+	excluded, err := s.excludedStaff(emergencyID, base)
+	if err != nil {
+		return err
+	}
+
+	settings := s.settings()
+	for round < base+settings.maxRounds {
+		round++
+		radius := math.Min(settings.initialRadius*math.Pow(2, float64(round-base-1)), settings.maxRadius)
+
+		candidates := s.findCandidates(emergency, radius, excluded)
+		if len(candidates) > settings.offersPerRound {
+			candidates = candidates[:settings.offersPerRound]
+		}
+
+		if len(candidates) > 0 {
+			return s.offer(emergency, round, radius, candidates, settings.acceptTimeout)
+		}
+		if radius >= settings.maxRadius {
+			break
+		}
+	}
+
+	return s.escalate(emergency)
+}
+
+// findCandidates 查找半径内可派单的安保人员，按距离由近到远排序
+func (s *DispatchService) findCandidates(emergency *model.Emergency, radius float64, excluded map[uint]bool) []geo.Match {
+	var candidates []geo.Match
+	for _, match := range s.geo.NearbyStaffMatches(emergency.Latitude, emergency.Longitude, radius) {
+		if !excluded[match.ID] {
+			candidates = append(candidates, match)
+		}
+	}
+	return candidates
+}
+
+// cycleStart 返回本派单周期之前已用掉的轮次。事件转人工后重新派单时开始新的周期，
+// 转人工之前的派单不计入本周期的轮数
+func (s *DispatchService) cycleStart(emergency *model.Emergency) (int, error) {
+	if emergency.EscalatedAt == nil {
+		return 0, nil
+	}
+	return s.dispatchRepo.GetLatestRoundBefore(emergency.ID, *emergency.EscalatedAt)
+}
+
+// excludedStaff 返回不参与本事件派单的安保人员：本周期已派过本事件的、正等待响应其他派单的、正在处理其他事件的
+func (s *DispatchService) excludedStaff(emergencyID uint, base int) (map[uint]bool, error) {
+	excluded := make(map[uint]bool)
+
+	offered, err := s.dispatchRepo.ListOfferedStaffIDs(emergencyID, base)
+	if err != nil {
+		return nil, err
+	}
+	engaged, err := s.dispatchRepo.ListEngagedStaffIDs()
+	if err != nil {
+		return nil, err
+	}
+	busy, err := s.emergencyRepo.ListStaffIDsByStatus(
+		model.EmergencyStatusAccepted,
+		model.EmergencyStatusEnRoute,
+		model.EmergencyStatusOnScene,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ids := range [][]uint{offered, engaged, busy} {
+		for _, id := range ids {
+			excluded[id] = true
+		}
+	}
+	return excluded, nil
+}
+
+// offer 向候选人派单并开始本轮计时
+func (s *DispatchService) offer(emergency *model.Emergency, round int, radius float64, candidates []geo.Match, timeout time.Duration) error {
+	expiresAt := time.Now().Add(timeout)
+	for _, candidate := range candidates {
+		offer := &model.DispatchOffer{
+			EmergencyID: emergency.ID,
+			StaffID:     candidate.ID,
+			Round:       round,
+			Radius:      radius,
+			Distance:    candidate.Distance,
+			Status:      model.OfferStatusPending,
+			ExpiresAt:   expiresAt,
+		}
+		if err := s.dispatchRepo.CreateOffer(offer); err != nil {
+			return err
+		}
+		publishOffer(s.hub, realtime.EventDispatchOffer, offer)
+		if err := s.record(emergency.ID, candidate.ID, actionDispatchOffer,
+			fmt.Sprintf("第%d轮派单，搜索半径%.0f米，距离%.0f米", round, radius, candidate.Distance)); err != nil {
+			return err
+		}
+	}
+
+	if emergency.Status != model.EmergencyStatusDispatched {
+		remark := fmt.Sprintf("第%d轮派单给%d名安保人员", round, len(candidates))
+		if _, err := s.stateMachine.Transit(emergency.ID, model.EmergencyStatusDispatched, 0, remark, nil); err != nil {
+			return err
+		}
+	}
+
+	s.schedule(emergency.ID, round, timeout)
+	return nil
+}
+
+// escalate 所有轮次都无人接单，转人工处理
+func (s *DispatchService) escalate(emergency *model.Emergency) error {
+	if emergency.Status == model.EmergencyStatusEscalated {
+		return nil
+	}
+	if _, err := s.stateMachine.Transit(emergency.ID, model.EmergencyStatusEscalated, 0, "附近无安保人员接单，转人工处理", nil); err != nil {
+		return err
+	}
+
+	s.logger.Warn("事件 %d 无人接单，已转人工处理", emergency.ID)
+	return nil
+}
+
+// expireRound 将本轮未响应的派单置为超时，调用方需持有事件的锁
+func (s *DispatchService) expireRound(emergencyID uint, round int) error {
+	expired, err := s.dispatchRepo.CloseRound(emergencyID, round, model.OfferStatusExpired)
+	if err != nil {
+		return err
+	}
+	for i := range expired {
+		offer := &expired[i]
+		publishOffer(s.hub, realtime.EventOfferClosed, offer)
+		if err := s.record(emergencyID, offer.StaffID, actionDispatchTimeout,
+			fmt.Sprintf("第%d轮派单超时未响应", round)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onTimeout 本轮派单超时，进入下一轮
+func (s *DispatchService) onTimeout(emergencyID uint, round int) {
+	defer s.lock(emergencyID)()
+
+	s.clearTimer(emergencyID, round)
+
+	latest, err := s.dispatchRepo.GetLatestRound(emergencyID)
+	if err != nil {
+		s.logger.Error("事件 %d 派单超时处理失败: %v", emergencyID, err)
+		return
+	}
+	if round != latest {
+		return
+	}
+
+	if err := s.expireRound(emergencyID, round); err != nil {
+		s.logger.Error("事件 %d 派单超时处理失败: %v", emergencyID, err)
+		return
+	}
+	if err := s.nextRound(emergencyID); err != nil {
+		s.logger.Error("事件 %d 派单失败: %v", emergencyID, err)
+	}
+}
+
+// lock 获取事件的派单锁，返回释放函数
+func (s *DispatchService) lock(emergencyID uint) func() {
+	s.mu.Lock()
+	l, ok := s.locks[emergencyID]
+	if !ok {
+		l = &emergencyLock{}
+		s.locks[emergencyID] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		s.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.locks, emergencyID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// hasTimer 判断事件是否有进行中的派单
+func (s *DispatchService) hasTimer(emergencyID uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.timers[emergencyID]
+	return ok
+}
+
+// schedule 设置本轮超时定时器，调用方需持有事件的锁
+func (s *DispatchService) schedule(emergencyID uint, round int, timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.timers[emergencyID]; ok {
+		t.timer.Stop()
+	}
+	s.timers[emergencyID] = &roundTimer{
+		timer: time.AfterFunc(timeout, func() {
+			s.onTimeout(emergencyID, round)
+		}),
+		round: round,
+	}
+}
+
+// clearTimer 移除已触发的定时器，定时器已被新一轮替换时保留新定时器
+func (s *DispatchService) clearTimer(emergencyID uint, round int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.timers[emergencyID]; ok && t.round == round {
+		delete(s.timers, emergencyID)
+	}
+}
+
+// stopTimer 取消事件的超时定时器，调用方需持有事件的锁
+func (s *DispatchService) stopTimer(emergencyID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.timers[emergencyID]; ok {
+		t.timer.Stop()
+		delete(s.timers, emergencyID)
+	}
+}
+
+// cancelPendingOffers 取消事件所有等待响应的派单并通知对应安保人员，调用方需持有事件的锁
+func (s *DispatchService) cancelPendingOffers(emergencyID uint) error {
+	cancelled, err := s.dispatchRepo.CancelPendingOffers(emergencyID)
+	if err != nil {
+		return err
+	}
+	for i := range cancelled {
+		publishOffer(s.hub, realtime.EventOfferClosed, &cancelled[i])
+	}
+	return nil
+}
+
+func (s *DispatchService) record(emergencyID, staffID uint, action, description string) error {
+	record := &model.HandlingRecord{
+		EmergencyID: emergencyID,
+		StaffID:     staffID,
+		Action:      action,
+		Description: description,
+	}
+	if err := s.emergencyRepo.CreateHandlingRecord(record); err != nil {
+		return err
+	}
+
+	publishHandlingRecord(s.hub, record)
+	return nil
+}
+
+// settings 读取派单配置，配置值不合法时使用默认值，避免派单不出或立即超时
+func (s *DispatchService) settings() dispatchSettings {
+	settings := dispatchSettings{
+		initialRadius:  s.configService.GetFloat(configDispatchInitialRadius, defaultDispatchInitialRadius),
+		maxRadius:      s.configService.GetFloat(configDispatchMaxRadius, defaultDispatchMaxRadius),
+		acceptTimeout:  s.configService.GetDuration(configDispatchAcceptTimeout, time.Second, defaultDispatchAcceptTimeout*time.Second),
+		offersPerRound: s.configService.GetInt(configDispatchOffersPerRound, defaultDispatchOffersPerRound),
+		maxRounds:      s.configService.GetInt(configDispatchMaxRounds, defaultDispatchMaxRounds),
+	}
+	if settings.initialRadius <= 0 {
+		settings.initialRadius = defaultDispatchInitialRadius
+	}
+	if settings.maxRadius < settings.initialRadius {
+		settings.maxRadius = math.Max(defaultDispatchMaxRadius, settings.initialRadius)
+	}
+	if settings.acceptTimeout <= 0 {
+		settings.acceptTimeout = defaultDispatchAcceptTimeout * time.Second
+	}
+	if settings.offersPerRound <= 0 {
+		settings.offersPerRound = defaultDispatchOffersPerRound
+	}
+	if settings.maxRounds <= 0 {
+		settings.maxRounds = defaultDispatchMaxRounds
+	}
+	return settings
+}
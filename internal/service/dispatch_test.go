@@ -0,0 +1,234 @@
+package service
+
+import (
+	"dididaren/internal/migrations"
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/config"
+	"dididaren/pkg/database"
+	"dididaren/pkg/logger"
+	"dididaren/pkg/realtime"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 事件位置，安保人员放在正北方向，纬度每度约 111195 米
+const (
+	testLat          = 39.9087
+	testLng          = 116.3975
+	metersPerDegree  = 111195.0
+	testAcceptWindow = "60" // 测试中手动触发超时，定时器不会到期
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Database.Driver = "sqlite"
+	cfg.Database.Database = ":memory:"
+	db, err := database.Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestDispatchService(t *testing.T, db *gorm.DB, configs map[string]string) *DispatchService {
+	t.Helper()
+	for key, value := range configs {
+		if err := db.Create(&model.SystemConfig{Key: key, Value: value, Type: "int"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log := logger.NewLogger("error")
+	hub := realtime.NewHub()
+	emergencyRepo := repository.NewEmergencyRepository(db)
+	securityRepo := repository.NewSecurityRepository(db)
+	return NewDispatchService(
+		emergencyRepo,
+		securityRepo,
+		repository.NewDispatchRepository(db),
+		NewGeoService(repository.NewDangerZoneRepository(db), securityRepo, log),
+		NewEmergencyStateMachine(emergencyRepo, hub),
+		NewSystemConfigService(repository.NewSystemConfigRepository(db)),
+		hub,
+		log,
+	)
+}
+
+type testRound struct {
+	round  int
+	radius float64
+	staff  []uint
+}
+
+func TestDispatchRoundEscalation(t *testing.T) {
+	tests := []struct {
+		name       string
+		staff      map[uint]float64 // 安保人员的用户ID -> 与事件的距离（米）
+		timeouts   int              // 依次触发几轮超时
+		wantRounds []testRound
+		wantStatus int
+	}{
+		{
+			name:       "首轮半径内有人",
+			staff:      map[uint]float64{1: 500},
+			wantRounds: []testRound{{1, 1000, []uint{1}}},
+			wantStatus: model.EmergencyStatusDispatched,
+		},
+		{
+			name:       "每轮最多派给3人，由近到远",
+			staff:      map[uint]float64{1: 900, 2: 100, 3: 700, 4: 300, 5: 500},
+			wantRounds: []testRound{{1, 1000, []uint{2, 4, 5}}},
+			wantStatus: model.EmergencyStatusDispatched,
+		},
+		{
+			name:       "前几轮无人时半径倍增，跳过空轮",
+			staff:      map[uint]float64{1: 3000},
+			wantRounds: []testRound{{3, 4000, []uint{1}}},
+			wantStatus: model.EmergencyStatusDispatched,
+		},
+		{
+			name:     "超时后扩大半径，不再派给已派过的人",
+			staff:    map[uint]float64{1: 500, 2: 1500},
+			timeouts: 1,
+			wantRounds: []testRound{
+				{1, 1000, []uint{1}},
+				{2, 2000, []uint{2}},
+			},
+			wantStatus: model.EmergencyStatusDispatched,
+		},
+		{
+			name:     "达到最大半径仍无人接单时转人工",
+			staff:    map[uint]float64{1: 500, 2: 1500},
+			timeouts: 2,
+			wantRounds: []testRound{
+				{1, 1000, []uint{1}},
+				{2, 2000, []uint{2}},
+			},
+			wantStatus: model.EmergencyStatusEscalated,
+		},
+		{
+			name:       "最大半径内没有安保人员时直接转人工",
+			staff:      map[uint]float64{1: 9000},
+			wantStatus: model.EmergencyStatusEscalated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			s := newTestDispatchService(t, db, map[string]string{configDispatchAcceptTimeout: testAcceptWindow})
+			for id, distance := range tt.staff {
+				s.geo.SyncStaff(id, testLat+distance/metersPerDegree, testLng, true)
+			}
+
+			emergency := &model.Emergency{UserID: 100, Title: "测试", Latitude: testLat, Longitude: testLng, Status: model.EmergencyStatusPending}
+			if err := s.emergencyRepo.Create(emergency); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Stop(emergency.ID) })
+
+			if err := s.Dispatch(emergency.ID); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.timeouts; i++ {
+				round, err := s.dispatchRepo.GetLatestRound(emergency.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.stopTimer(emergency.ID)
+				s.onTimeout(emergency.ID, round)
+			}
+
+			offers, err := s.dispatchRepo.ListOffers(emergency.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := groupRounds(offers); !reflect.DeepEqual(got, tt.wantRounds) {
+				t.Errorf("派单轮次 = %v, want %v", got, tt.wantRounds)
+			}
+
+			got, err := s.emergencyRepo.GetByID(emergency.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("事件状态 = %d, want %d", got.Status, tt.wantStatus)
+			}
+			if hasTimer := s.hasTimer(emergency.ID); hasTimer != (tt.wantStatus == model.EmergencyStatusDispatched) {
+				t.Errorf("hasTimer = %v，事件状态为 %d", hasTimer, got.Status)
+			}
+		})
+	}
+}
+
+func TestDispatchSettingsFallback(t *testing.T) {
+	defaults := dispatchSettings{
+		initialRadius:  defaultDispatchInitialRadius,
+		maxRadius:      defaultDispatchMaxRadius,
+		acceptTimeout:  defaultDispatchAcceptTimeout * time.Second,
+		offersPerRound: defaultDispatchOffersPerRound,
+		maxRounds:      defaultDispatchMaxRounds,
+	}
+
+	tests := []struct {
+		name    string
+		configs map[string]string
+		want    dispatchSettings
+	}{
+		{"未配置", nil, defaults},
+		{
+			"合法配置",
+			map[string]string{
+				configDispatchInitialRadius:  "500",
+				configDispatchMaxRadius:      "4000",
+				configDispatchAcceptTimeout:  "20",
+				configDispatchOffersPerRound: "2",
+				configDispatchMaxRounds:      "4",
+			},
+			dispatchSettings{initialRadius: 500, maxRadius: 4000, acceptTimeout: 20 * time.Second, offersPerRound: 2, maxRounds: 4},
+		},
+		{
+			"零和负数使用默认值",
+			map[string]string{
+				configDispatchInitialRadius:  "0",
+				configDispatchAcceptTimeout:  "-5",
+				configDispatchOffersPerRound: "0",
+				configDispatchMaxRounds:      "-1",
+			},
+			defaults,
+		},
+		{
+			"最大半径小于首轮半径",
+			map[string]string{configDispatchInitialRadius: "10000", configDispatchMaxRadius: "2000"},
+			dispatchSettings{initialRadius: 10000, maxRadius: 10000, acceptTimeout: defaults.acceptTimeout, offersPerRound: defaults.offersPerRound, maxRounds: defaults.maxRounds},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestDispatchService(t, newTestDB(t), tt.configs)
+			if got := s.settings(); got != tt.want {
+				t.Errorf("settings() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// groupRounds 按轮次整理派单记录
+func groupRounds(offers []model.DispatchOffer) []testRound {
+	var rounds []testRound
+	for _, offer := range offers {
+		if len(rounds) == 0 || rounds[len(rounds)-1].round != offer.Round {
+			rounds = append(rounds, testRound{round: offer.Round, radius: offer.Radius})
+		}
+		last := &rounds[len(rounds)-1]
+		last.staff = append(last.staff, offer.StaffID)
+	}
+	return rounds
+}
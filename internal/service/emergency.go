@@ -3,16 +3,39 @@ package service
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/repository"
-	"errors"
-	"time"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/realtime"
+	"fmt"
+
+	"gorm.io/gorm"
 )
 
 type EmergencyService struct {
-	repo *repository.EmergencyRepository
+	repo          *repository.EmergencyRepository
+	securityRepo  *repository.SecurityRepository
+	stateMachine  *EmergencyStateMachine
+	dispatcher    *DispatchService
+	notifications *NotificationService
+	hub           *realtime.Hub
 }
 
-func NewEmergencyService(repo *repository.EmergencyRepository) *EmergencyService {
-	return &EmergencyService{repo: repo}
+func NewEmergencyService(
+	repo *repository.EmergencyRepository,
+	securityRepo *repository.SecurityRepository,
+	stateMachine *EmergencyStateMachine,
+	dispatcher *DispatchService,
+	notifications *NotificationService,
+	hub *realtime.Hub,
+) *EmergencyService {
+	return &EmergencyService{
+		repo:          repo,
+		securityRepo:  securityRepo,
+		stateMachine:  stateMachine,
+		dispatcher:    dispatcher,
+		notifications: notifications,
+		hub:           hub,
+	}
 }
 
 // Create 创建紧急事件
@@ -25,19 +48,22 @@ func (s *EmergencyService) Create(userID uint, req *model.CreateEmergencyRequest
 		Location:    req.Location,
 		Latitude:    req.Latitude,
 		Longitude:   req.Longitude,
-		Status:      1, // 待处理
+		Status:      model.EmergencyStatusPending,
 	}
 
 	if err := s.repo.Create(emergency); err != nil {
 		return nil, err
 	}
 
+	s.dispatcher.DispatchAsync(emergency.ID)
+	s.notifications.NotifyContactsAsync(emergency)
+
 	return emergency, nil
 }
 
-// GetByID 获取紧急事件详情
-func (s *EmergencyService) GetByID(id uint) (*model.Emergency, error) {
-	return s.repo.GetByID(id)
+// GetByID 获取紧急事件详情，仅限报警人、接单安保人员和拥有 emergency:manage 权限的用户
+func (s *EmergencyService) GetByID(id, userID uint, roles []string) (*model.Emergency, error) {
+	return s.authorize(id, userID, roles, true)
 }
 
 // List 获取紧急事件列表
@@ -45,133 +71,196 @@ func (s *EmergencyService) List(page, size int) ([]model.Emergency, int64, error
 	return s.repo.List(page, size)
 }
 
-// Update 更新紧急事件
-func (s *EmergencyService) Update(id uint, req *model.UpdateEmergencyRequest) (*model.Emergency, error) {
-	emergency, err := s.repo.GetByID(id)
+// Update 更新紧急事件，仅限报警人、接单安保人员和拥有 emergency:manage 权限的用户
+func (s *EmergencyService) Update(id, userID uint, roles []string, req *model.UpdateEmergencyRequest) (*model.Emergency, error) {
+	emergency, err := s.authorize(id, userID, roles, true)
 	if err != nil {
 		return nil, err
 	}
 
+	if IsEmergencyFinished(emergency.Status) {
+		return nil, errors.ErrEventStatus
+	}
+
+	// 只更新事件描述和位置，状态、接单人等字段由状态机维护，不能被整行覆盖
+	fields := make(map[string]interface{})
 	if req.Title != "" {
 		emergency.Title = req.Title
+		fields["title"] = req.Title
 	}
 	if req.Description != "" {
 		emergency.Description = req.Description
+		fields["description"] = req.Description
 	}
 	if req.Location != "" {
 		emergency.Location = req.Location
+		fields["location"] = req.Location
 	}
 	if req.Latitude != 0 {
 		emergency.Latitude = req.Latitude
+		fields["latitude"] = req.Latitude
 	}
 	if req.Longitude != 0 {
 		emergency.Longitude = req.Longitude
+		fields["longitude"] = req.Longitude
+	}
+	if len(fields) == 0 {
+		return emergency, nil
 	}
 
-	if err := s.repo.Update(emergency); err != nil {
+	if err := s.repo.UpdateDetails(id, fields); err != nil {
 		return nil, err
 	}
 
 	return emergency, nil
 }
 
-// Delete 删除紧急事件
+// Delete 删除紧急事件，同时停止派单
 func (s *EmergencyService) Delete(id uint) error {
+	if err := s.dispatcher.Stop(id); err != nil {
+		return err
+	}
 	return s.repo.Delete(id)
 }
 
-// CreateHandlingRecord 创建处理记录
-func (s *EmergencyService) CreateHandlingRecord(emergencyID uint, req *model.CreateHandlingRecordRequest) (*model.HandlingRecord, error) {
+// CreateHandlingRecord 创建处理记录并记录操作人，只能为自己接单或报警的事件添加，拥有 emergency:manage 权限的用户不受限制
+func (s *EmergencyService) CreateHandlingRecord(emergencyID, userID uint, roles []string, req *model.CreateHandlingRecordRequest) (*model.HandlingRecord, error) {
+	emergency, err := s.authorize(emergencyID, userID, roles, true)
+	if err != nil {
+		return nil, err
+	}
+
 	record := &model.HandlingRecord{
 		EmergencyID: emergencyID,
+		OperatorID:  userID,
 		Action:      req.Action,
 		Description: req.Description,
 	}
+	if emergency.StaffID == userID {
+		record.StaffID = userID
+	}
 
 	if err := s.repo.CreateHandlingRecord(record); err != nil {
 		return nil, err
 	}
 
+	publishHandlingRecord(s.hub, record)
 	return record, nil
 }
 
-// ListHandlingRecords 获取处理记录列表
-func (s *EmergencyService) ListHandlingRecords(emergencyID uint) ([]model.HandlingRecord, error) {
-	return s.repo.ListHandlingRecords(emergencyID)
-}
-
-// UpdateStatus 更新紧急事件状态
-func (s *EmergencyService) UpdateStatus(id uint, status int) error {
-	emergency, err := s.repo.GetByID(id)
+// CanSubscribe 判断用户能否订阅紧急事件的实时推送，仅限报警人和接单安保人员
+func (s *EmergencyService) CanSubscribe(emergencyID, userID uint) (bool, error) {
+	emergency, err := s.repo.GetByID(emergencyID)
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	emergency.Status = status
-	return s.repo.Update(emergency)
+	return emergency.UserID == userID || (emergency.StaffID != 0 && emergency.StaffID == userID), nil
 }
 
-func (s *EmergencyService) CreateEmergency(userID uint, req *model.CreateEmergencyRequest) (*model.Emergency, error) {
-	emergency := &model.Emergency{
-		UserID:      userID,
-		Title:       req.Title,
-		Description: req.Description,
-		Location:    req.Location,
-		Latitude:    req.Latitude,
-		Longitude:   req.Longitude,
-		Level:       req.Level,
-		Status:      "pending",
-		StartTime:   time.Now(),
-	}
-
-	if err := s.repo.CreateEmergency(emergency); err != nil {
+// ListHandlingRecords 获取处理记录列表，仅限报警人、接单安保人员和拥有 emergency:manage 权限的用户
+func (s *EmergencyService) ListHandlingRecords(emergencyID, userID uint, roles []string) ([]model.HandlingRecord, error) {
+	if _, err := s.authorize(emergencyID, userID, roles, true); err != nil {
 		return nil, err
 	}
-
-	return emergency, nil
+	return s.repo.ListHandlingRecords(emergencyID)
 }
 
-func (s *EmergencyService) GetEmergencyByID(id uint) (*model.Emergency, error) {
-	return s.repo.GetEmergencyByID(id)
+// ListDispatchOffers 获取紧急事件的派单记录
+func (s *EmergencyService) ListDispatchOffers(emergencyID uint) ([]model.DispatchOffer, error) {
+	return s.dispatcher.ListOffers(emergencyID)
 }
 
-func (s *EmergencyService) ListEmergencies(page, size int, status string) ([]model.Emergency, int64, error) {
-	return s.repo.ListEmergencies(page, size, status)
+// ListNotifications 获取紧急事件发给紧急联系人的通知记录。
+// 通知中包含紧急联系人的联系方式，只有报警人和拥有 emergency:manage 权限的用户可以查看
+func (s *EmergencyService) ListNotifications(emergencyID, userID uint, roles []string) ([]model.NotificationDelivery, error) {
+	if _, err := s.authorize(emergencyID, userID, roles, false); err != nil {
+		return nil, err
+	}
+	return s.notifications.ListByEmergency(emergencyID)
 }
 
-func (s *EmergencyService) UpdateEmergencyStatus(id uint, status string) error {
-	if status != "pending" && status != "processing" && status != "completed" && status != "cancelled" {
-		return errors.New("无效的状态")
+// authorize 获取紧急事件并校验访问权限，报警人和拥有 emergency:manage 权限的用户可以访问，
+// allowStaff 为 true 时接单的安保人员也可以访问。无权访问时按事件不存在处理，不暴露事件是否存在
+func (s *EmergencyService) authorize(emergencyID, userID uint, roles []string, allowStaff bool) (*model.Emergency, error) {
+	emergency, err := s.repo.GetByID(emergencyID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if emergency.UserID == userID || auth.HasPermission(roles, auth.PermEmergencyManage) {
+		return emergency, nil
+	}
+	if allowStaff && emergency.StaffID != 0 && emergency.StaffID == userID {
+		return emergency, nil
 	}
-	return s.repo.UpdateEmergencyStatus(id, status)
+	return nil, errors.ErrEventNotFound
 }
 
-func (s *EmergencyService) AssignStaff(emergencyID, staffID uint) error {
-	emergency, err := s.repo.GetEmergencyByID(emergencyID)
-	if err != nil {
-		return err
+// UpdateStatus 按状态机流转紧急事件状态。接单、出发、到达现场必须关联安保人员，
+// 只能由安保人员操作或通过 AssignStaff 人工指派，这里不允许直接变更
+func (s *EmergencyService) UpdateStatus(id uint, status int, remark string) (*model.Emergency, error) {
+	switch status {
+	case model.EmergencyStatusAccepted, model.EmergencyStatusEnRoute, model.EmergencyStatusOnScene:
+		return nil, fmt.Errorf("%w: 接单、出发、到达现场需由安保人员操作，人工指派请使用指派接口", errors.ErrEventStatus)
 	}
 
-	if emergency.Status != "pending" {
-		return errors.New("该事件已被处理")
+	emergency, err := s.stateMachine.Transit(id, status, 0, remark, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	emergency.StaffID = staffID
-	emergency.Status = "processing"
-	return s.repo.UpdateEmergency(emergency)
+	switch {
+	case IsEmergencyFinished(status) || status == model.EmergencyStatusEscalated:
+		// 事件结束或转人工后不再继续派单
+		if err := s.dispatcher.Stop(id); err != nil {
+			return nil, err
+		}
+	case status == model.EmergencyStatusDispatched:
+		// 转人工后重新派单
+		s.dispatcher.DispatchAsync(id)
+	}
+	return emergency, nil
 }
 
-func (s *EmergencyService) CompleteEmergency(emergencyID uint) error {
-	emergency, err := s.repo.GetEmergencyByID(emergencyID)
+// AssignStaff 人工为紧急事件指派安保人员，staffID 为安保人员的用户ID
+func (s *EmergencyService) AssignStaff(emergencyID, staffID uint) (*model.Emergency, error) {
+	staff, err := s.securityRepo.GetStaffByUserID(staffID)
 	if err != nil {
-		return err
+		return nil, errors.ErrStaffNotFound
+	}
+	if staff.Status != model.StaffStatusActive {
+		return nil, errors.ErrStaffNotApproved
+	}
+	busy, err := s.securityRepo.GetActiveEventByStaff(staffID,
+		model.EmergencyStatusAccepted,
+		model.EmergencyStatusEnRoute,
+		model.EmergencyStatusOnScene,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if busy != nil {
+		return nil, errors.ErrStaffBusy
+	}
+
+	emergency, err := s.stateMachine.Transit(emergencyID, model.EmergencyStatusAccepted, staffID,
+		"人工指派安保人员", map[string]interface{}{"staff_id": staffID})
+	if err != nil {
+		return nil, err
 	}
 
-	if emergency.Status != "processing" {
-		return errors.New("该事件状态不正确")
+	if err := s.dispatcher.Stop(emergencyID); err != nil {
+		return nil, err
 	}
+	if err := s.securityRepo.IncrementOrderCount(staffID); err != nil {
+		return nil, err
+	}
+	return emergency, nil
+}
 
-	emergency.Status = "completed"
-	emergency.EndTime = time.Now()
-	return s.repo.UpdateEmergency(emergency)
+// CompleteEmergency 完成紧急事件
+func (s *EmergencyService) CompleteEmergency(emergencyID uint) (*model.Emergency, error) {
+	return s.stateMachine.Transit(emergencyID, model.EmergencyStatusCompleted, 0, "", nil)
 }
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/realtime"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// emergencyTransitions 紧急事件允许的状态流转，完成和取消为终态
+var emergencyTransitions = map[int][]int{
+	model.EmergencyStatusPending: {
+		model.EmergencyStatusDispatched,
+		model.EmergencyStatusAccepted,
+		model.EmergencyStatusCancelled,
+		model.EmergencyStatusEscalated,
+	},
+	model.EmergencyStatusDispatched: {
+		model.EmergencyStatusAccepted,
+		model.EmergencyStatusCancelled,
+		model.EmergencyStatusEscalated,
+	},
+	model.EmergencyStatusAccepted: {
+		model.EmergencyStatusEnRoute,
+		model.EmergencyStatusCancelled,
+		model.EmergencyStatusEscalated,
+	},
+	model.EmergencyStatusEnRoute: {
+		model.EmergencyStatusOnScene,
+		model.EmergencyStatusCancelled,
+		model.EmergencyStatusEscalated,
+	},
+	model.EmergencyStatusOnScene: {
+		model.EmergencyStatusCompleted,
+		model.EmergencyStatusEscalated,
+	},
+	model.EmergencyStatusEscalated: {
+		model.EmergencyStatusDispatched,
+		model.EmergencyStatusAccepted,
+		model.EmergencyStatusCancelled,
+	},
+}
+
+// emergencyStatusTimestamps 进入各状态时记录时间的字段
+var emergencyStatusTimestamps = map[int]string{
+	model.EmergencyStatusDispatched: "dispatched_at",
+	model.EmergencyStatusAccepted:   "accepted_at",
+	model.EmergencyStatusEnRoute:    "en_route_at",
+	model.EmergencyStatusOnScene:    "on_scene_at",
+	model.EmergencyStatusCompleted:  "completed_at",
+	model.EmergencyStatusCancelled:  "cancelled_at",
+	model.EmergencyStatusEscalated:  "escalated_at",
+}
+
+// CanTransitEmergency 判断紧急事件能否从 from 状态流转到 to 状态
+func CanTransitEmergency(from, to int) bool {
+	for _, next := range emergencyTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmergencyFinished 判断紧急事件是否已处于终态
+func IsEmergencyFinished(status int) bool {
+	return status == model.EmergencyStatusCompleted || status == model.EmergencyStatusCancelled
+}
+
+// EmergencyStateMachine 紧急事件状态机，所有状态变更都应经由此处
+type EmergencyStateMachine struct {
+	repo *repository.EmergencyRepository
+	hub  *realtime.Hub
+}
+
+func NewEmergencyStateMachine(repo *repository.EmergencyRepository, hub *realtime.Hub) *EmergencyStateMachine {
+	return &EmergencyStateMachine{repo: repo, hub: hub}
+}
+
+// Transit 将紧急事件流转到 to 状态，记录进入该状态的时间并自动写入处理记录。
+// fields 为随状态一起更新的字段，staffID 为执行操作的安保人员（非安保人员操作时为0）
+func (m *EmergencyStateMachine) Transit(id uint, to int, staffID uint, remark string, fields map[string]interface{}) (*model.Emergency, error) {
+	emergency, err := m.repo.GetByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	from := emergency.Status
+	if !CanTransitEmergency(from, to) {
+		return nil, errors.ErrEventStatus
+	}
+
+	updates := make(map[string]interface{}, len(fields)+1)
+	for column, value := range fields {
+		updates[column] = value
+	}
+	if column, ok := emergencyStatusTimestamps[to]; ok {
+		updates[column] = time.Now()
+	}
+
+	// 以当前状态为条件更新，并发流转时只有一个会成功
+	ok, err := m.repo.TransitionStatus(id, from, to, updates)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.ErrEventStatus
+	}
+
+	description := fmt.Sprintf("状态变更：%s → %s", model.EmergencyStatusNames[from], model.EmergencyStatusNames[to])
+	if remark != "" {
+		description += "，" + remark
+	}
+	record := &model.HandlingRecord{
+		EmergencyID: id,
+		StaffID:     staffID,
+		Action:      "status_" + model.EmergencyStatusNames[to],
+		Description: description,
+	}
+	if err := m.repo.CreateHandlingRecord(record); err != nil {
+		return nil, err
+	}
+
+	emergency, err = m.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	m.hub.Publish(realtime.EmergencyTopic(id), realtime.EventStatusChanged, emergency)
+	publishHandlingRecord(m.hub, record)
+	return emergency, nil
+}
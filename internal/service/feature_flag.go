@@ -0,0 +1,134 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// FeatureFlagService 功能开关，开关以 feature. 为前缀保存在系统配置中，读取经过配置缓存
+type FeatureFlagService struct {
+	configService *SystemConfigService
+	userRepo      *repository.UserRepository
+	roleService   *RoleService
+}
+
+func NewFeatureFlagService(configService *SystemConfigService, userRepo *repository.UserRepository, roleService *RoleService) *FeatureFlagService {
+	return &FeatureFlagService{
+		configService: configService,
+		userRepo:      userRepo,
+		roleService:   roleService,
+	}
+}
+
+// Enabled 判断功能开关对该用户是否开启，开关不存在或配置无效时视为关闭
+func (s *FeatureFlagService) Enabled(name string, fc model.FeatureContext) bool {
+	var flag model.FeatureFlag
+	if err := s.configService.GetJSON(model.FeatureFlagPrefix+name, &flag); err != nil {
+		return false
+	}
+	return evaluateFeatureFlag(name, &flag, fc)
+}
+
+// UserContext 根据用户ID查询计算功能开关所需的角色，供没有请求上下文的服务调用。
+// 城市不随用户保存，需要时由调用方填写
+func (s *FeatureFlagService) UserContext(userID uint) (model.FeatureContext, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return model.FeatureContext{}, errors.ErrUserNotFound
+	}
+	roles, err := s.roleService.Roles(user)
+	if err != nil {
+		return model.FeatureContext{}, err
+	}
+	return model.FeatureContext{UserID: userID, Roles: roles}, nil
+}
+
+// EvaluateAll 计算全部功能开关对该用户的结果，键为去掉前缀的开关名
+func (s *FeatureFlagService) EvaluateAll(fc model.FeatureContext) (map[string]bool, error) {
+	configs, err := s.configService.ListByPrefix(model.FeatureFlagPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		name := strings.TrimPrefix(config.Key, model.FeatureFlagPrefix)
+		flag, err := parseFeatureFlag(config.Value)
+		if err != nil {
+			result[name] = false
+			continue
+		}
+		result[name] = evaluateFeatureFlag(name, flag, fc)
+	}
+	return result, nil
+}
+
+// evaluateFeatureFlag 按开关规则计算结果，比例灰度按开关名和用户ID哈希分桶，
+// 同一用户对同一开关的结果保持稳定，不同开关选中的用户互不相关
+func evaluateFeatureFlag(name string, flag *model.FeatureFlag, fc model.FeatureContext) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if flag.Percentage == nil && len(flag.Users) == 0 && len(flag.Roles) == 0 && len(flag.Cities) == 0 {
+		return true
+	}
+
+	for _, id := range flag.Users {
+		if fc.UserID != 0 && id == fc.UserID {
+			return true
+		}
+	}
+	for _, role := range flag.Roles {
+		if containsString(fc.Roles, role) {
+			return true
+		}
+	}
+	if fc.City != "" && containsString(flag.Cities, fc.City) {
+		return true
+	}
+	if flag.Percentage != nil && fc.UserID != 0 {
+		return featureBucket(name, fc.UserID) < *flag.Percentage
+	}
+	return false
+}
+
+// featureBucket 将用户分到 0-99 的桶
+func featureBucket(name string, userID uint) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{':'})
+	h.Write([]byte(strconv.FormatUint(uint64(userID), 10)))
+	return int(h.Sum32() % 100)
+}
+
+// parseFeatureFlag 解析并校验功能开关配置
+func parseFeatureFlag(value string) (*model.FeatureFlag, error) {
+	var flag model.FeatureFlag
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&flag); err != nil {
+		return nil, fmt.Errorf("%w: 功能开关格式错误，可用字段为 enabled、percentage、users、roles、cities", errors.ErrInvalidConfig)
+	}
+	if flag.Percentage != nil && (*flag.Percentage < 0 || *flag.Percentage > 100) {
+		return nil, fmt.Errorf("%w: 功能开关的 percentage 必须在 0-100 之间", errors.ErrInvalidConfig)
+	}
+	return &flag, nil
+}
+
+// validateFeatureFlag 功能开关必须为 json 类型且格式正确，其他配置不校验
+func validateFeatureFlag(key, typ, value string) error {
+	if !strings.HasPrefix(key, model.FeatureFlagPrefix) {
+		return nil
+	}
+	if typ != model.ConfigTypeJSON {
+		return fmt.Errorf("%w: 功能开关的类型必须为 json", errors.ErrInvalidConfig)
+	}
+	_, err := parseFeatureFlag(value)
+	return err
+}
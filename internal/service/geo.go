@@ -0,0 +1,200 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/geo"
+	"dididaren/pkg/logger"
+	"time"
+)
+
+const (
+	// zoneGeohashPrecision 危险区域索引的 geohash 精度，单格约 4.9km×4.9km
+	zoneGeohashPrecision = 5
+	// staffGeohashPrecision 安保人员索引的 geohash 精度，单格约 1.2km×0.6km
+	staffGeohashPrecision = 6
+)
+
+// GeoService 地理检索层：在内存中维护活跃危险区域和在线安保人员的空间索引，
+// 写操作时同步更新，并定期从数据库重建以兜底其他途径的数据变更
+type GeoService struct {
+	dangerZoneRepo *repository.DangerZoneRepository
+	securityRepo   *repository.SecurityRepository
+	logger         *logger.Logger
+
+	zones *geo.Index
+	staff *geo.Index
+}
+
+func NewGeoService(
+	dangerZoneRepo *repository.DangerZoneRepository,
+	securityRepo *repository.SecurityRepository,
+	logger *logger.Logger,
+) *GeoService {
+	return &GeoService{
+		dangerZoneRepo: dangerZoneRepo,
+		securityRepo:   securityRepo,
+		logger:         logger,
+		zones:          geo.NewIndex(zoneGeohashPrecision),
+		staff:          geo.NewIndex(staffGeohashPrecision),
+	}
+}
+
+// Load 从数据库重建索引
+func (s *GeoService) Load() error {
+	zones, err := s.dangerZoneRepo.GetAllActiveZones()
+	if err != nil {
+		return err
+	}
+	zoneItems := make([]geo.Item, 0, len(zones))
+	for _, zone := range zones {
+		zoneItems = append(zoneItems, zoneItem(zone))
+	}
+
+	staff, err := s.securityRepo.ListOnlineStaff()
+	if err != nil {
+		return err
+	}
+	staffItems := make([]geo.Item, 0, len(staff))
+	for _, st := range staff {
+		staffItems = append(staffItems, geo.Item{ID: st.UserID, Lat: st.Latitude, Lng: st.Longitude})
+	}
+
+	s.zones.Reset(zoneItems)
+	s.staff.Reset(staffItems)
+	return nil
+}
+
+// Start 按固定间隔在后台重建索引
+func (s *GeoService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.Load(); err != nil {
+				s.logger.Error("重建空间索引失败: %v", err)
+			}
+		}
+	}()
+}
+
+// SyncZone 危险区域变更后同步索引，停用的区域会被移出索引
+func (s *GeoService) SyncZone(zone *model.DangerZone) {
+	if !zone.IsActive {
+		s.zones.Remove(zone.ID)
+		return
+	}
+	s.zones.Upsert(zoneItem(zone))
+}
+
+// RemoveZone 危险区域删除后同步索引
+func (s *GeoService) RemoveZone(id uint) {
+	s.zones.Remove(id)
+}
+
+// SyncStaff 安保人员位置或在线状态变更后同步索引，离线的安保人员会被移出索引
+func (s *GeoService) SyncStaff(userID uint, lat, lng float64, isOnline bool) {
+	if !isOnline {
+		s.staff.Remove(userID)
+		return
+	}
+	s.staff.Upsert(geo.Item{ID: userID, Lat: lat, Lng: lng})
+}
+
+// NearbyZones 获取与查询范围相交的危险区域，按距离由近到远排序
+func (s *GeoService) NearbyZones(lat, lng, radius float64) ([]model.NearbyDangerZone, error) {
+	return s.loadZones(lat, lng, s.zones.Nearby(lat, lng, radius))
+}
+
+// ZonesContaining 获取覆盖指定位置的危险区域，按距离由近到远排序
+func (s *GeoService) ZonesContaining(lat, lng float64) ([]model.NearbyDangerZone, error) {
+	// 索引按外接圆粗筛，多边形区域还需逐个判断
+	zones, err := s.loadZones(lat, lng, s.zones.Nearby(lat, lng, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	result := zones[:0]
+	for _, zone := range zones {
+		if zone.Inside {
+			result = append(result, zone)
+		}
+	}
+	return result, nil
+}
+
+// NearbyStaffMatches 获取半径内的在线安保人员，ID 为安保人员的用户ID，按距离由近到远排序
+func (s *GeoService) NearbyStaffMatches(lat, lng, radius float64) []geo.Match {
+	return s.staff.Nearby(lat, lng, radius)
+}
+
+// NearbyStaff 获取半径内的在线安保人员详情，按距离由近到远排序
+func (s *GeoService) NearbyStaff(lat, lng, radius float64) ([]model.NearbyStaff, error) {
+	matches := s.staff.Nearby(lat, lng, radius)
+	ids := make([]uint, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ID
+	}
+
+	staff, err := s.securityRepo.ListByUserIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byUserID := make(map[uint]*model.Staff, len(staff))
+	for _, st := range staff {
+		byUserID[st.UserID] = st
+	}
+
+	result := make([]model.NearbyStaff, 0, len(matches))
+	for _, match := range matches {
+		st, ok := byUserID[match.ID]
+		if !ok {
+			continue
+		}
+		result = append(result, model.NearbyStaff{
+			UserID:    st.UserID,
+			Name:      st.Name,
+			Rating:    st.Rating,
+			Latitude:  match.Lat,
+			Longitude: match.Lng,
+			Distance:  match.Distance,
+		})
+	}
+	return result, nil
+}
+
+// loadZones 按索引结果的顺序加载危险区域详情
+func (s *GeoService) loadZones(lat, lng float64, matches []geo.Match) ([]model.NearbyDangerZone, error) {
+	ids := make([]uint, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ID
+	}
+
+	zones, err := s.dangerZoneRepo.ListByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.DangerZone, len(zones))
+	for _, zone := range zones {
+		byID[zone.ID] = zone
+	}
+
+	result := make([]model.NearbyDangerZone, 0, len(matches))
+	for _, match := range matches {
+		zone, ok := byID[match.ID]
+		if !ok {
+			continue
+		}
+		result = append(result, model.NearbyDangerZone{
+			DangerZone: zone,
+			Distance:   match.Distance,
+			Inside:     zoneContains(&zone, lat, lng, match.Distance),
+		})
+	}
+	return result, nil
+}
+
+func zoneItem(zone *model.DangerZone) geo.Item {
+	return geo.Item{ID: zone.ID, Lat: zone.Latitude, Lng: zone.Longitude, Extent: zone.Radius}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/realtime"
+	"sync"
+	"time"
+)
+
+const (
+	configGeofenceAlertCooldown  = "geofence.alert_cooldown" // 同一区域重复提醒的冷却时间（秒）
+	defaultGeofenceAlertCooldown = 1800
+
+	// geofenceLockStripes 按用户分片加锁，同一用户的位置上报串行处理，避免重复判定进入
+	geofenceLockStripes = 64
+)
+
+// GeofenceService 根据用户上报的位置判断进出危险区域，进入时提醒用户。
+// 在区域边界附近来回移动时，冷却时间内重复进入同一区域只记录不提醒
+type GeofenceService struct {
+	repo          *repository.GeofenceRepository
+	userRepo      *repository.UserRepository
+	geo           *GeoService
+	configService *SystemConfigService
+	hub           *realtime.Hub
+
+	locks [geofenceLockStripes]sync.Mutex
+}
+
+func NewGeofenceService(
+	repo *repository.GeofenceRepository,
+	userRepo *repository.UserRepository,
+	geo *GeoService,
+	configService *SystemConfigService,
+	hub *realtime.Hub,
+) *GeofenceService {
+	return &GeofenceService{
+		repo:          repo,
+		userRepo:      userRepo,
+		geo:           geo,
+		configService: configService,
+		hub:           hub,
+	}
+}
+
+// SetLocationAlerts 开启或关闭危险区域提醒，关闭时清除用户的区域状态
+func (s *GeofenceService) SetLocationAlerts(userID uint, enabled bool) error {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return errors.ErrUserNotFound
+	}
+
+	if err := s.userRepo.UpdateLocationAlerts(userID, enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		return s.repo.DeleteStates(userID)
+	}
+	return nil
+}
+
+// ReportLocation 处理用户上报的位置，记录进出事件并对新进入的区域发出提醒
+func (s *GeofenceService) ReportLocation(userID uint, lat, lng float64) (*model.GeofenceResult, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if !user.LocationAlerts {
+		return nil, errors.ErrLocationAlertsDisabled
+	}
+
+	lock := &s.locks[userID%geofenceLockStripes]
+	lock.Lock()
+	defer lock.Unlock()
+
+	zones, err := s.geo.ZonesContaining(lat, lng)
+	if err != nil {
+		return nil, err
+	}
+	states, err := s.repo.ListStates(userID)
+	if err != nil {
+		return nil, err
+	}
+	byZone := make(map[uint]*model.GeofenceState, len(states))
+	for i := range states {
+		byZone[states[i].ZoneID] = &states[i]
+	}
+
+	now := time.Now()
+	cooldown := s.configService.GetDuration(configGeofenceAlertCooldown, time.Second, defaultGeofenceAlertCooldown*time.Second)
+	result := &model.GeofenceResult{
+		Inside:  []uint{},
+		Entered: []uint{},
+		Exited:  []uint{},
+		Alerts:  []model.GeofenceAlert{},
+	}
+
+	inside := make(map[uint]bool, len(zones))
+	for _, zone := range zones {
+		inside[zone.ID] = true
+		result.Inside = append(result.Inside, zone.ID)
+
+		state, ok := byZone[zone.ID]
+		if ok && state.Inside {
+			continue
+		}
+		if !ok {
+			state = &model.GeofenceState{UserID: userID, ZoneID: zone.ID}
+		}
+
+		alerted := state.LastAlertAt == nil || now.Sub(*state.LastAlertAt) >= cooldown
+		state.Inside = true
+		if alerted {
+			state.LastAlertAt = &now
+		}
+		if err := s.repo.SaveState(state); err != nil {
+			return nil, err
+		}
+		if err := s.recordEvent(userID, zone.ID, model.GeofenceEventEnter, lat, lng, alerted); err != nil {
+			return nil, err
+		}
+
+		result.Entered = append(result.Entered, zone.ID)
+		if alerted {
+			alert := model.GeofenceAlert{
+				ZoneID:      zone.ID,
+				Name:        zone.Name,
+				Level:       zone.Level,
+				Description: zone.Description,
+				Distance:    zone.Distance,
+			}
+			result.Alerts = append(result.Alerts, alert)
+			s.hub.Publish(realtime.UserTopic(userID), realtime.EventGeofenceAlert, alert)
+		}
+	}
+
+	// 之前在区域内、本次不在的视为离开，区域被停用或删除时同样如此
+	for _, state := range byZone {
+		if !state.Inside || inside[state.ZoneID] {
+			continue
+		}
+		state.Inside = false
+		if err := s.repo.SaveState(state); err != nil {
+			return nil, err
+		}
+		if err := s.recordEvent(userID, state.ZoneID, model.GeofenceEventExit, lat, lng, false); err != nil {
+			return nil, err
+		}
+		result.Exited = append(result.Exited, state.ZoneID)
+	}
+
+	return result, nil
+}
+
+// GetStats 按危险区域统计进出和提醒次数
+func (s *GeofenceService) GetStats(from, to time.Time) ([]model.GeofenceStat, error) {
+	if !from.Before(to) {
+		return nil, errors.ErrInvalidParameter
+	}
+	return s.repo.CountEvents(from, to)
+}
+
+func (s *GeofenceService) recordEvent(userID, zoneID uint, event string, lat, lng float64, alerted bool) error {
+	return s.repo.CreateEvent(&model.GeofenceEvent{
+		UserID:    userID,
+		ZoneID:    zoneID,
+		Event:     event,
+		Latitude:  lat,
+		Longitude: lng,
+		Alerted:   alerted,
+	})
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/geo"
+	"dididaren/pkg/logger"
+	"math"
+	"sync"
+	"time"
+)
+
+// 热度计算配置项
+const (
+	configHeatHalfLife    = "heat.half_life"    // 事件影响衰减一半所需的时间（小时）
+	configHeatWeight24h   = "heat.weight_24h"   // 近24小时窗口权重
+	configHeatWeight7d    = "heat.weight_7d"    // 近7天窗口权重
+	configHeatWeight30d   = "heat.weight_30d"   // 近30天窗口权重
+	configHeatLevelStep   = "heat.level_step"   // 每升一级所需的得分
+	configHeatTypeWeights = "heat.type_weights" // 按事件类型的严重程度权重，JSON 对象，如 {"抢劫": 2}
+
+	defaultHeatHalfLife  = 168
+	defaultHeatWeight24h = 3
+	defaultHeatWeight7d  = 1
+	defaultHeatWeight30d = 0.5
+	defaultHeatLevelStep = 3
+
+	// maxHeatLevel 热度等级上限
+	maxHeatLevel = 5
+)
+
+// 热度统计窗口
+const (
+	heatWindow24h = 24 * time.Hour
+	heatWindow7d  = 7 * 24 * time.Hour
+	heatWindow30d = 30 * 24 * time.Hour
+)
+
+// heatStatusWeights 按事件结果的严重程度权重：升级人工处理的更严重，取消的多为误报
+var heatStatusWeights = map[int]float64{
+	model.EmergencyStatusEscalated: 2,
+	model.EmergencyStatusCancelled: 0.3,
+}
+
+// HeatService 根据区域内的紧急事件定期计算危险区域热度等级。
+// 得分为各窗口内事件权重之和的加权和，事件权重 = 类型权重 × 结果权重 × 时间衰减，
+// 近期事件同时落在多个窗口中，因此影响更大
+type HeatService struct {
+	dangerZoneRepo *repository.DangerZoneRepository
+	emergencyRepo  *repository.EmergencyRepository
+	configService  *SystemConfigService
+	logger         *logger.Logger
+
+	mu sync.Mutex
+	// last 每个区域上次写入历史的结果，未变化时不重复记录
+	last map[uint]heatResult
+}
+
+type heatSettings struct {
+	halfLife    time.Duration
+	weight24h   float64
+	weight7d    float64
+	weight30d   float64
+	levelStep   float64
+	typeWeights map[string]float64
+}
+
+type heatResult struct {
+	level    int
+	score    float64
+	count24h int
+	count7d  int
+	count30d int
+}
+
+func NewHeatService(
+	dangerZoneRepo *repository.DangerZoneRepository,
+	emergencyRepo *repository.EmergencyRepository,
+	configService *SystemConfigService,
+	logger *logger.Logger,
+) *HeatService {
+	return &HeatService{
+		dangerZoneRepo: dangerZoneRepo,
+		emergencyRepo:  emergencyRepo,
+		configService:  configService,
+		logger:         logger,
+		last:           make(map[uint]heatResult),
+	}
+}
+
+// Start 立即计算一次，之后按固定间隔在后台重新计算
+func (s *HeatService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.Compute(); err != nil {
+				s.logger.Error("计算危险区域热度失败: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Compute 重新计算所有活跃危险区域的热度等级，变化的结果写入历史
+func (s *HeatService) Compute() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	settings := s.settings()
+
+	zones, err := s.dangerZoneRepo.GetAllActiveZones()
+	if err != nil {
+		return err
+	}
+	emergencies, err := s.emergencyRepo.ListCreatedSince(now.Add(-heatWindow30d))
+	if err != nil {
+		return err
+	}
+
+	// 按外接圆建立临时索引，再逐个判断事件是否位于区域内
+	index := geo.NewIndex(zoneGeohashPrecision)
+	byID := make(map[uint]*model.DangerZone, len(zones))
+	for _, zone := range zones {
+		index.Upsert(zoneItem(zone))
+		byID[zone.ID] = zone
+	}
+
+	results := make(map[uint]*heatResult, len(zones))
+	for _, zone := range zones {
+		results[zone.ID] = &heatResult{}
+	}
+	for i := range emergencies {
+		emergency := &emergencies[i]
+		age := now.Sub(emergency.CreatedAt)
+		weight := settings.eventWeight(emergency, age)
+
+		for _, match := range index.Nearby(emergency.Latitude, emergency.Longitude, 0) {
+			if !zoneContains(byID[match.ID], emergency.Latitude, emergency.Longitude, match.Distance) {
+				continue
+			}
+			result := results[match.ID]
+			result.count30d++
+			result.score += settings.weight30d * weight
+			if age <= heatWindow7d {
+				result.count7d++
+				result.score += settings.weight7d * weight
+			}
+			if age <= heatWindow24h {
+				result.count24h++
+				result.score += settings.weight24h * weight
+			}
+		}
+	}
+
+	var histories []*model.DangerZoneHeatHistory
+	for _, zone := range zones {
+		result := results[zone.ID]
+		result.score = math.Round(result.score*100) / 100
+		result.level = heatLevel(result.score, settings.levelStep)
+
+		changed := result.level != zone.HeatLevel
+		if changed {
+			if err := s.dangerZoneRepo.UpdateHeatLevel(zone.ID, result.level); err != nil {
+				return err
+			}
+		}
+		if last, ok := s.last[zone.ID]; ok && last == *result && !changed {
+			continue
+		}
+		s.last[zone.ID] = *result
+		histories = append(histories, &model.DangerZoneHeatHistory{
+			ZoneID:    zone.ID,
+			HeatLevel: result.level,
+			Score:     result.score,
+			Count24h:  result.count24h,
+			Count7d:   result.count7d,
+			Count30d:  result.count30d,
+			Source:    model.HeatSourceAuto,
+		})
+	}
+	return s.dangerZoneRepo.CreateHeatHistories(histories)
+}
+
+// eventWeight 计算单个事件的权重
+func (h heatSettings) eventWeight(emergency *model.Emergency, age time.Duration) float64 {
+	return eventSeverity(h.typeWeights, emergency) * math.Pow(0.5, age.Hours()/h.halfLife.Hours())
+}
+
+// eventSeverity 事件的严重程度 = 类型权重 × 结果权重
+func eventSeverity(typeWeights map[string]float64, emergency *model.Emergency) float64 {
+	weight := 1.0
+	if w, ok := typeWeights[emergency.Type]; ok {
+		weight = w
+	}
+	if w, ok := heatStatusWeights[emergency.Status]; ok {
+		weight *= w
+	}
+	return weight
+}
+
+// loadHeatTypeWeights 读取按事件类型的严重程度权重，未配置或非法时返回空
+func loadHeatTypeWeights(configService *SystemConfigService, logger *logger.Logger) map[string]float64 {
+	var weights map[string]float64
+	if err := configService.GetJSON(configHeatTypeWeights, &weights); err != nil {
+		if err != errors.ErrConfigNotFound {
+			logger.Error("读取事件类型权重失败: %v", err)
+		}
+		return nil
+	}
+	return weights
+}
+
+// settings 读取热度配置，半衰期不是正数时使用默认值，否则衰减系数无法计算
+func (s *HeatService) settings() heatSettings {
+	settings := heatSettings{
+		halfLife:    s.configService.GetDuration(configHeatHalfLife, time.Hour, defaultHeatHalfLife*time.Hour),
+		weight24h:   s.configService.GetFloat(configHeatWeight24h, defaultHeatWeight24h),
+		weight7d:    s.configService.GetFloat(configHeatWeight7d, defaultHeatWeight7d),
+		weight30d:   s.configService.GetFloat(configHeatWeight30d, defaultHeatWeight30d),
+		levelStep:   s.configService.GetFloat(configHeatLevelStep, defaultHeatLevelStep),
+		typeWeights: loadHeatTypeWeights(s.configService, s.logger),
+	}
+	if settings.halfLife <= 0 {
+		settings.halfLife = defaultHeatHalfLife * time.Hour
+	}
+	return settings
+}
+
+// heatLevel 按得分计算热度等级，每级所需得分不是正数时视为配置错误，有得分即为最高等级
+func heatLevel(score, step float64) int {
+	if score <= 0 {
+		return 0
+	}
+	if step <= 0 {
+		return maxHeatLevel
+	}
+	return int(math.Min(maxHeatLevel, math.Floor(score/step)))
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/geo"
+	"dididaren/pkg/logger"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	configHeatmapCacheTTL  = "heatmap.cache_ttl" // 瓦片缓存时间（秒）
+	defaultHeatmapCacheTTL = 60
+
+	// maxHeatmapPrecision 允许的最大 geohash 精度，单格约 38m×19m
+	maxHeatmapPrecision = 8
+	// maxHeatmapCells 自动选择精度时，查询范围最多覆盖的格子数
+	maxHeatmapCells = 4096
+	// heatmapTileLevels 缓存瓦片比格子粗的级数，每个瓦片包含 32×32 个格子
+	heatmapTileLevels = 2
+	// maxHeatmapTiles 单次查询最多覆盖的瓦片数
+	maxHeatmapTiles = 64
+	// maxHeatmapCacheEntries 缓存的瓦片数上限
+	maxHeatmapCacheEntries = 4096
+)
+
+// HeatmapService 按 geohash 格子聚合紧急事件，生成地图热力层数据。
+// 查询范围被切分为比格子粗两级的瓦片，每个瓦片的聚合结果单独缓存，相邻、重叠的查询可复用
+type HeatmapService struct {
+	emergencyRepo *repository.EmergencyRepository
+	configService *SystemConfigService
+	logger        *logger.Logger
+
+	mu    sync.Mutex
+	cache map[string]heatmapTile
+}
+
+type heatmapTile struct {
+	cells     []model.HeatmapCell
+	expiresAt time.Time
+}
+
+func NewHeatmapService(
+	emergencyRepo *repository.EmergencyRepository,
+	configService *SystemConfigService,
+	logger *logger.Logger,
+) *HeatmapService {
+	return &HeatmapService{
+		emergencyRepo: emergencyRepo,
+		configService: configService,
+		logger:        logger,
+		cache:         make(map[string]heatmapTile),
+	}
+}
+
+// GetHeatmap 获取查询范围内的热力图格子
+func (s *HeatmapService) GetHeatmap(query *model.HeatmapQuery) (*model.Heatmap, error) {
+	if query.MinLat >= query.MaxLat || query.MinLng >= query.MaxLng || !query.From.Before(query.To) {
+		return nil, errors.ErrInvalidParameter
+	}
+	if query.Precision < 0 || query.Precision > maxHeatmapPrecision {
+		return nil, errors.ErrInvalidParameter
+	}
+
+	// 时间按分钟取整，使相近的查询能命中同一缓存
+	query.From = query.From.Truncate(time.Minute)
+	query.To = query.To.Truncate(time.Minute)
+	if query.Precision == 0 {
+		query.Precision = heatmapPrecision(query)
+	}
+
+	tilePrecision := query.Precision - heatmapTileLevels
+	if tilePrecision < 1 {
+		tilePrecision = 1
+	}
+	tiles := geo.GeohashCover(query.MinLat, query.MaxLat, query.MinLng, query.MaxLng, tilePrecision, maxHeatmapTiles)
+	if tiles == nil {
+		return nil, fmt.Errorf("%w：查询范围过大，请降低精度", errors.ErrInvalidParameter)
+	}
+
+	cells := []model.HeatmapCell{}
+	for _, tile := range tiles {
+		tileCells, err := s.tileCells(tile, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, cell := range tileCells {
+			minLat, maxLat, minLng, maxLng := geo.GeohashBounds(cell.Geohash)
+			if maxLat >= query.MinLat && minLat <= query.MaxLat && maxLng >= query.MinLng && minLng <= query.MaxLng {
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return &model.Heatmap{Precision: query.Precision, Cells: cells}, nil
+}
+
+// tileCells 获取单个瓦片内的格子，优先使用缓存
+func (s *HeatmapService) tileCells(tile string, query *model.HeatmapQuery) ([]model.HeatmapCell, error) {
+	key := fmt.Sprintf("%s|%d|%d|%d|%s|%v", tile, query.Precision, query.From.Unix(), query.To.Unix(),
+		strings.Join(query.Types, ","), query.Statuses)
+
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.cells, nil
+	}
+
+	tileQuery := *query
+	tileQuery.MinLat, tileQuery.MaxLat, tileQuery.MinLng, tileQuery.MaxLng = geo.GeohashBounds(tile)
+	emergencies, err := s.emergencyRepo.ListForHeatmap(&tileQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	typeWeights := loadHeatTypeWeights(s.configService, s.logger)
+	byHash := make(map[string]*model.HeatmapCell)
+	for i := range emergencies {
+		emergency := &emergencies[i]
+		hash := geo.GeohashEncode(emergency.Latitude, emergency.Longitude, query.Precision)
+		// 恰好落在瓦片边界上的事件会被相邻两个瓦片同时查出，只计入所属的瓦片
+		if !strings.HasPrefix(hash, tile) {
+			continue
+		}
+		cell, ok := byHash[hash]
+		if !ok {
+			minLat, maxLat, minLng, maxLng := geo.GeohashBounds(hash)
+			cell = &model.HeatmapCell{
+				Geohash:   hash,
+				Latitude:  (minLat + maxLat) / 2,
+				Longitude: (minLng + maxLng) / 2,
+			}
+			byHash[hash] = cell
+		}
+		cell.Count++
+		cell.Weight += eventSeverity(typeWeights, emergency)
+	}
+
+	cells := make([]model.HeatmapCell, 0, len(byHash))
+	for _, cell := range byHash {
+		cells = append(cells, *cell)
+	}
+	sort.Slice(cells, func(i, j int) bool { return cells[i].Geohash < cells[j].Geohash })
+
+	ttl := s.configService.GetDuration(configHeatmapCacheTTL, time.Second, defaultHeatmapCacheTTL*time.Second)
+	s.mu.Lock()
+	if len(s.cache) >= maxHeatmapCacheEntries {
+		s.evictExpired(now)
+	}
+	s.cache[key] = heatmapTile{cells: cells, expiresAt: now.Add(ttl)}
+	s.mu.Unlock()
+
+	return cells, nil
+}
+
+// evictExpired 清理过期的瓦片，仍然超限时清空缓存
+func (s *HeatmapService) evictExpired(now time.Time) {
+	for key, tile := range s.cache {
+		if !now.Before(tile.expiresAt) {
+			delete(s.cache, key)
+		}
+	}
+	if len(s.cache) >= maxHeatmapCacheEntries {
+		s.cache = make(map[string]heatmapTile)
+	}
+}
+
+// heatmapPrecision 选择格子数不超过上限的最大精度
+func heatmapPrecision(query *model.HeatmapQuery) int {
+	for precision := maxHeatmapPrecision; precision > 1; precision-- {
+		latSpan, lngSpan := geo.GeohashCellSize(precision)
+		rows := (query.MaxLat-query.MinLat)/latSpan + 1
+		cols := (query.MaxLng-query.MinLng)/lngSpan + 1
+		if rows*cols <= maxHeatmapCells {
+			return precision
+		}
+	}
+	return 1
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/logger"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 登录保护配置项
+const (
+	configLoginFailureWindow = "login.failure_window"  // 统计失败次数的时间窗口（秒）
+	configLoginMaxFailures   = "login.max_failures"    // 窗口内同一账号失败多少次后锁定
+	configLoginLockDuration  = "login.lock_duration"   // 锁定时长（秒）
+	configLoginDelayAfter    = "login.delay_after"     // 同一手机号失败多少次后开始要求等待
+	configLoginDelayBase     = "login.delay_base"      // 首次等待时长（秒），之后每失败一次翻倍
+	configLoginIPMaxFailures = "login.ip_max_failures" // 窗口内同一 IP 最多失败次数
+
+	defaultLoginFailureWindow = 900
+	defaultLoginMaxFailures   = 10
+	defaultLoginLockDuration  = 1800
+	defaultLoginDelayAfter    = 3
+	defaultLoginDelayBase     = 2
+	defaultLoginIPMaxFailures = 50
+
+	// loginAttemptRetention 登录尝试记录的保留时间
+	loginAttemptRetention = 7 * 24 * time.Hour
+)
+
+// LoginGuardService 登录暴力破解防护。
+// 同一手机号连续失败后，每次重试前需要等待的时间逐次翻倍；失败次数达到上限后账号被临时锁定，
+// 锁定状态记录在 User.Status 中，到期自动解除，也可由管理员提前解锁。同一 IP 失败过多时暂停其登录
+type LoginGuardService struct {
+	repo          *repository.LoginAttemptRepository
+	userRepo      *repository.UserRepository
+	configService *SystemConfigService
+	logger        *logger.Logger
+}
+
+type loginSettings struct {
+	window        time.Duration
+	maxFailures   int
+	lockDuration  time.Duration
+	delayAfter    int
+	delayBase     time.Duration
+	ipMaxFailures int64
+}
+
+func NewLoginGuardService(
+	repo *repository.LoginAttemptRepository,
+	userRepo *repository.UserRepository,
+	configService *SystemConfigService,
+	logger *logger.Logger,
+) *LoginGuardService {
+	return &LoginGuardService{
+		repo:          repo,
+		userRepo:      userRepo,
+		configService: configService,
+		logger:        logger,
+	}
+}
+
+// Start 按固定间隔在后台清理过期的登录尝试记录
+func (s *LoginGuardService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.repo.DeleteCreatedBefore(time.Now().Add(-loginAttemptRetention)); err != nil {
+				s.logger.Error("清理登录尝试记录失败: %v", err)
+			}
+		}
+	}()
+}
+
+// Check 登录前检查是否允许尝试，user 为手机号对应的用户，不存在时为 nil
+func (s *LoginGuardService) Check(phone, ip string, user *model.User) error {
+	now := time.Now()
+	settings := s.settings()
+
+	if ip != "" {
+		count, err := s.repo.CountIPFailuresSince(ip, now.Add(-settings.window))
+		if err != nil {
+			return err
+		}
+		if count >= settings.ipMaxFailures {
+			return retryAfter(errors.ErrLoginTooFrequent, settings.window)
+		}
+	}
+
+	if user != nil && user.Status == model.UserStatusLocked {
+		if user.LockedUntil == nil || now.Before(*user.LockedUntil) {
+			if user.LockedUntil == nil {
+				return errors.ErrAccountLocked
+			}
+			return retryAfter(errors.ErrAccountLocked, user.LockedUntil.Sub(now))
+		}
+		// 锁定已到期，自动解除
+		if err := s.unlock(user); err != nil {
+			return err
+		}
+	}
+
+	failures, err := s.repo.ListPhoneFailuresSince(phone, now.Add(-settings.window))
+	if err != nil {
+		return err
+	}
+	if len(failures) >= settings.delayAfter {
+		last := failures[len(failures)-1].CreatedAt
+		wait := last.Add(settings.delay(len(failures))).Sub(now)
+		if wait > 0 {
+			return retryAfter(errors.ErrLoginTooFrequent, wait)
+		}
+	}
+	return nil
+}
+
+// RecordFailure 记录登录失败，失败次数达到上限时锁定账号并返回 ErrAccountLocked
+func (s *LoginGuardService) RecordFailure(phone, ip string, user *model.User) error {
+	if err := s.repo.Create(&model.LoginAttempt{Phone: phone, IP: ip}); err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	settings := s.settings()
+	now := time.Now()
+	failures, err := s.repo.ListPhoneFailuresSince(phone, now.Add(-settings.window))
+	if err != nil {
+		return err
+	}
+	if len(failures) < settings.maxFailures {
+		return nil
+	}
+
+	lockedUntil := now.Add(settings.lockDuration)
+	if err := s.userRepo.UpdateLock(user.ID, model.UserStatusLocked, &lockedUntil); err != nil {
+		return err
+	}
+	user.Status = model.UserStatusLocked
+	user.LockedUntil = &lockedUntil
+	s.logger.Warn("账号登录失败次数过多已锁定: user_id=%d ip=%s", user.ID, ip)
+	return retryAfter(errors.ErrAccountLocked, settings.lockDuration)
+}
+
+// RecordSuccess 记录登录成功，之前的失败次数不再计入
+func (s *LoginGuardService) RecordSuccess(phone, ip string) error {
+	return s.repo.Create(&model.LoginAttempt{Phone: phone, IP: ip, Success: true})
+}
+
+// Unlock 管理员解锁账号
+func (s *LoginGuardService) Unlock(userID uint) (*model.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	if err := s.unlock(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *LoginGuardService) unlock(user *model.User) error {
+	if err := s.userRepo.UpdateLock(user.ID, model.UserStatusNormal, nil); err != nil {
+		return err
+	}
+	if err := s.repo.ClearPhoneFailures(user.Phone); err != nil {
+		return err
+	}
+	user.Status = model.UserStatusNormal
+	user.LockedUntil = nil
+	return nil
+}
+
+func (s *LoginGuardService) settings() loginSettings {
+	return loginSettings{
+		window:        s.configService.GetDuration(configLoginFailureWindow, time.Second, defaultLoginFailureWindow*time.Second),
+		maxFailures:   s.configService.GetInt(configLoginMaxFailures, defaultLoginMaxFailures),
+		lockDuration:  s.configService.GetDuration(configLoginLockDuration, time.Second, defaultLoginLockDuration*time.Second),
+		delayAfter:    s.configService.GetInt(configLoginDelayAfter, defaultLoginDelayAfter),
+		delayBase:     s.configService.GetDuration(configLoginDelayBase, time.Second, defaultLoginDelayBase*time.Second),
+		ipMaxFailures: int64(s.configService.GetInt(configLoginIPMaxFailures, defaultLoginIPMaxFailures)),
+	}
+}
+
+// delay 失败 n 次后下次尝试前需要等待的时间，不超过统计窗口
+func (l loginSettings) delay(failures int) time.Duration {
+	delay := float64(l.delayBase) * math.Pow(2, float64(failures-l.delayAfter))
+	return time.Duration(math.Min(delay, float64(l.window)))
+}
+
+// retryAfter 在错误信息中附上需要等待的时间
+func retryAfter(err error, wait time.Duration) error {
+	return fmt.Errorf("%w，请%d秒后再试", err, int(math.Ceil(wait.Seconds())))
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/config"
+	"dididaren/pkg/logger"
+	"dididaren/pkg/notify"
+	"fmt"
+	"time"
+)
+
+// notificationSendTimeout 单次发送的超时
+const notificationSendTimeout = 10 * time.Second
+
+// NotificationService 紧急事件发生时通知用户的紧急联系人。
+// 每个联系人的每个渠道生成一条通知记录，按默认联系人优先的顺序依次发送，
+// 失败的按指数退避重试，重试不阻塞后续联系人
+type NotificationService struct {
+	repo        *repository.NotificationRepository
+	contactRepo *repository.ContactRepository
+	userRepo    *repository.UserRepository
+	notifier    notify.Notifier
+	cfg         config.NotifyConfig
+	logger      *logger.Logger
+}
+
+func NewNotificationService(
+	repo *repository.NotificationRepository,
+	contactRepo *repository.ContactRepository,
+	userRepo *repository.UserRepository,
+	notifier notify.Notifier,
+	cfg config.NotifyConfig,
+	logger *logger.Logger,
+) *NotificationService {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	return &NotificationService{
+		repo:        repo,
+		contactRepo: contactRepo,
+		userRepo:    userRepo,
+		notifier:    notifier,
+		cfg:         cfg,
+		logger:      logger,
+	}
+}
+
+// NotifyContactsAsync 在后台通知紧急事件发起人的紧急联系人
+func (s *NotificationService) NotifyContactsAsync(emergency *model.Emergency) {
+	go func() {
+		if err := s.NotifyContacts(emergency); err != nil {
+			s.logger.Error("通知紧急联系人失败，事件 %d: %v", emergency.ID, err)
+		}
+	}()
+}
+
+// NotifyContacts 为紧急事件发起人的每个已确认的紧急联系人生成通知记录并依次发送
+func (s *NotificationService) NotifyContacts(emergency *model.Emergency) error {
+	user, err := s.userRepo.GetByID(emergency.UserID)
+	if err != nil {
+		return err
+	}
+	contacts, err := s.contactRepo.ListVerifiedByUser(emergency.UserID)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("紧急求助：%s", emergency.Title)
+	content := fmt.Sprintf("您的紧急联系人%s（%s）发起了紧急求助：%s。位置：%s（%.6f, %.6f）。请尽快与其联系，必要时拨打110。",
+		user.Name, user.Phone, emergency.Title, emergency.Location, emergency.Latitude, emergency.Longitude)
+
+	var deliveries []*model.NotificationDelivery
+	for _, contact := range contacts {
+		for _, channel := range s.cfg.Channels {
+			recipient := contactRecipient(&contact, channel)
+			if recipient == "" {
+				continue
+			}
+			deliveries = append(deliveries, &model.NotificationDelivery{
+				EmergencyID: emergency.ID,
+				ContactID:   contact.ID,
+				ContactName: contact.Name,
+				Channel:     channel,
+				Recipient:   recipient,
+				Subject:     subject,
+				Content:     content,
+				Priority:    len(deliveries),
+				Status:      model.DeliveryStatusPending,
+			})
+		}
+	}
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		s.deliver(delivery)
+	}
+	return nil
+}
+
+// Resume 服务启动时继续发送重启前未完成的通知
+func (s *NotificationService) Resume() error {
+	deliveries, err := s.repo.ListPending()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for i := range deliveries {
+			s.deliver(&deliveries[i])
+		}
+	}()
+	return nil
+}
+
+// ListByEmergency 获取紧急事件的通知记录
+func (s *NotificationService) ListByEmergency(emergencyID uint) ([]model.NotificationDelivery, error) {
+	return s.repo.ListByEmergency(emergencyID)
+}
+
+// deliver 发送一次通知并保存结果，失败且未达到最大次数时安排重试
+func (s *NotificationService) deliver(delivery *model.NotificationDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), notificationSendTimeout)
+	err := s.notifier.Send(ctx, &notify.Message{
+		Channel: delivery.Channel,
+		To:      delivery.Recipient,
+		Subject: delivery.Subject,
+		Body:    delivery.Content,
+	})
+	cancel()
+
+	delivery.Attempts++
+	if err == nil {
+		now := time.Now()
+		delivery.Status = model.DeliveryStatusSent
+		delivery.LastError = ""
+		delivery.SentAt = &now
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= s.cfg.MaxAttempts {
+			delivery.Status = model.DeliveryStatusFailed
+		}
+	}
+
+	if err := s.repo.UpdateDelivery(delivery); err != nil {
+		s.logger.Error("保存通知记录 %d 失败: %v", delivery.ID, err)
+		return
+	}
+	if delivery.Status == model.DeliveryStatusPending {
+		backoff := s.cfg.RetryBackoff << (delivery.Attempts - 1)
+		time.AfterFunc(backoff, func() { s.deliver(delivery) })
+	}
+}
+
+// contactRecipient 返回联系人在指定渠道的接收方，未填写时返回空
+func contactRecipient(contact *model.EmergencyContact, channel string) string {
+	switch channel {
+	case notify.ChannelSMS, notify.ChannelWebhook:
+		return contact.Phone
+	case notify.ChannelEmail:
+		return contact.Email
+	}
+	return ""
+}
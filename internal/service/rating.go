@@ -3,6 +3,10 @@ package service
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/repository"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
+
+	"gorm.io/gorm"
 )
 
 type RatingService struct {
@@ -35,9 +39,9 @@ func (s *RatingService) ListRatings(staffID uint) ([]*model.Rating, error) {
 	return s.repo.ListRatings(staffID)
 }
 
-// UpdateRating 更新评价
-func (s *RatingService) UpdateRating(id uint, req *model.CreateRatingRequest) error {
-	rating, err := s.repo.GetRatingByID(id)
+// UpdateRating 更新评价，仅限评价人和拥有 emergency:manage 权限的用户
+func (s *RatingService) UpdateRating(id, userID uint, roles []string, req *model.CreateRatingRequest) error {
+	rating, err := s.authorize(id, userID, roles)
 	if err != nil {
 		return err
 	}
@@ -49,7 +53,25 @@ func (s *RatingService) UpdateRating(id uint, req *model.CreateRatingRequest) er
 	return s.repo.UpdateRating(rating)
 }
 
-// DeleteRating 删除评价
-func (s *RatingService) DeleteRating(id uint) error {
+// DeleteRating 删除评价，仅限评价人和拥有 emergency:manage 权限的用户
+func (s *RatingService) DeleteRating(id, userID uint, roles []string) error {
+	if _, err := s.authorize(id, userID, roles); err != nil {
+		return err
+	}
 	return s.repo.DeleteRating(id)
 }
+
+// authorize 获取评价并校验修改权限，无权修改时按评价不存在处理
+func (s *RatingService) authorize(id, userID uint, roles []string) (*model.Rating, error) {
+	rating, err := s.repo.GetRatingByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrRatingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rating.UserID != userID && !auth.HasPermission(roles, auth.PermEmergencyManage) {
+		return nil, errors.ErrRatingNotFound
+	}
+	return rating, nil
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/pkg/realtime"
+)
+
+// publishHandlingRecord 向事件订阅者推送新增的处理记录
+func publishHandlingRecord(hub *realtime.Hub, record *model.HandlingRecord) {
+	hub.Publish(realtime.EmergencyTopic(record.EmergencyID), realtime.EventHandlingRecord, record)
+}
+
+// publishOffer 向安保人员推送派单或派单关闭
+func publishOffer(hub *realtime.Hub, eventType string, offer *model.DispatchOffer) {
+	hub.Publish(realtime.UserTopic(offer.StaffID), eventType, offer)
+}
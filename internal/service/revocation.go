@@ -0,0 +1,107 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/logger"
+	"sync"
+	"time"
+)
+
+// RevocationService 已吊销登录会话的名单，供认证中间件逐请求检查。
+// 名单缓存在内存中，并定期从数据库重新加载，使多实例部署时其他实例的吊销也能生效
+type RevocationService struct {
+	repo      *repository.TokenRepository
+	accessTTL time.Duration
+	logger    *logger.Logger
+
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+func NewRevocationService(repo *repository.TokenRepository, accessTTL time.Duration, logger *logger.Logger) *RevocationService {
+	return &RevocationService{
+		repo:      repo,
+		accessTTL: accessTTL,
+		logger:    logger,
+		revoked:   make(map[string]time.Time),
+	}
+}
+
+// Load 从数据库重新加载吊销名单
+func (s *RevocationService) Load() error {
+	sessions, err := s.repo.ListRevokedSessions()
+	if err != nil {
+		return err
+	}
+
+	revoked := make(map[string]time.Time, len(sessions))
+	for _, session := range sessions {
+		revoked[session.SessionID] = session.ExpiresAt
+	}
+
+	s.mu.Lock()
+	s.revoked = revoked
+	s.mu.Unlock()
+	return nil
+}
+
+// Start 按固定间隔在后台重新加载名单并清理过期记录
+func (s *RevocationService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.repo.DeleteExpired(); err != nil {
+				s.logger.Error("清理过期令牌失败: %v", err)
+			}
+			if err := s.Load(); err != nil {
+				s.logger.Error("加载会话吊销名单失败: %v", err)
+			}
+		}
+	}()
+}
+
+// Revoke 吊销登录会话：会话的刷新令牌立即失效，访问令牌在过期前都会被拒绝
+func (s *RevocationService) Revoke(userID uint, sessionID string) error {
+	if err := s.repo.RevokeSession(sessionID); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(s.accessTTL)
+	if err := s.repo.CreateRevokedSession(&model.RevokedSession{
+		SessionID: sessionID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.revoked[sessionID] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeUser 吊销用户所有仍可刷新的登录会话
+func (s *RevocationService) RevokeUser(userID uint) error {
+	sessionIDs, err := s.repo.ListActiveSessionIDs(userID)
+	if err != nil {
+		return err
+	}
+	for _, sessionID := range sessionIDs {
+		if err := s.Revoke(userID, sessionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsRevoked 实现 auth.RevocationList
+func (s *RevocationService) IsRevoked(sessionID string) bool {
+	s.mu.RLock()
+	expiresAt, ok := s.revoked[sessionID]
+	s.mu.RUnlock()
+	return ok && time.Now().Before(expiresAt)
+}
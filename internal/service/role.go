@@ -0,0 +1,126 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
+
+	"gorm.io/gorm"
+)
+
+// RoleService 用户角色管理。
+// 用户的角色由三部分组成：所有用户都有的普通用户角色、管理员授予的角色，
+// 以及由系统推导的角色（is_admin 标记的系统管理员、审核通过的安保人员）
+type RoleService struct {
+	repo         *repository.RoleRepository
+	userRepo     *repository.UserRepository
+	securityRepo *repository.SecurityRepository
+	revocations  *RevocationService
+}
+
+func NewRoleService(
+	repo *repository.RoleRepository,
+	userRepo *repository.UserRepository,
+	securityRepo *repository.SecurityRepository,
+	revocations *RevocationService,
+) *RoleService {
+	return &RoleService{
+		repo:         repo,
+		userRepo:     userRepo,
+		securityRepo: securityRepo,
+		revocations:  revocations,
+	}
+}
+
+// Roles 获取用户的全部角色，签发令牌时写入 claims
+func (s *RoleService) Roles(user *model.User) ([]string, error) {
+	roles := []string{auth.RoleUser}
+
+	staff, err := s.securityRepo.GetStaffByUserID(user.ID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+	if staff != nil && staff.Status == model.StaffStatusActive {
+		roles = append(roles, auth.RoleStaff)
+	}
+	if user.IsAdmin {
+		roles = append(roles, auth.RoleAdmin)
+	}
+
+	granted, err := s.repo.ListByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range granted {
+		if !auth.HasRole(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// GetUserRoles 获取用户的角色
+func (s *RoleService) GetUserRoles(userID uint) (*model.UserRoles, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	roles, err := s.Roles(user)
+	if err != nil {
+		return nil, err
+	}
+	granted, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.UserRoles{UserID: userID, Roles: roles, Granted: granted}, nil
+}
+
+// Grant 授予角色，用户刷新令牌后生效
+func (s *RoleService) Grant(operatorID, userID uint, role string) (*model.UserRoles, error) {
+	if err := validateGrantableRole(role); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	if err := s.repo.Grant(&model.UserRole{UserID: userID, Role: role, GrantedBy: operatorID}); err != nil {
+		return nil, err
+	}
+	return s.GetUserRoles(userID)
+}
+
+// Revoke 撤销角色。为使撤销立即生效，用户的所有登录会话会被吊销，需要重新登录
+func (s *RoleService) Revoke(operatorID, userID uint, role string) (*model.UserRoles, error) {
+	if err := validateGrantableRole(role); err != nil {
+		return nil, err
+	}
+	// 防止管理员撤销自己的管理员角色后无人可以管理角色
+	if operatorID == userID && role == auth.RoleAdmin {
+		return nil, errors.ErrInvalidAction
+	}
+
+	revoked, err := s.repo.Revoke(userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		if err := s.revocations.RevokeUser(userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUserRoles(userID)
+}
+
+func validateGrantableRole(role string) error {
+	if !auth.ValidRole(role) {
+		return errors.ErrInvalidRole
+	}
+	if role == auth.RoleUser || role == auth.RoleStaff {
+		return errors.ErrRoleNotGrantable
+	}
+	return nil
+}
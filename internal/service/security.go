@@ -3,15 +3,40 @@ package service
 import (
 	"dididaren/internal/model"
 	"dididaren/internal/repository"
-	"errors"
+	pkgerrors "dididaren/pkg/errors"
+	"dididaren/pkg/geo"
+	"dididaren/pkg/realtime"
+	"time"
 )
 
+// maxTrailRange 单次查询轨迹的最大时间跨度
+const maxTrailRange = 31 * 24 * time.Hour
+
 type SecurityService struct {
-	repo *repository.SecurityRepository
+	repo         *repository.SecurityRepository
+	locationRepo *repository.LocationRepository
+	geo          *GeoService
+	stateMachine *EmergencyStateMachine
+	dispatcher   *DispatchService
+	hub          *realtime.Hub
 }
 
-func NewSecurityService(repo *repository.SecurityRepository) *SecurityService {
-	return &SecurityService{repo: repo}
+func NewSecurityService(
+	repo *repository.SecurityRepository,
+	locationRepo *repository.LocationRepository,
+	geo *GeoService,
+	stateMachine *EmergencyStateMachine,
+	dispatcher *DispatchService,
+	hub *realtime.Hub,
+) *SecurityService {
+	return &SecurityService{
+		repo:         repo,
+		locationRepo: locationRepo,
+		geo:          geo,
+		stateMachine: stateMachine,
+		dispatcher:   dispatcher,
+		hub:          hub,
+	}
 }
 
 func (s *SecurityService) CreateStaff(req *model.CreateStaffRequest) (*model.Staff, error) {
@@ -36,13 +61,6 @@ func (s *SecurityService) ListStaffs(page, size int, status string) ([]model.Sta
 	return s.repo.ListStaffs(page, size, status)
 }
 
-func (s *SecurityService) UpdateStaffStatus(id uint, status string) error {
-	if status != "pending" && status != "active" && status != "inactive" {
-		return errors.New("无效的状态")
-	}
-	return s.repo.UpdateStaffStatus(id, status)
-}
-
 func (s *SecurityService) CreateRating(req *model.CreateRatingRequest) (*model.Rating, error) {
 	rating := &model.Rating{
 		StaffID:  req.StaffID,
@@ -63,40 +81,178 @@ func (s *SecurityService) ListRatings(staffID uint) ([]model.Rating, error) {
 	return s.repo.ListRatings(staffID)
 }
 
-func (s *SecurityService) UpdateLocation(staffID uint, lat, lng float64) error {
-	return s.repo.UpdateLocation(staffID, lat, lng)
+func (s *SecurityService) UpdateLocation(userID uint, lat, lng float64) error {
+	staff, err := s.repo.GetStaffByUserID(userID)
+	if err != nil {
+		return pkgerrors.ErrStaffNotFound
+	}
+	if err := s.repo.UpdateLocation(userID, lat, lng); err != nil {
+		return err
+	}
+	// 同步派单使用的空间索引
+	s.geo.SyncStaff(userID, lat, lng, staff.IsOnline)
+
+	event, err := s.repo.GetActiveEventByStaff(userID, model.EmergencyStatusAccepted, model.EmergencyStatusEnRoute, model.EmergencyStatusOnScene)
+	if err != nil {
+		return err
+	}
+
+	// 保存轨迹点，处理事件期间的轨迹关联到该事件
+	location := &model.StaffLocation{
+		StaffID:    userID,
+		Latitude:   lat,
+		Longitude:  lng,
+		RecordedAt: time.Now(),
+	}
+	if event != nil {
+		location.EmergencyID = event.ID
+	}
+	if err := s.locationRepo.Create(location); err != nil {
+		return err
+	}
+
+	// 正在处理事件时，把位置推送给报警人
+	if event != nil {
+		s.hub.Publish(realtime.EmergencyTopic(event.ID), realtime.EventStaffLocation, map[string]interface{}{
+			"staff_id":  userID,
+			"latitude":  lat,
+			"longitude": lng,
+		})
+	}
+	return nil
+}
+
+// GetStaffTrail 获取安保人员在时间范围内的轨迹，staffID 为安保人员记录ID
+func (s *SecurityService) GetStaffTrail(staffID uint, from, to time.Time) ([]model.StaffLocation, error) {
+	if !from.Before(to) || to.Sub(from) > maxTrailRange {
+		return nil, pkgerrors.ErrInvalidParameter
+	}
+
+	staff, err := s.repo.GetStaffByID(staffID)
+	if err != nil {
+		return nil, pkgerrors.ErrStaffNotFound
+	}
+	return s.locationRepo.ListByStaff(staff.UserID, from, to)
 }
 
-func (s *SecurityService) GetStaffInfo(userID uint) (*model.Staff, error) {
-	return s.repo.GetStaffByUserID(userID)
+// GetEventTrail 获取安保人员处理某事件期间的轨迹
+func (s *SecurityService) GetEventTrail(eventID uint) ([]model.StaffLocation, error) {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, pkgerrors.ErrEventNotFound
+	}
+	return s.locationRepo.ListByEmergency(eventID)
 }
 
-func (s *SecurityService) ApplySecurityStaff(userID uint, name, phone, idCard string) error {
-	// 检查是否已经是安保人员
-	existingStaff, err := s.repo.GetStaffByUserID(userID)
-	if err == nil && existingStaff != nil {
-		return errors.New("您已经是安保人员")
+// TrailToGPX 将轨迹转换为 GPX 文档
+func TrailToGPX(name string, locations []model.StaffLocation) *geo.GPX {
+	points := make([]geo.GPXPoint, len(locations))
+	for i, location := range locations {
+		points[i] = geo.GPXPoint{
+			Lat:  location.Latitude,
+			Lon:  location.Longitude,
+			Time: location.RecordedAt,
+		}
 	}
+	return geo.NewGPX("dididaren", name, points)
+}
 
-	staff := &model.Staff{
-		UserID: userID,
-		Name:   name,
-		Phone:  phone,
-		IDCard: idCard,
+// TrailToGeoJSON 将轨迹转换为 GeoJSON，包含一条轨迹折线和每个轨迹点
+func TrailToGeoJSON(name string, locations []model.StaffLocation) *geo.FeatureCollection {
+	points := make([][2]float64, len(locations))
+	times := make([]string, len(locations))
+	features := make([]*geo.Feature, 0, len(locations)+1)
+	for i, location := range locations {
+		points[i] = [2]float64{location.Latitude, location.Longitude}
+		times[i] = location.RecordedAt.UTC().Format(time.RFC3339)
 	}
 
-	if err := s.repo.CreateStaff(staff); err != nil {
+	// 单点无法构成折线，此时只输出轨迹点
+	if len(points) > 1 {
+		features = append(features, geo.NewFeature(geo.NewLineString(points), map[string]interface{}{
+			"name":  name,
+			"times": times,
+		}))
+	}
+	for i, location := range locations {
+		feature := geo.NewFeature(geo.NewPoint(location.Latitude, location.Longitude), map[string]interface{}{
+			"staff_id":     location.StaffID,
+			"emergency_id": location.EmergencyID,
+			"recorded_at":  times[i],
+		})
+		feature.ID = location.ID
+		features = append(features, feature)
+	}
+	return geo.NewFeatureCollection(features...)
+}
+
+// UpdateOnlineStatus 安保人员上线或下线，只有审核通过的安保人员可以上线接单
+func (s *SecurityService) UpdateOnlineStatus(userID uint, isOnline bool) error {
+	staff, err := s.repo.GetStaffByUserID(userID)
+	if err != nil {
+		return pkgerrors.ErrStaffNotFound
+	}
+	if isOnline && staff.Status != model.StaffStatusActive {
+		return pkgerrors.ErrStaffNotApproved
+	}
+
+	if err := s.repo.UpdateOnlineStatus(userID, isOnline); err != nil {
 		return err
 	}
+
+	s.geo.SyncStaff(userID, staff.Latitude, staff.Longitude, isOnline)
 	return nil
 }
 
+// GetNearbyStaff 获取附近的在线安保人员，按距离由近到远排序
+func (s *SecurityService) GetNearbyStaff(lat, lng, radius float64) ([]model.NearbyStaff, error) {
+	return s.geo.NearbyStaff(lat, lng, radius)
+}
+
+func (s *SecurityService) GetStaffInfo(userID uint) (*model.Staff, error) {
+	return s.repo.GetStaffByUserID(userID)
+}
+
 func (s *SecurityService) AcceptEvent(staffID uint, eventID uint) error {
-	// TODO: 实现接单逻辑
-	return nil
+	return s.dispatcher.Accept(staffID, eventID)
+}
+
+func (s *SecurityService) DeclineEvent(staffID uint, eventID uint, reason string) error {
+	return s.dispatcher.Decline(staffID, eventID, reason)
+}
+
+func (s *SecurityService) ListPendingOffers(staffID uint) ([]model.DispatchOffer, error) {
+	return s.dispatcher.ListPendingOffers(staffID)
+}
+
+func (s *SecurityService) DepartEvent(staffID uint, eventID uint) error {
+	return s.transitAssignedEvent(staffID, eventID, model.EmergencyStatusEnRoute, "安保人员出发前往现场")
+}
+
+func (s *SecurityService) ArriveEvent(staffID uint, eventID uint) error {
+	return s.transitAssignedEvent(staffID, eventID, model.EmergencyStatusOnScene, "安保人员到达现场")
 }
 
 func (s *SecurityService) CompleteEvent(staffID uint, eventID uint) error {
-	// TODO: 实现完成订单逻辑
-	return nil
+	return s.transitAssignedEvent(staffID, eventID, model.EmergencyStatusCompleted, "安保人员完成处理")
+}
+
+// transitAssignedEvent 由接单的安保人员推进事件状态
+func (s *SecurityService) transitAssignedEvent(staffID uint, eventID uint, status int, remark string) error {
+	event, err := s.repo.GetEventByID(eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return pkgerrors.ErrEventNotFound
+	}
+	if event.StaffID != staffID {
+		return pkgerrors.ErrInvalidAction
+	}
+
+	_, err = s.stateMachine.Transit(eventID, status, staffID, remark, nil)
+	return err
 }
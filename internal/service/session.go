@@ -0,0 +1,102 @@
+package service
+
+import (
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/logger"
+	"sync"
+	"time"
+)
+
+const (
+	// sessionTouchInterval 同一会话最近活动时间写入数据库的最小间隔
+	sessionTouchInterval = time.Minute
+	// maxDeviceNameLength 设备名称的最大长度
+	maxDeviceNameLength = 100
+	// maxUserAgentLength User-Agent 的最大长度
+	maxUserAgentLength = 255
+)
+
+// SessionService 用户的多设备登录会话管理
+type SessionService struct {
+	repo        *repository.TokenRepository
+	revocations *RevocationService
+	logger      *logger.Logger
+
+	mu sync.Mutex
+	// touched 每个会话上次写入最近活动时间的时刻
+	touched map[string]time.Time
+}
+
+func NewSessionService(repo *repository.TokenRepository, revocations *RevocationService, logger *logger.Logger) *SessionService {
+	return &SessionService{
+		repo:        repo,
+		revocations: revocations,
+		logger:      logger,
+		touched:     make(map[string]time.Time),
+	}
+}
+
+// List 获取用户的登录会话，标记出发起请求的会话
+func (s *SessionService) List(userID uint, currentSessionID string) ([]model.Session, error) {
+	sessions, err := s.repo.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// Revoke 吊销用户的某个登录会话，该设备需要重新登录
+func (s *SessionService) Revoke(userID uint, sessionID string) error {
+	session, err := s.repo.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return errors.ErrSessionNotFound
+	}
+	return s.revocations.Revoke(userID, sessionID)
+}
+
+// Touch 记录会话的最近活动，实现 middleware.SessionTracker。
+// 每个会话每分钟最多写一次数据库
+func (s *SessionService) Touch(sessionID, ip string) {
+	if sessionID == "" {
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	if last, ok := s.touched[sessionID]; ok && now.Sub(last) < sessionTouchInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.touched[sessionID] = now
+	// 清理早已不活动的会话，避免 map 无限增长
+	if len(s.touched) > 10000 {
+		for id, last := range s.touched {
+			if now.Sub(last) >= sessionTouchInterval {
+				delete(s.touched, id)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	err := s.repo.UpdateSession(sessionID, map[string]interface{}{"ip": ip, "last_seen_at": now})
+	if err != nil {
+		s.logger.Error("更新会话活动时间失败: session_id=%s err=%v", sessionID, err)
+	}
+}
+
+// truncateRunes 按字符截断字符串
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
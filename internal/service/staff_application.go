@@ -0,0 +1,376 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/logger"
+	"dididaren/pkg/notify"
+	"dididaren/pkg/storage"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxStaffDocumentSize 单个申请材料的最大字节数
+	maxStaffDocumentSize = 10 << 20
+	// staffNotifyTimeout 通知申请人的超时
+	staffNotifyTimeout = 10 * time.Second
+)
+
+// staffDocumentTypes 需要上传的申请材料，审核通过前每种都要上传
+var staffDocumentTypes = []string{
+	model.StaffDocumentIDCardFront,
+	model.StaffDocumentIDCardBack,
+	model.StaffDocumentLicence,
+}
+
+// staffDocumentExts 允许的材料格式，按文件内容识别
+var staffDocumentExts = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// staffTransitions 审核人员可以执行的状态变更
+var staffTransitions = map[string][]string{
+	model.StaffStatusPending:  {model.StaffStatusActive, model.StaffStatusRejected},
+	model.StaffStatusActive:   {model.StaffStatusInactive},
+	model.StaffStatusInactive: {model.StaffStatusActive},
+}
+
+// staffStatusNames 通知申请人时使用的状态名称
+var staffStatusNames = map[string]string{
+	model.StaffStatusActive:   "已通过",
+	model.StaffStatusRejected: "未通过",
+	model.StaffStatusInactive: "已停用",
+}
+
+// StaffApplicationService 安保人员申请和审核。
+// 申请人提交资料并上传证件，审核人员通过或拒绝时必须填写原因，
+// 每次状态变更都记录在案并短信通知申请人。审核通过后 RoleService 自动授予 staff 角色
+type StaffApplicationService struct {
+	repo         *repository.StaffApplicationRepository
+	securityRepo *repository.SecurityRepository
+	userRepo     *repository.UserRepository
+	storage      storage.Storage
+	notifier     notify.Notifier
+	revocations  *RevocationService
+	geo          *GeoService
+	logger       *logger.Logger
+}
+
+func NewStaffApplicationService(
+	repo *repository.StaffApplicationRepository,
+	securityRepo *repository.SecurityRepository,
+	userRepo *repository.UserRepository,
+	storage storage.Storage,
+	notifier notify.Notifier,
+	revocations *RevocationService,
+	geo *GeoService,
+	logger *logger.Logger,
+) *StaffApplicationService {
+	return &StaffApplicationService{
+		repo:         repo,
+		securityRepo: securityRepo,
+		userRepo:     userRepo,
+		storage:      storage,
+		notifier:     notifier,
+		revocations:  revocations,
+		geo:          geo,
+		logger:       logger,
+	}
+}
+
+// Apply 提交安保人员申请，被拒绝的申请修改资料后可以重新提交
+func (s *StaffApplicationService) Apply(userID uint, req *model.ApplyStaffRequest) (*model.Staff, error) {
+	if !ValidPhone(req.Phone) {
+		return nil, errors.ErrInvalidPhone
+	}
+
+	staff, err := s.findByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	fromStatus := ""
+	if staff != nil {
+		switch staff.Status {
+		case model.StaffStatusRejected:
+			fromStatus = staff.Status
+		case model.StaffStatusPending:
+			return nil, errors.ErrStaffUnderReview
+		default:
+			return nil, errors.ErrStaffExists
+		}
+	} else {
+		staff = &model.Staff{UserID: userID}
+	}
+
+	staff.Name = req.Name
+	staff.Phone = req.Phone
+	staff.IDCard = req.IDCard
+	staff.Status = model.StaffStatusPending
+	staff.ReviewReason = ""
+	ok, err := s.repo.Apply(staff, fromStatus)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.ErrStaffStatusChanged
+	}
+	return staff, nil
+}
+
+// GetApplication 获取当前用户的申请详情
+func (s *StaffApplicationService) GetApplication(userID uint) (*model.StaffApplication, error) {
+	staff, err := s.findByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil {
+		return nil, errors.ErrStaffNotFound
+	}
+	return s.application(staff)
+}
+
+// GetApplicationByStaffID 审核人员查看申请详情
+func (s *StaffApplicationService) GetApplicationByStaffID(staffID uint) (*model.StaffApplication, error) {
+	staff, err := s.securityRepo.GetStaffByID(staffID)
+	if err != nil {
+		return nil, errors.ErrStaffNotFound
+	}
+	return s.application(staff)
+}
+
+// UploadDocument 上传申请材料，只能在待审核或被拒绝时上传。
+// 每种材料只保留一份，重新上传同类型的材料会替换之前的文件
+func (s *StaffApplicationService) UploadDocument(userID uint, docType, fileName string, r io.Reader) (*model.StaffDocument, error) {
+	if !validStaffDocumentType(docType) {
+		return nil, errors.ErrInvalidParameter
+	}
+	staff, err := s.findByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil {
+		return nil, errors.ErrStaffNotFound
+	}
+	if staff.Status != model.StaffStatusPending && staff.Status != model.StaffStatusRejected {
+		return nil, errors.ErrStaffApplicationClosed
+	}
+
+	// 按文件内容识别格式，不信任客户端提供的类型和扩展名
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, errors.ErrInvalidStaffDocument
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	ext, ok := staffDocumentExts[contentType]
+	if !ok {
+		return nil, errors.ErrInvalidStaffDocument
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("staff/%d/%s-%s%s", staff.ID, docType, hex.EncodeToString(suffix), ext)
+
+	// 多读一个字节用于判断是否超出大小限制
+	counter := &countingReader{r: io.LimitReader(io.MultiReader(bytes.NewReader(head), r), maxStaffDocumentSize+1)}
+	if err := s.storage.Save(key, counter); err != nil {
+		return nil, err
+	}
+	if counter.n > maxStaffDocumentSize {
+		s.deleteFile(key)
+		return nil, errors.ErrInvalidStaffDocument
+	}
+
+	doc := &model.StaffDocument{
+		StaffID:     staff.ID,
+		Type:        docType,
+		FileKey:     key,
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		Size:        counter.n,
+	}
+	replaced, err := s.repo.ReplaceDocument(doc)
+	if err != nil {
+		s.deleteFile(key)
+		return nil, err
+	}
+	for _, old := range replaced {
+		s.deleteFile(old.FileKey)
+	}
+	return doc, nil
+}
+
+// OpenDocument 审核人员读取申请材料，调用方负责关闭返回的文件
+func (s *StaffApplicationService) OpenDocument(staffID, docID uint) (*model.StaffDocument, io.ReadCloser, error) {
+	doc, err := s.repo.GetDocument(docID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc == nil || doc.StaffID != staffID {
+		return nil, nil, errors.ErrStaffDocumentNotFound
+	}
+	file, err := s.storage.Open(doc.FileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, file, nil
+}
+
+// UpdateStatus 审核人员通过、拒绝、停用或恢复安保人员，原因会记录并通知申请人。
+// 停用后立即下线，并吊销其登录会话使 staff 角色失效
+func (s *StaffApplicationService) UpdateStatus(staffID, operatorID uint, req *model.UpdateStaffStatusRequest) error {
+	staff, err := s.securityRepo.GetStaffByID(staffID)
+	if err != nil {
+		return errors.ErrStaffNotFound
+	}
+	if !validStaffTransition(staff.Status, req.Status) {
+		return errors.ErrInvalidStaffStatus
+	}
+	if staff.Status == model.StaffStatusPending && req.Status == model.StaffStatusActive {
+		if err := s.checkDocuments(staff.ID); err != nil {
+			return err
+		}
+	}
+
+	ok, err := s.repo.UpdateStatus(staff.ID, staff.Status, req.Status, operatorID, req.Reason)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.ErrStaffStatusChanged
+	}
+
+	if req.Status == model.StaffStatusInactive {
+		if err := s.securityRepo.UpdateOnlineStatus(staff.UserID, false); err != nil {
+			return err
+		}
+		s.geo.SyncStaff(staff.UserID, 0, 0, false)
+		if err := s.revocations.RevokeUser(staff.UserID); err != nil {
+			return err
+		}
+	}
+
+	s.notifyApplicantAsync(staff, req.Status, req.Reason)
+	return nil
+}
+
+// findByUser 获取用户的安保人员记录，不存在时返回 nil
+func (s *StaffApplicationService) findByUser(userID uint) (*model.Staff, error) {
+	staff, err := s.securityRepo.GetStaffByUserID(userID)
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// application 组装申请详情
+func (s *StaffApplicationService) application(staff *model.Staff) (*model.StaffApplication, error) {
+	docs, err := s.repo.ListDocuments(staff.ID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.repo.ListHistory(staff.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StaffApplication{Staff: staff, Documents: docs, History: history}, nil
+}
+
+// checkDocuments 检查申请材料是否齐全
+func (s *StaffApplicationService) checkDocuments(staffID uint) error {
+	docs, err := s.repo.ListDocuments(staffID)
+	if err != nil {
+		return err
+	}
+	uploaded := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		uploaded[doc.Type] = true
+	}
+	for _, docType := range staffDocumentTypes {
+		if !uploaded[docType] {
+			return errors.ErrStaffDocumentsMissing
+		}
+	}
+	return nil
+}
+
+// notifyApplicantAsync 在后台短信通知申请人审核结果
+func (s *StaffApplicationService) notifyApplicantAsync(staff *model.Staff, status, reason string) {
+	go func() {
+		user, err := s.userRepo.GetByID(staff.UserID)
+		if err != nil {
+			s.logger.Error("通知安保人员申请人失败: staff_id=%d err=%v", staff.ID, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), staffNotifyTimeout)
+		defer cancel()
+		err = s.notifier.Send(ctx, &notify.Message{
+			Channel: notify.ChannelSMS,
+			To:      user.Phone,
+			Subject: "安保人员审核结果",
+			Body:    fmt.Sprintf("您的安保人员资格%s，原因：%s。", staffStatusNames[status], reason),
+		})
+		if err != nil {
+			s.logger.Error("通知安保人员申请人失败: staff_id=%d err=%v", staff.ID, err)
+		}
+	}()
+}
+
+// deleteFile 删除已保存的文件，失败只记录日志
+func (s *StaffApplicationService) deleteFile(key string) {
+	if err := s.storage.Delete(key); err != nil {
+		s.logger.Error("删除申请材料文件失败: key=%s err=%v", key, err)
+	}
+}
+
+// validStaffDocumentType 判断是否为支持的申请材料类型
+func validStaffDocumentType(docType string) bool {
+	for _, t := range staffDocumentTypes {
+		if t == docType {
+			return true
+		}
+	}
+	return false
+}
+
+// validStaffTransition 判断审核人员能否执行该状态变更
+func validStaffTransition(from, to string) bool {
+	for _, status := range staffTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
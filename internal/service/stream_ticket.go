@@ -0,0 +1,70 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// streamTicketTTL 实时推送连接凭证的有效期，客户端取得凭证后应立即建立连接
+const streamTicketTTL = 30 * time.Second
+
+type streamTicket struct {
+	userID    uint
+	roles     []string
+	sessionID string
+	expiresAt time.Time
+}
+
+// StreamTicketService 签发实时推送的一次性连接凭证。
+// 浏览器的 WebSocket/EventSource 无法设置请求头，访问令牌放在查询参数中会被写入访问日志和代理日志，
+// 因此先用访问令牌换取短期有效、只能使用一次的凭证，再通过查询参数携带凭证建立连接。
+// 凭证只保存在内存中，与派单一样按单节点部署设计
+type StreamTicketService struct {
+	mu      sync.Mutex
+	tickets map[string]streamTicket
+}
+
+func NewStreamTicketService() *StreamTicketService {
+	return &StreamTicketService{tickets: make(map[string]streamTicket)}
+}
+
+// Issue 为已认证的用户签发连接凭证，返回凭证和有效期
+func (s *StreamTicketService) Issue(userID uint, roles []string, sessionID string) (string, time.Duration, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", 0, err
+	}
+	ticket := hex.EncodeToString(buf)
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 顺带清理过期未使用的凭证
+	for key, t := range s.tickets {
+		if now.After(t.expiresAt) {
+			delete(s.tickets, key)
+		}
+	}
+	s.tickets[ticket] = streamTicket{
+		userID:    userID,
+		roles:     roles,
+		sessionID: sessionID,
+		expiresAt: now.Add(streamTicketTTL),
+	}
+	return ticket, streamTicketTTL, nil
+}
+
+// Redeem 使用连接凭证，凭证无论是否有效都会被作废
+func (s *StreamTicketService) Redeem(ticket string) (userID uint, roles []string, sessionID string, ok bool) {
+	s.mu.Lock()
+	t, found := s.tickets[ticket]
+	delete(s.tickets, ticket)
+	s.mu.Unlock()
+
+	if !found || time.Now().After(t.expiresAt) {
+		return 0, nil, "", false
+	}
+	return t.userID, t.roles, t.sessionID, true
+}
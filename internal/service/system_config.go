@@ -4,28 +4,79 @@ import (
 	"dididaren/internal/model"
 	"dididaren/internal/repository"
 	"dididaren/pkg/errors"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// configCacheTTL 配置缓存的有效期。本实例的写操作会立即清除缓存，
+// 有效期用于限制多实例部署时其他实例修改配置后读到旧值的时间
+const configCacheTTL = time.Minute
+
+// configCacheEntry 缓存的配置，config 为空表示配置不存在，同样缓存以免反复查询未配置的键
+type configCacheEntry struct {
+	config    *model.SystemConfig
+	expiresAt time.Time
+}
+
+// configListCacheEntry 按前缀缓存的配置列表
+type configListCacheEntry struct {
+	configs   []model.SystemConfig
+	expiresAt time.Time
+}
+
 type SystemConfigService struct {
 	repo *repository.SystemConfigRepository
+
+	mu          sync.RWMutex
+	cache       map[string]configCacheEntry
+	prefixCache map[string]configListCacheEntry
+	generation  uint64 // 每次写操作加一，查询期间发生过写操作时不写入缓存
 }
 
 func NewSystemConfigService(repo *repository.SystemConfigRepository) *SystemConfigService {
-	return &SystemConfigService{repo: repo}
+	return &SystemConfigService{
+		repo:        repo,
+		cache:       make(map[string]configCacheEntry),
+		prefixCache: make(map[string]configListCacheEntry),
+	}
 }
 
-// Create 创建系统配置
-func (s *SystemConfigService) Create(req *model.CreateSystemConfigRequest) (*model.SystemConfig, error) {
+// Create 创建系统配置，值必须符合声明的类型和取值范围
+func (s *SystemConfigService) Create(operatorID uint, req *model.CreateSystemConfigRequest) (*model.SystemConfig, error) {
+	if err := validateConfigSchema(req.Type, req.Schema); err != nil {
+		return nil, err
+	}
+	if err := validateConfigValue(req.Type, req.Value, req.Schema); err != nil {
+		return nil, err
+	}
+	if err := validateFeatureFlag(req.Key, req.Type, req.Value); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetByKey(req.Key); err == nil {
+		return nil, errors.ErrConfigExists
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
 	config := &model.SystemConfig{
-		Key:   req.Key,
-		Value: req.Value,
-		Type:  req.Type,
-		Desc:  req.Desc,
+		Key:    req.Key,
+		Value:  req.Value,
+		Type:   req.Type,
+		Schema: req.Schema,
+		Desc:   req.Desc,
 	}
 
-	if err := s.repo.Create(config); err != nil {
+	if err := s.repo.Create(config, operatorID); err != nil {
 		return nil, err
 	}
+	s.invalidate(config.Key)
 
 	return config, nil
 }
@@ -40,23 +91,54 @@ func (s *SystemConfigService) List(page, size int) ([]model.SystemConfig, int64,
 	return s.repo.List(page, size)
 }
 
-// Update 更新系统配置
-func (s *SystemConfigService) Update(id uint, req *model.UpdateSystemConfigRequest) error {
-	config, err := s.repo.GetByID(id)
+// Update 更新系统配置，值必须符合新的类型和取值范围
+func (s *SystemConfigService) Update(operatorID, id uint, req *model.UpdateSystemConfigRequest) error {
+	if err := validateConfigSchema(req.Type, req.Schema); err != nil {
+		return err
+	}
+	if err := validateConfigValue(req.Type, req.Value, req.Schema); err != nil {
+		return err
+	}
+	current, err := s.repo.GetByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return errors.ErrConfigNotFound
+	}
 	if err != nil {
 		return err
 	}
+	if err := validateFeatureFlag(current.Key, req.Type, req.Value); err != nil {
+		return err
+	}
 
-	config.Value = req.Value
-	config.Type = req.Type
-	config.Desc = req.Desc
-
-	return s.repo.Update(config)
+	config := &model.SystemConfig{
+		ID:     id,
+		Value:  req.Value,
+		Type:   req.Type,
+		Schema: req.Schema,
+		Desc:   req.Desc,
+	}
+	old, err := s.repo.Update(config, operatorID)
+	if err == gorm.ErrRecordNotFound {
+		return errors.ErrConfigNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.invalidate(old.Key)
+	return nil
 }
 
 // Delete 删除系统配置
-func (s *SystemConfigService) Delete(id uint) error {
-	return s.repo.Delete(id)
+func (s *SystemConfigService) Delete(operatorID, id uint) error {
+	old, err := s.repo.Delete(id, operatorID)
+	if err == gorm.ErrRecordNotFound {
+		return errors.ErrConfigNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.invalidate(old.Key)
+	return nil
 }
 
 // GetByKey 根据key获取配置
@@ -73,10 +155,213 @@ func (s *SystemConfigService) GetByKey(key string) (*model.SystemConfig, error)
 
 // GetValue 获取配置值
 func (s *SystemConfigService) GetValue(key string) (string, error) {
-	return s.repo.GetValue(key)
+	config, err := s.cached(key)
+	if err != nil {
+		return "", err
+	}
+	if config == nil {
+		return "", errors.ErrConfigNotFound
+	}
+	return config.Value, nil
+}
+
+// UpdateValue 更新配置值，值必须符合配置声明的类型和取值范围
+func (s *SystemConfigService) UpdateValue(operatorID uint, key string, value string) error {
+	err := s.repo.UpdateValue(key, value, operatorID, func(config *model.SystemConfig) error {
+		if err := validateConfigValue(config.Type, value, config.Schema); err != nil {
+			return err
+		}
+		return validateFeatureFlag(config.Key, config.Type, value)
+	})
+	if err == gorm.ErrRecordNotFound {
+		return errors.ErrConfigNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.invalidate(key)
+	return nil
+}
+
+// ListByPrefix 获取键以 prefix 开头的全部配置，读取经过缓存，返回的切片为副本
+func (s *SystemConfigService) ListByPrefix(prefix string) ([]model.SystemConfig, error) {
+	now := time.Now()
+	s.mu.RLock()
+	entry, ok := s.prefixCache[prefix]
+	generation := s.generation
+	s.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return append([]model.SystemConfig(nil), entry.configs...), nil
+	}
+
+	configs, err := s.repo.ListByKeyPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.generation == generation {
+		s.prefixCache[prefix] = configListCacheEntry{configs: configs, expiresAt: now.Add(configCacheTTL)}
+	}
+	s.mu.Unlock()
+	return append([]model.SystemConfig(nil), configs...), nil
+}
+
+// ListHistory 获取配置的变更记录，按版本从新到旧排列。配置删除后记录仍然保留
+func (s *SystemConfigService) ListHistory(key string, page, size int) ([]model.SystemConfigHistory, int64, error) {
+	return s.repo.ListHistory(key, page, size)
+}
+
+// Rollback 将配置恢复为指定版本变更后的值、类型和取值范围，回滚本身也记录为一个新版本。
+// 配置已被删除时重新创建；不能回滚到删除记录
+func (s *SystemConfigService) Rollback(operatorID uint, key string, version int) (*model.SystemConfig, error) {
+	target, err := s.repo.GetHistory(key, version)
+	if err == gorm.ErrRecordNotFound {
+		return nil, errors.ErrConfigVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.NewValue == nil {
+		return nil, fmt.Errorf("%w: 版本 %d 是删除记录，不能回滚到该版本", errors.ErrInvalidConfig, version)
+	}
+
+	config, err := s.repo.Restore(target, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(key)
+	return config, nil
+}
+
+// GetFloat 读取数值配置项，缺失或不是数字时返回默认值。0 和负数原样返回，需要限制范围时为配置设置 schema
+func (s *SystemConfigService) GetFloat(key string, def float64) float64 {
+	value, err := s.GetValue(key)
+	if err != nil {
+		return def
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetInt 读取整数配置项，缺失或不是整数时返回默认值。
+// 小数部分为0的 float 配置也可以读取，如 "5.0"
+func (s *SystemConfigService) GetInt(key string, def int) int {
+	value, err := s.GetValue(key)
+	if err != nil {
+		return def
+	}
+	if v, err := strconv.Atoi(value); err == nil {
+		return v
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v != math.Trunc(v) {
+		return def
+	}
+	return int(v)
+}
+
+// GetBool 读取布尔配置项，缺失或非法时返回默认值
+func (s *SystemConfigService) GetBool(key string, def bool) bool {
+	value, err := s.GetValue(key)
+	if err != nil {
+		return def
+	}
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// GetDuration 读取时长配置项，缺失或非法时返回默认值。
+// duration 类型的值如 "30s"、"5m" 按时长解析；int、float 类型的值为 unit 的倍数，
+// 如 unit 为 time.Second 时 "300" 表示300秒，兼容以数字保存的旧配置
+func (s *SystemConfigService) GetDuration(key string, unit, def time.Duration) time.Duration {
+	value, err := s.GetValue(key)
+	if err != nil {
+		return def
+	}
+	if v, err := time.ParseDuration(value); err == nil {
+		return v
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return def
+	}
+	return time.Duration(v * float64(unit))
+}
+
+// GetString 读取字符串配置项，缺失时返回默认值
+func (s *SystemConfigService) GetString(key string, def string) string {
+	value, err := s.GetValue(key)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// GetStringList 读取字符串数组配置项，缺失或非法时返回默认值
+func (s *SystemConfigService) GetStringList(key string, def []string) []string {
+	value, err := s.GetValue(key)
+	if err != nil {
+		return def
+	}
+	var v []string
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return def
+	}
+	return v
+}
+
+// GetJSON 将 JSON 配置项解码到 out，配置不存在时返回 ErrConfigNotFound
+func (s *SystemConfigService) GetJSON(key string, out interface{}) error {
+	value, err := s.GetValue(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(value), out)
 }
 
-// UpdateValue 更新配置值
-func (s *SystemConfigService) UpdateValue(key string, value string) error {
-	return s.repo.UpdateValue(key, value)
+// cached 从缓存读取配置，缓存过期或不存在时查询数据库，配置不存在时返回 nil
+func (s *SystemConfigService) cached(key string) (*model.SystemConfig, error) {
+	now := time.Now()
+	s.mu.RLock()
+	entry, ok := s.cache[key]
+	generation := s.generation
+	s.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.config, nil
+	}
+
+	config, err := s.repo.GetByKey(key)
+	if err == gorm.ErrRecordNotFound {
+		config, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.generation == generation {
+		s.cache[key] = configCacheEntry{config: config, expiresAt: now.Add(configCacheTTL)}
+	}
+	s.mu.Unlock()
+	return config, nil
+}
+
+// invalidate 清除配置缓存
+func (s *SystemConfigService) invalidate(key string) {
+	s.mu.Lock()
+	delete(s.cache, key)
+	for prefix := range s.prefixCache {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.prefixCache, prefix)
+		}
+	}
+	s.generation++
+	s.mu.Unlock()
 }
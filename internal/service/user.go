@@ -5,21 +5,57 @@ import (
 	"dididaren/internal/repository"
 	"dididaren/pkg/auth"
 	"dididaren/pkg/errors"
+	"time"
 )
 
 type UserService struct {
-	repo *repository.UserRepository
+	repo        *repository.UserRepository
+	authService *AuthService
+	verifyCodes *VerifyCodeService
+	loginGuard  *LoginGuardService
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(
+	repo *repository.UserRepository,
+	authService *AuthService,
+	verifyCodes *VerifyCodeService,
+	loginGuard *LoginGuardService,
+) *UserService {
+	return &UserService{
+		repo:        repo,
+		authService: authService,
+		verifyCodes: verifyCodes,
+		loginGuard:  loginGuard,
+	}
 }
 
-// Register 用户注册
-func (s *UserService) Register(req *model.RegisterRequest) (*model.User, error) {
-	// 检查手机号是否已注册
+// SendCode 发送登录或注册验证码，返回有效期
+func (s *UserService) SendCode(req *model.SendCodeRequest, ip string) (time.Duration, error) {
+	if !ValidPhone(req.Phone) {
+		return 0, errors.ErrInvalidPhone
+	}
+
+	// 注册时已注册、登录时未注册的手机号同样返回成功，只是不发送短信，避免探测手机号是否注册
 	exists := s.repo.ExistsByPhone(req.Phone)
-	if exists {
+	if (req.Purpose == model.VerifyCodePurposeRegister && exists) ||
+		(req.Purpose == model.VerifyCodePurposeLogin && !exists) {
+		return s.verifyCodes.SendSilently(req.Phone, req.Purpose, ip)
+	}
+
+	return s.verifyCodes.Send(req.Phone, req.Purpose, ip)
+}
+
+// Register 用户注册，需要先获取注册验证码
+func (s *UserService) Register(req *model.RegisterRequest) (*model.User, error) {
+	if !ValidPhone(req.Phone) {
+		return nil, errors.ErrInvalidPhone
+	}
+
+	// 先校验验证码再检查手机号是否已注册，已注册的手机号收不到注册验证码，无法据此探测
+	if err := s.verifyCodes.Verify(req.Phone, model.VerifyCodePurposeRegister, req.Code); err != nil {
+		return nil, err
+	}
+	if s.repo.ExistsByPhone(req.Phone) {
 		return nil, errors.ErrPhoneAlreadyRegistered
 	}
 
@@ -38,25 +74,58 @@ func (s *UserService) Register(req *model.RegisterRequest) (*model.User, error)
 }
 
 // Login 用户登录
-func (s *UserService) Login(req *model.LoginRequest) (string, error) {
-	// 获取用户信息
+func (s *UserService) Login(req *model.LoginRequest, client *model.ClientInfo) (*model.TokenPair, error) {
+	// 获取用户信息，手机号未注册时同样计入失败次数
 	user, err := s.repo.GetByPhone(req.Phone)
 	if err != nil {
-		return "", errors.ErrInvalidCredentials
+		user = nil
+	}
+	if err := s.loginGuard.Check(req.Phone, client.IP, user); err != nil {
+		return nil, err
 	}
 
 	// 验证密码
-	if !auth.VerifyPassword(req.Password, user.Password) {
-		return "", errors.ErrInvalidCredentials
+	if user == nil || !auth.VerifyPassword(req.Password, user.Password) {
+		if err := s.loginGuard.RecordFailure(req.Phone, client.IP, user); err != nil {
+			return nil, err
+		}
+		return nil, errors.ErrInvalidCredentials
+	}
+	if err := s.loginGuard.RecordSuccess(req.Phone, client.IP); err != nil {
+		return nil, err
 	}
 
 	// 生成token
-	token, err := auth.GenerateToken(user.ID, user.Phone, user.IsAdmin)
+	return s.authService.IssueTokens(user, client)
+}
+
+// LoginByCode 验证码登录
+func (s *UserService) LoginByCode(req *model.CodeLoginRequest, client *model.ClientInfo) (*model.TokenPair, error) {
+	// 手机号未注册时与验证码错误一样处理，不暴露手机号是否注册
+	user, err := s.repo.GetByPhone(req.Phone)
 	if err != nil {
-		return "", err
+		user = nil
+	}
+	// 被锁定的账号同样不能通过验证码登录
+	if err := s.loginGuard.Check(req.Phone, client.IP, user); err != nil {
+		return nil, err
 	}
 
-	return token, nil
+	if err := s.verifyCodes.Verify(req.Phone, model.VerifyCodePurposeLogin, req.Code); err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrInvalidVerifyCode
+	}
+	if err := s.loginGuard.RecordSuccess(req.Phone, client.IP); err != nil {
+		return nil, err
+	}
+	return s.authService.IssueTokens(user, client)
+}
+
+// Unlock 解除账号的登录锁定
+func (s *UserService) Unlock(userID uint) (*model.User, error) {
+	return s.loginGuard.Unlock(userID)
 }
 
 // GetUserByID 根据ID获取用户信息
@@ -101,7 +170,3 @@ func (s *UserService) UpdatePassword(id uint, req *model.UpdatePasswordRequest)
 func (s *UserService) List(page, size int) ([]model.User, int64, error) {
 	return s.repo.List(page, size)
 }
-
-func (s *UserService) Delete(userID uint) error {
-	return s.repo.Delete(userID)
-}
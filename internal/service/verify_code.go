@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"dididaren/internal/model"
+	"dididaren/internal/repository"
+	"dididaren/pkg/auth"
+	"dididaren/pkg/errors"
+	"dididaren/pkg/logger"
+	"dididaren/pkg/notify"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+)
+
+// 验证码配置项
+const (
+	configVerifyCodeTTL         = "verify_code.ttl"               // 有效期（秒）
+	configVerifyCodeMaxAttempts = "verify_code.max_attempts"      // 单个验证码允许的校验失败次数
+	configVerifyCodeInterval    = "verify_code.send_interval"     // 同一手机号两次发送的最小间隔（秒）
+	configVerifyCodePhoneDaily  = "verify_code.phone_daily_limit" // 同一手机号每天最多发送次数
+	configVerifyCodeIPHourly    = "verify_code.ip_hourly_limit"   // 同一 IP 每小时最多发送次数
+
+	defaultVerifyCodeTTL         = 300
+	defaultVerifyCodeMaxAttempts = 5
+	defaultVerifyCodeInterval    = 60
+	defaultVerifyCodePhoneDaily  = 10
+	defaultVerifyCodeIPHourly    = 20
+
+	// verifyCodeLength 验证码位数
+	verifyCodeLength = 6
+	// verifyCodeRetention 验证码记录的保留时间，需覆盖最长的限流窗口
+	verifyCodeRetention = 24 * time.Hour
+	// verifyCodeSendTimeout 调用短信网关的超时
+	verifyCodeSendTimeout = 10 * time.Second
+)
+
+// phonePattern 中国大陆手机号
+var phonePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// VerifyCodeService 短信验证码：生成、发送、限流和校验。
+// 验证码通过 notify.Notifier 的短信渠道发送，开发环境下只写日志
+type VerifyCodeService struct {
+	repo          *repository.VerifyCodeRepository
+	sender        notify.Notifier
+	configService *SystemConfigService
+	logger        *logger.Logger
+}
+
+func NewVerifyCodeService(
+	repo *repository.VerifyCodeRepository,
+	sender notify.Notifier,
+	configService *SystemConfigService,
+	logger *logger.Logger,
+) *VerifyCodeService {
+	return &VerifyCodeService{
+		repo:          repo,
+		sender:        sender,
+		configService: configService,
+		logger:        logger,
+	}
+}
+
+// Start 按固定间隔在后台清理过期的验证码记录
+func (s *VerifyCodeService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.repo.DeleteCreatedBefore(time.Now().Add(-verifyCodeRetention)); err != nil {
+				s.logger.Error("清理过期验证码失败: %v", err)
+			}
+		}
+	}()
+}
+
+// Send 生成并发送验证码，返回有效期
+func (s *VerifyCodeService) Send(phone, purpose, ip string) (time.Duration, error) {
+	return s.send(phone, purpose, ip, "验证码", func(code string, ttl time.Duration) string {
+		return fmt.Sprintf("您的验证码为 %s，%d 分钟内有效，请勿泄露给他人。", code, int(ttl.Minutes()))
+	})
+}
+
+// SendSilently 与 Send 一样校验发送频率并生成验证码记录，但不发送短信，返回相同的有效期。
+// 用于手机号与用途不符的请求，使响应与正常发送一致，无法据此判断手机号是否已注册
+func (s *VerifyCodeService) SendSilently(phone, purpose, ip string) (time.Duration, error) {
+	return s.send(phone, purpose, ip, "", nil)
+}
+
+// SendContactInvite 向紧急联系人发送邀请短信，联系人同意后将短信中的验证码告知邀请人完成确认
+func (s *VerifyCodeService) SendContactInvite(contactID uint, phone, inviter, ip string) (time.Duration, error) {
+	return s.send(phone, ContactInvitePurpose(contactID), ip, "紧急联系人邀请", func(code string, ttl time.Duration) string {
+		return fmt.Sprintf("%s 希望将您设为紧急联系人，对方遇到危险时您会收到求助通知。如同意，请将验证码 %s 告知对方，%d 分钟内有效；如不认识对方，请忽略本短信。",
+			inviter, code, int(ttl.Minutes()))
+	})
+}
+
+// ContactInvitePurpose 紧急联系人邀请验证码的用途，按联系人区分
+func ContactInvitePurpose(contactID uint) string {
+	return fmt.Sprintf("contact:%d", contactID)
+}
+
+// send 生成验证码并以 body 生成的短信内容发送，body 为空时只生成记录不发送
+func (s *VerifyCodeService) send(phone, purpose, ip, subject string, body func(code string, ttl time.Duration) string) (time.Duration, error) {
+	if !ValidPhone(phone) {
+		return 0, errors.ErrInvalidPhone
+	}
+	if err := s.checkThrottle(phone, ip); err != nil {
+		return 0, err
+	}
+
+	code, err := generateVerifyCode()
+	if err != nil {
+		return 0, err
+	}
+	ttl := s.configService.GetDuration(configVerifyCodeTTL, time.Second, defaultVerifyCodeTTL*time.Second)
+	if err := s.repo.Create(&model.VerificationCode{
+		Phone:     phone,
+		Purpose:   purpose,
+		CodeHash:  hashVerifyCode(phone, purpose, code),
+		IP:        ip,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return 0, err
+	}
+	if body == nil {
+		return ttl, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyCodeSendTimeout)
+	defer cancel()
+	err = s.sender.Send(ctx, &notify.Message{
+		Channel: notify.ChannelSMS,
+		To:      phone,
+		Subject: subject,
+		Body:    body(code, ttl),
+	})
+	if err != nil {
+		s.logger.Error("发送验证码失败: phone=%s err=%v", phone, err)
+		return 0, errors.ErrVerifyCodeSendFailed
+	}
+	return ttl, nil
+}
+
+// Verify 校验验证码，成功后验证码失效。
+// 只校验最近发送的一条，失败次数达到上限后该验证码作废，需要重新获取
+func (s *VerifyCodeService) Verify(phone, purpose, code string) error {
+	latest, err := s.repo.GetLatest(phone, purpose)
+	if err != nil {
+		return err
+	}
+	maxAttempts := s.configService.GetInt(configVerifyCodeMaxAttempts, defaultVerifyCodeMaxAttempts)
+	if latest == nil || latest.UsedAt != nil || time.Now().After(latest.ExpiresAt) || latest.Attempts >= maxAttempts {
+		return errors.ErrVerifyCodeExpired
+	}
+
+	expected := hashVerifyCode(phone, purpose, code)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(latest.CodeHash)) != 1 {
+		if err := s.repo.IncrementAttempts(latest.ID); err != nil {
+			return err
+		}
+		return errors.ErrInvalidVerifyCode
+	}
+
+	ok, err := s.repo.MarkUsed(latest.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.ErrVerifyCodeExpired
+	}
+	return nil
+}
+
+// checkThrottle 按手机号和 IP 限制发送频率
+func (s *VerifyCodeService) checkThrottle(phone, ip string) error {
+	now := time.Now()
+
+	interval := s.configService.GetDuration(configVerifyCodeInterval, time.Second, defaultVerifyCodeInterval*time.Second)
+	recent, err := s.repo.CountByPhoneSince(phone, now.Add(-interval))
+	if err != nil {
+		return err
+	}
+	if recent > 0 {
+		return errors.ErrVerifyCodeTooFrequent
+	}
+
+	daily, err := s.repo.CountByPhoneSince(phone, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if daily >= int64(s.configService.GetInt(configVerifyCodePhoneDaily, defaultVerifyCodePhoneDaily)) {
+		return errors.ErrVerifyCodeTooFrequent
+	}
+
+	if ip != "" {
+		hourly, err := s.repo.CountByIPSince(ip, now.Add(-time.Hour))
+		if err != nil {
+			return err
+		}
+		if hourly >= int64(s.configService.GetInt(configVerifyCodeIPHourly, defaultVerifyCodeIPHourly)) {
+			return errors.ErrVerifyCodeTooFrequent
+		}
+	}
+	return nil
+}
+
+// ValidPhone 判断是否为有效的中国大陆手机号
+func ValidPhone(phone string) bool {
+	return phonePattern.MatchString(phone)
+}
+
+// generateVerifyCode 生成数字验证码
+func generateVerifyCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < verifyCodeLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", verifyCodeLength, n), nil
+}
+
+// hashVerifyCode 计算验证码哈希，混入手机号和用途，使相同验证码的哈希各不相同
+func hashVerifyCode(phone, purpose, code string) string {
+	return auth.HashToken(phone + ":" + purpose + ":" + code)
+}
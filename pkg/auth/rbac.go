@@ -0,0 +1,67 @@
+package auth
+
+// 角色
+const (
+	RoleUser        = "user"         // 普通用户
+	RoleStaff       = "staff"        // 安保人员
+	RoleDispatcher  = "dispatcher"   // 调度员
+	RoleAgencyAdmin = "agency_admin" // 安保机构管理员
+	RoleAdmin       = "admin"        // 系统管理员
+)
+
+// 权限
+const (
+	PermStaffRespond    = "staff:respond"    // 安保人员上报位置、接单和处理事件
+	PermStaffManage     = "staff:manage"     // 管理安保人员
+	PermEmergencyHandle = "emergency:handle" // 记录事件处理过程
+	PermEmergencyManage = "emergency:manage" // 查看全部事件、修改状态和查看派单
+	PermZoneManage      = "zone:manage"      // 管理危险区域
+	PermConfigManage    = "config:manage"    // 管理系统配置
+	PermRoleManage      = "role:manage"      // 授予和撤销角色
+	PermUserManage      = "user:manage"      // 管理用户账号，如解除登录锁定
+	PermSensitiveView   = "sensitive:view"   // 查看完整的手机号和身份证号，没有该权限时返回脱敏后的值
+)
+
+// rolePermissions 各角色拥有的权限，普通用户只能使用不需要额外权限的接口
+var rolePermissions = map[string][]string{
+	RoleUser:  {},
+	RoleStaff: {PermStaffRespond, PermEmergencyHandle},
+	RoleDispatcher: {
+		PermEmergencyHandle, PermEmergencyManage,
+	},
+	RoleAgencyAdmin: {
+		PermStaffManage, PermEmergencyHandle, PermEmergencyManage, PermZoneManage,
+	},
+	RoleAdmin: {
+		PermStaffManage, PermEmergencyHandle, PermEmergencyManage, PermZoneManage,
+		PermConfigManage, PermRoleManage, PermUserManage, PermSensitiveView,
+	},
+}
+
+// ValidRole 判断角色是否存在
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasRole 判断角色列表中是否包含指定角色
+func HasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission 判断角色列表是否拥有指定权限
+func HasPermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
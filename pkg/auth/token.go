@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"dididaren/pkg/config"
+	"dididaren/pkg/errors"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// legacyKID 未带 kid 头的旧 token 使用 JWT.Secret 校验
+const legacyKID = ""
+
+// Claims 访问令牌中的用户信息
+type Claims struct {
+	UserID    uint     `json:"user_id"`
+	Phone     string   `json:"phone"`
+	Roles     []string `json:"roles"`
+	SessionID string   `json:"sid"` // 登录会话ID，同一次登录后刷新得到的令牌共享
+	jwt.StandardClaims
+}
+
+// RevocationList 已吊销的登录会话
+type RevocationList interface {
+	IsRevoked(sessionID string) bool
+}
+
+// TokenManager 签发和校验访问令牌、生成刷新令牌。
+// 支持多把签名密钥：新 token 使用当前密钥签名并在 kid 头中标明，
+// 校验时按 kid 选择密钥，轮换时把旧密钥保留到其签发的 token 全部过期即可
+type TokenManager struct {
+	keys        map[string][]byte
+	activeKID   string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	revocations RevocationList
+}
+
+func NewTokenManager(cfg config.JWTConfig, revocations RevocationList) (*TokenManager, error) {
+	keys := make(map[string][]byte, len(cfg.Keys)+1)
+	for kid, secret := range cfg.Keys {
+		keys[kid] = []byte(secret)
+	}
+	if cfg.Secret != "" {
+		keys[legacyKID] = []byte(cfg.Secret)
+	}
+	if _, ok := keys[cfg.ActiveKID]; !ok {
+		return nil, fmt.Errorf("JWT 签名密钥 %q 未配置", cfg.ActiveKID)
+	}
+	if cfg.AccessTTL <= 0 || cfg.RefreshTTL <= 0 {
+		return nil, fmt.Errorf("JWT 令牌有效期必须大于0")
+	}
+
+	return &TokenManager{
+		keys:        keys,
+		activeKID:   cfg.ActiveKID,
+		accessTTL:   cfg.AccessTTL,
+		refreshTTL:  cfg.RefreshTTL,
+		revocations: revocations,
+	}, nil
+}
+
+// AccessTTL 访问令牌有效期
+func (m *TokenManager) AccessTTL() time.Duration {
+	return m.accessTTL
+}
+
+// IssueAccessToken 签发访问令牌
+func (m *TokenManager) IssueAccessToken(userID uint, phone string, roles []string, sessionID string) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:    userID,
+		Phone:     phone,
+		Roles:     roles,
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			Id:        randomHex(16),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(m.accessTTL).Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if m.activeKID != legacyKID {
+		token.Header["kid"] = m.activeKID
+	}
+	return token.SignedString(m.keys[m.activeKID])
+}
+
+// ParseAccessToken 校验访问令牌的签名、有效期和所属会话是否已吊销
+func (m *TokenManager) ParseAccessToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.ErrInvalidToken
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys[kid]
+		if !ok {
+			return nil, errors.ErrInvalidToken
+		}
+		return key, nil
+	})
+	if err != nil {
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return nil, errors.ErrTokenExpired
+		}
+		return nil, errors.ErrInvalidToken
+	}
+	if !token.Valid {
+		return nil, errors.ErrInvalidToken
+	}
+
+	if claims.SessionID != "" && m.revocations != nil && m.revocations.IsRevoked(claims.SessionID) {
+		return nil, errors.ErrTokenRevoked
+	}
+	// 旧 token 不带角色，只按普通用户处理
+	if len(claims.Roles) == 0 {
+		claims.Roles = []string{RoleUser}
+	}
+	return claims, nil
+}
+
+// NewRefreshToken 生成刷新令牌，返回令牌原文、用于存储的哈希和过期时间
+func (m *TokenManager) NewRefreshToken() (token, hash string, expiresAt time.Time) {
+	token = randomHex(32)
+	return token, HashToken(token), time.Now().Add(m.refreshTTL)
+}
+
+// HashToken 计算令牌的哈希，数据库中只保存哈希
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewSessionID 生成登录会话ID
+func NewSessionID() string {
+	return randomHex(16)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
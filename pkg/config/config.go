@@ -1,43 +1,185 @@
 package config
 
+import "time"
+
+// Config 应用配置。
+// 加载顺序为代码中的默认值、配置文件、环境配置文件、环境变量，后加载的覆盖先加载的；
+// yaml 标签为配置文件中的键，env 标签为对应的环境变量
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Redis      RedisConfig      `yaml:"redis"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	Log        LogConfig        `yaml:"log"`
+	OSS        OSSConfig        `yaml:"oss"`
+	Map        MapConfig        `yaml:"map"`
+	Notify     NotifyConfig     `yaml:"notify"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Encryption EncryptionConfig `yaml:"encryption"`
 }
 
 type ServerConfig struct {
-	Port int
+	Port int    `yaml:"port" env:"SERVER_PORT"`
+	Mode string `yaml:"mode" env:"SERVER_MODE"` // gin 运行模式：debug、release、test
+	// 允许建立 WebSocket 连接的其他网页来源，如 https://admin.example.com，同源页面无需配置
+	AllowedOrigins []string `yaml:"allowed_origins" env:"SERVER_ALLOWED_ORIGINS"`
 }
 
 type DatabaseConfig struct {
-	Driver   string
-	Host     string
-	Port     int
-	Username string
-	Password string
-	Database string
+	Driver       string `yaml:"driver" env:"DB_DRIVER"` // mysql、postgres 或 sqlite
+	Host         string `yaml:"host" env:"DB_HOST"`
+	Port         int    `yaml:"port" env:"DB_PORT"`
+	Username     string `yaml:"username" env:"DB_USER"`
+	Password     string `yaml:"password" env:"DB_PASSWORD"`
+	Database     string `yaml:"dbname" env:"DB_NAME"`     // sqlite 为数据库文件路径，:memory: 表示内存数据库
+	Charset      string `yaml:"charset" env:"DB_CHARSET"` // 只用于 mysql
+	SSLMode      string `yaml:"sslmode" env:"DB_SSLMODE"` // 只用于 postgres
+	MaxIdleConns int    `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	MaxOpenConns int    `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+}
+
+// RedisConfig Redis 连接配置
+type RedisConfig struct {
+	Host     string `yaml:"host" env:"REDIS_HOST"`
+	Port     int    `yaml:"port" env:"REDIS_PORT"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD"`
+	DB       int    `yaml:"db" env:"REDIS_DB"`
 }
 
 type JWTConfig struct {
-	Secret string
+	Secret     string            `yaml:"secret" env:"JWT_SECRET"`           // 旧版单一密钥，只用于校验不带 kid 头的 token
+	Keys       map[string]string `yaml:"keys" env:"JWT_KEYS"`               // 签名密钥，key 为 kid
+	ActiveKID  string            `yaml:"active_kid" env:"JWT_ACTIVE_KID"`   // 签发新 token 使用的密钥
+	Expire     time.Duration     `yaml:"expire" env:"JWT_EXPIRE"`           // 旧版配置项，等同于 access_ttl，只在未设置 access_ttl 时生效
+	AccessTTL  time.Duration     `yaml:"access_ttl" env:"JWT_ACCESS_TTL"`   // 访问令牌有效期
+	RefreshTTL time.Duration     `yaml:"refresh_ttl" env:"JWT_REFRESH_TTL"` // 刷新令牌有效期
+}
+
+// LogConfig 日志配置，filename 为空时只输出到标准输出
+type LogConfig struct {
+	Level      string `yaml:"level" env:"LOG_LEVEL"` // debug、info、warn、error
+	Filename   string `yaml:"filename" env:"LOG_FILENAME"`
+	MaxSize    int    `yaml:"max_size" env:"LOG_MAX_SIZE"`       // 单个日志文件的大小上限（MB），超过后切分
+	MaxAge     int    `yaml:"max_age" env:"LOG_MAX_AGE"`         // 切分出的旧日志保留天数，0 表示不按时间清理
+	MaxBackups int    `yaml:"max_backups" env:"LOG_MAX_BACKUPS"` // 切分出的旧日志保留个数，0 表示不按个数清理
+}
+
+// OSSConfig 阿里云 OSS 配置
+type OSSConfig struct {
+	Endpoint        string `yaml:"endpoint" env:"OSS_ENDPOINT"`
+	AccessKeyID     string `yaml:"access_key_id" env:"OSS_ACCESS_KEY_ID"`
+	AccessKeySecret string `yaml:"access_key_secret" env:"OSS_ACCESS_KEY_SECRET"`
+	BucketName      string `yaml:"bucket_name" env:"OSS_BUCKET_NAME"`
+}
+
+// MapConfig 地图服务配置
+type MapConfig struct {
+	AmapKey string `yaml:"amap_key" env:"AMAP_KEY"` // 高德地图 Web 服务 key
+}
+
+// NotifyConfig 紧急联系人通知配置
+type NotifyConfig struct {
+	Provider     string        `yaml:"provider" env:"NOTIFY_PROVIDER"`         // log：只写日志，用于开发环境；live：使用下面配置的真实渠道
+	Channels     []string      `yaml:"channels" env:"NOTIFY_CHANNELS"`         // 通知紧急联系人使用的渠道：sms、email、webhook
+	MaxAttempts  int           `yaml:"max_attempts" env:"NOTIFY_MAX_ATTEMPTS"` // 每条通知最多发送次数
+	RetryBackoff time.Duration `yaml:"retry_backoff" env:"NOTIFY_RETRY_BACKOFF"`
+	SMS          SMSConfig     `yaml:"sms"`
+	Email        EmailConfig   `yaml:"email"`
+	Webhook      WebhookConfig `yaml:"webhook"`
+}
+
+// SMSConfig 短信网关配置
+type SMSConfig struct {
+	URL    string `yaml:"url" env:"SMS_URL"`
+	APIKey string `yaml:"api_key" env:"SMS_API_KEY"`
+}
+
+// EmailConfig SMTP 配置
+type EmailConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+}
+
+// WebhookConfig webhook 配置
+type WebhookConfig struct {
+	URL    string `yaml:"url" env:"WEBHOOK_URL"`
+	Secret string `yaml:"secret" env:"WEBHOOK_SECRET"`
+}
+
+// StorageConfig 上传文件存储配置
+type StorageConfig struct {
+	Dir string `yaml:"dir" env:"STORAGE_DIR"` // 本地存储目录
+}
+
+// EncryptionConfig 手机号、身份证号等敏感字段的加密配置，密钥为 base64 编码的 32 字节随机数
+type EncryptionConfig struct {
+	Keys      map[string]string `yaml:"keys" env:"ENCRYPTION_KEYS"`             // 加密密钥，key 为 kid，旧密钥保留用于解密历史数据
+	ActiveKID string            `yaml:"active_kid" env:"ENCRYPTION_ACTIVE_KID"` // 加密新数据使用的密钥
+	IndexKey  string            `yaml:"index_key" env:"ENCRYPTION_INDEX_KEY"`   // 计算盲索引的密钥，修改后需要重建所有盲索引
 }
 
-func Load() (*Config, error) {
+// 开发环境使用的默认密钥，release 模式下禁止使用
+const (
+	devJWTSecret     = "your-secret-key"
+	devEncryptionKey = "ZGV2LW9ubHktZGF0YS1lbmNyeXB0aW9uLWtleS0wMDE="
+	devIndexKey      = "ZGV2LW9ubHktYmxpbmQtaW5kZXgta2V5LTAwMDAwMDE="
+)
+
+// defaultAccessTTL 既没有设置 access_ttl 也没有设置旧版 expire 时的访问令牌有效期
+const defaultAccessTTL = 15 * time.Minute
+
+// Default 默认配置，用于开发环境，配置文件中没有设置的项使用这里的值
+func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
 			Port: 8080,
+			Mode: "debug",
 		},
 		Database: DatabaseConfig{
-			Driver:   "mysql",
-			Host:     "localhost",
-			Port:     3306,
-			Username: "root",
-			Password: "123456",
-			Database: "dididaren",
+			Driver:       "mysql",
+			Host:         "localhost",
+			Port:         3306,
+			Username:     "root",
+			Password:     "123456",
+			Database:     "dididaren",
+			Charset:      "utf8mb4",
+			SSLMode:      "disable",
+			MaxIdleConns: 10,
+			MaxOpenConns: 100,
+		},
+		Redis: RedisConfig{
+			Host: "localhost",
+			Port: 6379,
 		},
 		JWT: JWTConfig{
-			Secret: "your-secret-key",
+			Secret: devJWTSecret,
+			Keys: map[string]string{
+				"2024-01": devJWTSecret,
+			},
+			ActiveKID:  "2024-01",
+			RefreshTTL: 30 * 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Notify: NotifyConfig{
+			Provider:     "log",
+			Channels:     []string{"sms", "email"},
+			MaxAttempts:  3,
+			RetryBackoff: 5 * time.Second,
+		},
+		Storage: StorageConfig{
+			Dir: "./data/uploads",
+		},
+		Encryption: EncryptionConfig{
+			Keys: map[string]string{
+				"2024-01": devEncryptionKey,
+			},
+			ActiveKID: "2024-01",
+			IndexKey:  devIndexKey,
 		},
-	}, nil
+	}
 }
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath 未通过参数或 CONFIG_FILE 指定时使用的配置文件
+const DefaultPath = "config/config.yaml"
+
+// Load 加载配置并校验。
+// path 为空时使用环境变量 CONFIG_FILE，再为空时使用 DefaultPath；
+// 设置了环境变量 APP_ENV 时，再加载同目录下的 config.<APP_ENV>.yaml 覆盖其中的配置项，
+// 最后用环境变量覆盖，环境变量名见各字段的 env 标签
+func Load(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		path = DefaultPath
+	}
+
+	cfg := Default()
+	if err := loadFile(cfg, path); err != nil {
+		return nil, err
+	}
+	if env := os.Getenv("APP_ENV"); env != "" {
+		ext := filepath.Ext(path)
+		profile := strings.TrimSuffix(path, ext) + "." + env + ext
+		if err := loadFile(cfg, profile); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	// 旧版配置只有 jwt.expire
+	if cfg.JWT.AccessTTL == 0 {
+		cfg.JWT.AccessTTL = cfg.JWT.Expire
+	}
+	if cfg.JWT.AccessTTL == 0 {
+		cfg.JWT.AccessTTL = defaultAccessTTL
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 读取配置文件覆盖 cfg 中已有的值。
+// 文件中出现的 map 类型配置项（如 jwt.keys）整体替换而不是合并，避免环境配置中残留默认密钥
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("配置文件 %s 不存在，可以通过 -config 参数或 CONFIG_FILE 环境变量指定", path)
+		}
+		return fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	resetMaps(reflect.ValueOf(cfg).Elem(), doc.Content[0])
+	if err := doc.Content[0].Decode(cfg); err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// resetMaps 清空 node 中出现的 map 类型字段
+func resetMaps(v reflect.Value, node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		field, ok := fieldByYAMLKey(v, node.Content[i].Value)
+		if !ok {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Map:
+			field.Set(reflect.Zero(field.Type()))
+		case reflect.Struct:
+			resetMaps(field, node.Content[i+1])
+		}
+	}
+}
+
+func fieldByYAMLKey(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// applyEnv 用环境变量覆盖带 env 标签的字段。
+// 列表用逗号分隔，如 sms,email；map 用逗号分隔多项、冒号分隔键和值，如 2024-01:secret1,2024-02:secret2
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Duration(0)) {
+			if err := applyEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("环境变量 %s 的值无效: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		m := make(map[string]string)
+		for _, item := range strings.Split(value, ",") {
+			key, val, ok := strings.Cut(strings.TrimSpace(item), ":")
+			if !ok || key == "" {
+				return fmt.Errorf("%q 不是 key:value 格式", item)
+			}
+			m[key] = val
+		}
+		field.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("不支持的配置类型 %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyEnvMaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantJWT map[string]string
+		wantEnc map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "未设置时保留默认值",
+			wantJWT: map[string]string{"2024-01": devJWTSecret},
+			wantEnc: map[string]string{"2024-01": devEncryptionKey},
+		},
+		{
+			name:    "整体替换默认值",
+			env:     map[string]string{"JWT_KEYS": "2024-06:secret"},
+			wantJWT: map[string]string{"2024-06": "secret"},
+			wantEnc: map[string]string{"2024-01": devEncryptionKey},
+		},
+		{
+			name:    "多个键值对，忽略两侧空白",
+			env:     map[string]string{"JWT_KEYS": "2024-01:old, 2024-06:new"},
+			wantJWT: map[string]string{"2024-01": "old", "2024-06": "new"},
+			wantEnc: map[string]string{"2024-01": devEncryptionKey},
+		},
+		{
+			name:    "值中的冒号属于值",
+			env:     map[string]string{"JWT_KEYS": "k1:a:b"},
+			wantJWT: map[string]string{"k1": "a:b"},
+			wantEnc: map[string]string{"2024-01": devEncryptionKey},
+		},
+		{
+			name:    "base64 密钥",
+			env:     map[string]string{"ENCRYPTION_KEYS": "2024-01:" + devEncryptionKey + ",2024-06:" + devIndexKey},
+			wantJWT: map[string]string{"2024-01": devJWTSecret},
+			wantEnc: map[string]string{"2024-01": devEncryptionKey, "2024-06": devIndexKey},
+		},
+		{
+			name:    "缺少冒号",
+			env:     map[string]string{"JWT_KEYS": "secret"},
+			wantErr: true,
+		},
+		{
+			name:    "键为空",
+			env:     map[string]string{"ENCRYPTION_KEYS": ":secret"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg := Default()
+			err := applyEnv(reflect.ValueOf(cfg).Elem())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(cfg.JWT.Keys, tt.wantJWT) {
+				t.Errorf("JWT.Keys = %v, want %v", cfg.JWT.Keys, tt.wantJWT)
+			}
+			if !reflect.DeepEqual(cfg.Encryption.Keys, tt.wantEnc) {
+				t.Errorf("Encryption.Keys = %v, want %v", cfg.Encryption.Keys, tt.wantEnc)
+			}
+		})
+	}
+}
+
+func TestLoadMapOverrides(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, `
+jwt:
+  keys:
+    file-1: file-secret
+  active_kid: file-1
+`)
+	writeFile(t, filepath.Join(dir, "config.test.yaml"), `
+jwt:
+  keys:
+    profile-1: profile-secret
+  active_kid: profile-1
+`)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want map[string]string
+	}{
+		{"配置文件替换默认值", nil, map[string]string{"file-1": "file-secret"}},
+		{"环境配置替换配置文件", map[string]string{"APP_ENV": "test"}, map[string]string{"profile-1": "profile-secret"}},
+		{
+			"环境变量优先于配置文件",
+			map[string]string{"APP_ENV": "test", "JWT_KEYS": "env-1:env-secret", "JWT_ACTIVE_KID": "env-1"},
+			map[string]string{"env-1": "env-secret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_ENV", "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg.JWT.Keys, tt.want) {
+				t.Errorf("JWT.Keys = %v, want %v", cfg.JWT.Keys, tt.want)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Validate 校验配置，一次返回所有问题
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		add("server.port 必须在 1-65535 之间，当前为 %d", c.Server.Port)
+	}
+	if !oneOf(c.Server.Mode, "debug", "release", "test") {
+		add("server.mode 必须为 debug、release 或 test，当前为 %q", c.Server.Mode)
+	}
+
+	switch c.Database.Driver {
+	case "mysql", "postgres":
+		if c.Database.Host == "" {
+			add("database.host 不能为空")
+		}
+		if c.Database.Port <= 0 || c.Database.Port > 65535 {
+			add("database.port 必须在 1-65535 之间，当前为 %d", c.Database.Port)
+		}
+		if c.Database.Username == "" {
+			add("database.username 不能为空")
+		}
+		if c.Database.Driver == "mysql" && c.Database.Charset == "" {
+			add("database.charset 不能为空")
+		}
+		if c.Database.Driver == "postgres" && !oneOf(c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full") {
+			add("database.sslmode 不是有效的 postgres sslmode，当前为 %q", c.Database.SSLMode)
+		}
+	case "sqlite":
+	default:
+		add("database.driver 必须为 mysql、postgres 或 sqlite，当前为 %q", c.Database.Driver)
+	}
+	if c.Database.Database == "" {
+		add("database.dbname 不能为空")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxOpenConns < 0 {
+		add("database.max_idle_conns 和 database.max_open_conns 不能为负数")
+	}
+
+	if len(c.JWT.Keys) == 0 {
+		add("jwt.keys 至少需要配置一个签名密钥")
+	}
+	for _, kid := range sortedKeys(c.JWT.Keys) {
+		if c.JWT.Keys[kid] == "" {
+			add("jwt.keys.%s 不能为空", kid)
+		}
+	}
+	if _, ok := c.JWT.Keys[c.JWT.ActiveKID]; !ok {
+		add("jwt.active_kid %q 不在 jwt.keys 中", c.JWT.ActiveKID)
+	}
+	if c.JWT.AccessTTL <= 0 {
+		add("jwt.access_ttl 必须大于 0")
+	}
+	if c.JWT.RefreshTTL <= c.JWT.AccessTTL {
+		add("jwt.refresh_ttl 必须大于 jwt.access_ttl")
+	}
+
+	if !oneOf(c.Log.Level, "debug", "info", "warn", "error") {
+		add("log.level 必须为 debug、info、warn 或 error，当前为 %q", c.Log.Level)
+	}
+	if c.Log.MaxSize < 0 || c.Log.MaxAge < 0 || c.Log.MaxBackups < 0 {
+		add("log.max_size、log.max_age 和 log.max_backups 不能为负数")
+	}
+
+	if !oneOf(c.Notify.Provider, "log", "live") {
+		add("notify.provider 必须为 log 或 live，当前为 %q", c.Notify.Provider)
+	}
+	for _, channel := range c.Notify.Channels {
+		if !oneOf(channel, "sms", "email", "webhook") {
+			add("notify.channels 中的 %q 不是有效的渠道，可选 sms、email、webhook", channel)
+			continue
+		}
+		if c.Notify.Provider != "live" {
+			continue
+		}
+		switch {
+		case channel == "sms" && c.Notify.SMS.URL == "":
+			add("notify.provider 为 live 且启用了 sms 渠道时 notify.sms.url 不能为空")
+		case channel == "email" && (c.Notify.Email.Host == "" || c.Notify.Email.From == ""):
+			add("notify.provider 为 live 且启用了 email 渠道时 notify.email.host 和 notify.email.from 不能为空")
+		case channel == "webhook" && c.Notify.Webhook.URL == "":
+			add("notify.provider 为 live 且启用了 webhook 渠道时 notify.webhook.url 不能为空")
+		}
+	}
+	if c.Notify.MaxAttempts < 1 {
+		add("notify.max_attempts 至少为 1")
+	}
+
+	if c.Storage.Dir == "" {
+		add("storage.dir 不能为空")
+	}
+
+	if len(c.Encryption.Keys) == 0 {
+		add("encryption.keys 至少需要配置一个加密密钥")
+	}
+	for _, kid := range sortedKeys(c.Encryption.Keys) {
+		if strings.Contains(kid, ":") {
+			add("encryption.keys 的 kid %q 不能包含冒号", kid)
+		}
+		if !validKey(c.Encryption.Keys[kid]) {
+			add("encryption.keys.%s 必须是 base64 编码的 32 字节密钥", kid)
+		}
+	}
+	if _, ok := c.Encryption.Keys[c.Encryption.ActiveKID]; !ok {
+		add("encryption.active_kid %q 不在 encryption.keys 中", c.Encryption.ActiveKID)
+	}
+	if !validKey(c.Encryption.IndexKey) {
+		add("encryption.index_key 必须是 base64 编码的 32 字节密钥")
+	}
+
+	// 生产环境不允许使用代码和示例配置中的开发密钥
+	if c.Server.Mode == "release" {
+		if c.JWT.Secret == devJWTSecret || c.JWT.Keys[c.JWT.ActiveKID] == devJWTSecret {
+			add("server.mode 为 release 时不能使用默认的 JWT 密钥")
+		}
+		if c.Encryption.Keys[c.Encryption.ActiveKID] == devEncryptionKey || c.Encryption.IndexKey == devIndexKey {
+			add("server.mode 为 release 时不能使用默认的数据加密密钥")
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("配置校验失败:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+func oneOf(value string, options ...string) bool {
+	for _, option := range options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func validKey(encoded string) bool {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	return err == nil && len(key) == 32
+}
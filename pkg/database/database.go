@@ -1,42 +1,106 @@
 package database
 
 import (
-	"dididaren/internal/model"
 	"dididaren/pkg/config"
+	"dididaren/pkg/sensitive"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// sqliteMemory sqlite 内存数据库的文件名
+const sqliteMemory = ":memory:"
+
+// Init 配置敏感字段加密并连接数据库
 func Init(cfg *config.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.Database.Username,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Database,
-	)
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	cipher, err := sensitive.New(cfg.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("加载数据加密密钥失败: %v", err)
+	}
+	sensitive.Init(cipher)
+
+	dialector, err := openDialector(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		return nil, fmt.Errorf("连接数据库失败: %v", err)
 	}
 
-	// 自动迁移数据库表结构
-	err = db.AutoMigrate(
-		&model.User{},
-		&model.Staff{},
-		&model.Emergency{},
-		&model.EmergencyContact{},
-		&model.HandlingRecord{},
-		&model.DangerZone{},
-		&model.Rating{},
-		&model.SystemConfig{},
-	)
+	sqlDB, err := db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("迁移数据库失败: %v", err)
+		return nil, err
+	}
+	if cfg.Database.Driver == "sqlite" {
+		// sqlite 同一时间只允许一个写入者，多个连接并发写入会返回 database is locked；
+		// 内存数据库在最后一个连接关闭时销毁，因此固定使用一个不过期的连接
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	} else {
+		sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+		sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
 	}
 
 	return db, nil
 }
+
+// InMemory 是否为内存数据库。内存数据库随进程退出销毁，无法由 cmd/migrate 预先建表，
+// 需要在服务启动时执行迁移
+func InMemory(cfg config.DatabaseConfig) bool {
+	return cfg.Driver == "sqlite" && cfg.Database == sqliteMemory
+}
+
+// openDialector 根据 database.driver 生成 GORM 方言
+func openDialector(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+			cfg.Username,
+			cfg.Password,
+			cfg.Host,
+			cfg.Port,
+			cfg.Database,
+			cfg.Charset,
+		)
+		return mysql.Open(dsn), nil
+	case "postgres":
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Host,
+			cfg.Port,
+			cfg.Username,
+			quoteDSNValue(cfg.Password),
+			cfg.Database,
+			cfg.SSLMode,
+		)
+		return postgres.Open(dsn), nil
+	case "sqlite":
+		// 外键约束默认关闭，与其他数据库保持一致需要显式开启；busy_timeout 避免其他进程写入时立即失败
+		pragmas := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+		if cfg.Database == sqliteMemory {
+			return sqlite.Open("file::memory:?" + pragmas), nil
+		}
+		if dir := filepath.Dir(cfg.Database); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, fmt.Errorf("创建 sqlite 数据库目录失败: %v", err)
+			}
+		}
+		return sqlite.Open("file:" + cfg.Database + "?" + pragmas + "&_pragma=journal_mode(WAL)"), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %q", cfg.Driver)
+	}
+}
+
+// quoteDSNValue 为 postgres DSN 中可能含空格或引号的值加引号
+func quoteDSNValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
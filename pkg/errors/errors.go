@@ -7,6 +7,9 @@ var (
 	ErrUserExists             = errors.New("用户已存在")
 	ErrInvalidPassword        = errors.New("密码错误")
 	ErrInvalidToken           = errors.New("无效的token")
+	ErrTokenExpired           = errors.New("token已过期")
+	ErrTokenRevoked           = errors.New("登录已失效，请重新登录")
+	ErrInvalidRefreshToken    = errors.New("无效的刷新令牌")
 	ErrEventNotFound          = errors.New("事件不存在")
 	ErrEventStatus            = errors.New("事件状态错误")
 	ErrStaffNotFound          = errors.New("安保人员不存在")
@@ -21,6 +24,35 @@ var (
 	ErrConfigNotFound         = errors.New("配置不存在")
 	ErrConfigExists           = errors.New("配置已存在")
 	ErrInvalidConfig          = errors.New("无效的配置")
+	ErrConfigVersionNotFound  = errors.New("配置版本不存在")
 	ErrPhoneAlreadyRegistered = errors.New("手机号已注册")
 	ErrInvalidCredentials     = errors.New("手机号或密码错误")
+	ErrOfferNotFound          = errors.New("派单不存在或已失效")
+	ErrStaffNotApproved       = errors.New("安保人员未通过审核")
+	ErrLocationAlertsDisabled = errors.New("未开启危险区域提醒")
+	ErrPermissionDenied       = errors.New("没有权限执行该操作")
+	ErrInvalidRole            = errors.New("无效的角色")
+	ErrRoleNotGrantable       = errors.New("该角色由系统自动授予，不能手动修改")
+	ErrInvalidPhone           = errors.New("无效的手机号")
+	ErrInvalidVerifyCode      = errors.New("验证码错误")
+	ErrVerifyCodeExpired      = errors.New("验证码已失效，请重新获取")
+	ErrVerifyCodeTooFrequent  = errors.New("验证码发送过于频繁，请稍后再试")
+	ErrVerifyCodeSendFailed   = errors.New("验证码发送失败，请稍后再试")
+	ErrAccountLocked          = errors.New("登录失败次数过多，账号已被临时锁定")
+	ErrLoginTooFrequent       = errors.New("登录尝试过于频繁")
+	ErrAccountBusy            = errors.New("存在进行中的紧急事件，暂时无法注销账号")
+	ErrSessionNotFound        = errors.New("登录会话不存在")
+	ErrContactNotFound        = errors.New("紧急联系人不存在")
+	ErrContactExists          = errors.New("该手机号已是紧急联系人")
+	ErrContactLimit           = errors.New("紧急联系人数量已达上限")
+	ErrContactIsSelf          = errors.New("不能将自己设为紧急联系人")
+	ErrContactVerified        = errors.New("紧急联系人已确认")
+	ErrStaffStatusChanged     = errors.New("安保人员状态已变化，请刷新后重试")
+	ErrInvalidStaffStatus     = errors.New("不允许的安保人员状态变更")
+	ErrStaffDocumentsMissing  = errors.New("申请材料不完整")
+	ErrStaffDocumentNotFound  = errors.New("申请材料不存在")
+	ErrRatingNotFound         = errors.New("评价不存在")
+	ErrInvalidStaffDocument   = errors.New("申请材料只支持 JPG、PNG 或 PDF 文件，且不超过 10MB")
+	ErrStaffApplicationClosed = errors.New("当前状态不能修改申请")
+	ErrStaffUnderReview       = errors.New("申请正在审核中")
 )
@@ -0,0 +1,30 @@
+package geo
+
+import "math"
+
+// EarthRadius 地球平均半径（米）
+const EarthRadius = 6371000.0
+
+// Distance 使用 haversine 公式计算两点间的球面距离（米）
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoundingBox 返回以指定点为中心、半径为 radius（米）的经纬度外接矩形
+func BoundingBox(lat, lng, radius float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := radius / EarthRadius * 180 / math.Pi
+	dLng := dLat
+	if cos := math.Cos(toRadians(lat)); cos > 1e-6 {
+		dLng = dLat / cos
+	}
+	return lat - dLat, lat + dLat, lng - dLng, lng + dLng
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
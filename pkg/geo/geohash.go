@@ -0,0 +1,113 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashEncode 计算指定精度（字符数）的 geohash
+func GeohashEncode(lat, lng float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLng, maxLng := -180.0, 180.0
+
+	var sb strings.Builder
+	bit, ch := 0, 0
+	even := true
+	for sb.Len() < precision {
+		if even {
+			mid := (minLng + maxLng) / 2
+			if lng >= mid {
+				ch |= 1 << (4 - bit)
+				minLng = mid
+			} else {
+				maxLng = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				minLat = mid
+			} else {
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		if bit < 4 {
+			bit++
+		} else {
+			sb.WriteByte(geohashBase32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// GeohashCellSize 返回指定精度下单个 geohash 格子的纬度、经度跨度（度）
+func GeohashCellSize(precision int) (latSpan, lngSpan float64) {
+	bits := precision * 5
+	lngBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lngBits))
+}
+
+// GeohashCover 返回覆盖经纬度矩形的所有 geohash 格子，格子数超过 limit 时返回 nil
+func GeohashCover(minLat, maxLat, minLng, maxLng float64, precision, limit int) []string {
+	latSpan, lngSpan := GeohashCellSize(precision)
+	minLat, maxLat = math.Max(minLat, -90), math.Min(maxLat, 90)
+	minLng, maxLng = math.Max(minLng, -180), math.Min(maxLng, 180)
+
+	rows := int(math.Ceil((maxLat-minLat)/latSpan)) + 1
+	cols := int(math.Ceil((maxLng-minLng)/lngSpan)) + 1
+	if rows*cols > limit {
+		return nil
+	}
+
+	seen := make(map[string]bool, rows*cols)
+	cells := make([]string, 0, rows*cols)
+	for i := 0; i < rows; i++ {
+		lat := math.Min(minLat+float64(i)*latSpan, maxLat)
+		for j := 0; j < cols; j++ {
+			lng := math.Min(minLng+float64(j)*lngSpan, maxLng)
+			cell := GeohashEncode(lat, lng, precision)
+			if !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
+
+// GeohashBounds 返回 geohash 格子的经纬度范围
+func GeohashBounds(hash string) (minLat, maxLat, minLng, maxLng float64) {
+	minLat, maxLat = -90.0, 90.0
+	minLng, maxLng = -180.0, 180.0
+
+	even := true
+	for i := 0; i < len(hash); i++ {
+		ch := strings.IndexByte(geohashBase32, hash[i])
+		for bit := 4; bit >= 0; bit-- {
+			set := ch>>uint(bit)&1 == 1
+			if even {
+				mid := (minLng + maxLng) / 2
+				if set {
+					minLng = mid
+				} else {
+					maxLng = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if set {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return minLat, maxLat, minLng, maxLng
+}
@@ -0,0 +1,68 @@
+package geo
+
+import (
+	"encoding/json"
+)
+
+// GeoJSON 类型
+const (
+	TypeFeatureCollection = "FeatureCollection"
+	TypeFeature           = "Feature"
+	TypePoint             = "Point"
+	TypeLineString        = "LineString"
+)
+
+// Geometry GeoJSON 几何对象，坐标顺序为 [经度, 纬度]
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// Feature GeoJSON 要素
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// FeatureCollection GeoJSON 要素集合
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// NewFeatureCollection 创建要素集合
+func NewFeatureCollection(features ...*Feature) *FeatureCollection {
+	if features == nil {
+		features = []*Feature{}
+	}
+	return &FeatureCollection{Type: TypeFeatureCollection, Features: features}
+}
+
+// NewFeature 创建要素
+func NewFeature(geometry *Geometry, properties map[string]interface{}) *Feature {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return &Feature{Type: TypeFeature, Geometry: geometry, Properties: properties}
+}
+
+// NewPoint 创建点
+func NewPoint(lat, lng float64) *Geometry {
+	return newGeometry(TypePoint, [2]float64{lng, lat})
+}
+
+// NewLineString 创建折线，points 中每个元素为 [纬度, 经度]
+func NewLineString(points [][2]float64) *Geometry {
+	coordinates := make([][2]float64, len(points))
+	for i, p := range points {
+		coordinates[i] = [2]float64{p[1], p[0]}
+	}
+	return newGeometry(TypeLineString, coordinates)
+}
+
+func newGeometry(geometryType string, coordinates interface{}) *Geometry {
+	raw, _ := json.Marshal(coordinates)
+	return &Geometry{Type: geometryType, Coordinates: raw}
+}
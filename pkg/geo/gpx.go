@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// GPX GPX 1.1 文档
+type GPX struct {
+	XMLName xml.Name   `xml:"gpx"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	Version string     `xml:"version,attr"`
+	Creator string     `xml:"creator,attr"`
+	Tracks  []GPXTrack `xml:"trk"`
+}
+
+// GPXTrack 轨迹
+type GPXTrack struct {
+	Name     string       `xml:"name,omitempty"`
+	Segments []GPXSegment `xml:"trkseg"`
+}
+
+// GPXSegment 轨迹段
+type GPXSegment struct {
+	Points []GPXPoint `xml:"trkpt"`
+}
+
+// GPXPoint 轨迹点
+type GPXPoint struct {
+	Lat  float64   `xml:"lat,attr"`
+	Lon  float64   `xml:"lon,attr"`
+	Time time.Time `xml:"time"`
+}
+
+// NewGPX 创建只包含一条轨迹的 GPX 文档
+func NewGPX(creator, name string, points []GPXPoint) *GPX {
+	for i := range points {
+		points[i].Time = points[i].Time.UTC()
+	}
+	return &GPX{
+		Xmlns:   "http://www.topografix.com/GPX/1/1",
+		Version: "1.1",
+		Creator: creator,
+		Tracks: []GPXTrack{{
+			Name:     name,
+			Segments: []GPXSegment{{Points: points}},
+		}},
+	}
+}
+
+// Marshal 输出带 XML 声明的 GPX 文档
+func (g *GPX) Marshal() ([]byte, error) {
+	data, err := xml.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,144 @@
+package geo
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// maxCoverCells 单次查询最多枚举的 geohash 格子数，超过后直接遍历全部对象
+const maxCoverCells = 4096
+
+// Item 索引中的对象
+type Item struct {
+	ID     uint
+	Lat    float64
+	Lng    float64
+	Extent float64 // 对象自身的覆盖半径（米），点对象为0
+}
+
+// Match 查询结果
+type Match struct {
+	Item
+	Distance float64 // 查询点到对象中心的距离（米）
+}
+
+// Index 基于 geohash 分格的内存空间索引，查询时用 haversine 精确过滤并按距离排序
+type Index struct {
+	mu        sync.RWMutex
+	precision int
+	items     map[uint]Item
+	cells     map[string]map[uint]struct{}
+	cellOf    map[uint]string
+	maxExtent float64 // 删除对象时不回收，Reset 时重新计算
+}
+
+// NewIndex 创建索引，precision 为 geohash 精度
+func NewIndex(precision int) *Index {
+	return &Index{
+		precision: precision,
+		items:     make(map[uint]Item),
+		cells:     make(map[string]map[uint]struct{}),
+		cellOf:    make(map[uint]string),
+	}
+}
+
+// Reset 用给定对象重建索引
+func (idx *Index) Reset(items []Item) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.items = make(map[uint]Item, len(items))
+	idx.cells = make(map[string]map[uint]struct{})
+	idx.cellOf = make(map[uint]string, len(items))
+	idx.maxExtent = 0
+	for _, item := range items {
+		idx.insert(item)
+	}
+}
+
+// Upsert 新增或更新对象
+func (idx *Index) Upsert(item Item) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(item.ID)
+	idx.insert(item)
+}
+
+// Remove 删除对象
+func (idx *Index) Remove(id uint) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+}
+
+// Len 返回索引中的对象数
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.items)
+}
+
+// Nearby 查找与以 (lat, lng) 为圆心、radius 为半径的圆相交的对象，按距离由近到远排序。
+// 点对象要求距离不超过 radius，带覆盖半径的对象要求距离不超过 radius 加其覆盖半径
+func (idx *Index) Nearby(lat, lng, radius float64) []Match {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// 搜索范围需放大到最大覆盖半径，才能找到中心在范围外但覆盖到查询点的对象
+	minLat, maxLat, minLng, maxLng := BoundingBox(lat, lng, radius+idx.maxExtent)
+
+	var candidates []uint
+	if cells := GeohashCover(minLat, maxLat, minLng, maxLng, idx.precision, maxCoverCells); cells != nil {
+		for _, cell := range cells {
+			for id := range idx.cells[cell] {
+				candidates = append(candidates, id)
+			}
+		}
+	} else {
+		for id := range idx.items {
+			candidates = append(candidates, id)
+		}
+	}
+
+	matches := make([]Match, 0, len(candidates))
+	for _, id := range candidates {
+		item := idx.items[id]
+		distance := Distance(lat, lng, item.Lat, item.Lng)
+		if distance <= radius+item.Extent {
+			matches = append(matches, Match{Item: item, Distance: distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Distance < matches[j].Distance
+	})
+	return matches
+}
+
+func (idx *Index) insert(item Item) {
+	cell := GeohashEncode(item.Lat, item.Lng, idx.precision)
+	if idx.cells[cell] == nil {
+		idx.cells[cell] = make(map[uint]struct{})
+	}
+	idx.cells[cell][item.ID] = struct{}{}
+	idx.cellOf[item.ID] = cell
+	idx.items[item.ID] = item
+	idx.maxExtent = math.Max(idx.maxExtent, item.Extent)
+}
+
+func (idx *Index) remove(id uint) {
+	cell, ok := idx.cellOf[id]
+	if !ok {
+		return
+	}
+	delete(idx.cells[cell], id)
+	if len(idx.cells[cell]) == 0 {
+		delete(idx.cells, cell)
+	}
+	delete(idx.cellOf, id)
+	delete(idx.items, id)
+}
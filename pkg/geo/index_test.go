@@ -0,0 +1,79 @@
+package geo
+
+import "testing"
+
+func TestIndexNearby(t *testing.T) {
+	// 以天安门为查询点，纬度每 0.001 度约 111 米
+	const lat, lng = 39.9087, 116.3975
+
+	idx := NewIndex(6)
+	idx.Reset([]Item{
+		{ID: 1, Lat: lat + 0.001, Lng: lng},              // 约111米
+		{ID: 2, Lat: lat + 0.004, Lng: lng},              // 约445米
+		{ID: 3, Lat: lat - 0.009, Lng: lng},              // 约1000米
+		{ID: 4, Lat: lat + 0.05, Lng: lng},               // 约5.6公里
+		{ID: 5, Lat: lat - 0.02, Lng: lng, Extent: 2000}, // 中心约2.2公里，覆盖半径2公里
+		{ID: 6, Lat: lat, Lng: lng + 0.3},                // 约25.6公里
+	})
+
+	tests := []struct {
+		name   string
+		radius float64
+		want   []uint
+	}{
+		{"只返回半径内的点", 200, []uint{1}},
+		{"覆盖范围与查询圆相交的对象", 500, []uint{1, 2, 5}},
+		{"按到中心的距离排序", 2300, []uint{1, 2, 3, 5}},
+		{"大半径", 10000, []uint{1, 2, 3, 5, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := idx.Nearby(lat, lng, tt.radius)
+			var got []uint
+			for _, m := range matches {
+				got = append(got, m.ID)
+			}
+			if !equalIDs(got, tt.want) {
+				t.Errorf("Nearby(radius=%v) = %v, want %v", tt.radius, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexUpsertRemove(t *testing.T) {
+	const lat, lng = 39.9087, 116.3975
+
+	idx := NewIndex(6)
+	idx.Upsert(Item{ID: 1, Lat: lat, Lng: lng})
+	idx.Upsert(Item{ID: 2, Lat: lat, Lng: lng})
+
+	// 移动到 5 公里外后不再出现在原位置附近
+	idx.Upsert(Item{ID: 1, Lat: lat + 0.045, Lng: lng})
+	if got := idx.Nearby(lat, lng, 100); len(got) != 1 || got[0].ID != 2 {
+		t.Fatalf("移动后 Nearby = %v, want [2]", got)
+	}
+	if got := idx.Nearby(lat+0.045, lng, 100); len(got) != 1 || got[0].ID != 1 {
+		t.Fatalf("移动后新位置 Nearby = %v, want [1]", got)
+	}
+
+	idx.Remove(2)
+	idx.Remove(3)
+	if got := idx.Nearby(lat, lng, 100); len(got) != 0 {
+		t.Fatalf("删除后 Nearby = %v, want []", got)
+	}
+	if idx.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", idx.Len())
+	}
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+// GeoJSON 面类型
+const (
+	TypePolygon      = "Polygon"
+	TypeMultiPolygon = "MultiPolygon"
+)
+
+// ErrInvalidPolygon 面几何对象不合法
+var ErrInvalidPolygon = errors.New("无效的多边形")
+
+// Ring 线性环，坐标顺序为 [经度, 纬度]，首尾点相同
+type Ring [][2]float64
+
+// Polygon 多边形，第一个环为外边界，其余为内部的洞
+type Polygon []Ring
+
+// MultiPolygon 多个多边形组成的区域
+type MultiPolygon []Polygon
+
+// ParseMultiPolygon 解析 Polygon 或 MultiPolygon 几何对象，统一转换为 MultiPolygon
+func ParseMultiPolygon(geometry *Geometry) (MultiPolygon, error) {
+	if geometry == nil {
+		return nil, ErrInvalidPolygon
+	}
+
+	var shape MultiPolygon
+	switch geometry.Type {
+	case TypePolygon:
+		var polygon Polygon
+		if err := json.Unmarshal(geometry.Coordinates, &polygon); err != nil {
+			return nil, ErrInvalidPolygon
+		}
+		shape = MultiPolygon{polygon}
+	case TypeMultiPolygon:
+		if err := json.Unmarshal(geometry.Coordinates, &shape); err != nil {
+			return nil, ErrInvalidPolygon
+		}
+	default:
+		return nil, ErrInvalidPolygon
+	}
+
+	if err := shape.validate(); err != nil {
+		return nil, err
+	}
+	return shape, nil
+}
+
+// Geometry 转换为 GeoJSON 几何对象，只有一个多边形时输出 Polygon
+func (m MultiPolygon) Geometry() *Geometry {
+	if len(m) == 1 {
+		return newGeometry(TypePolygon, m[0])
+	}
+	return newGeometry(TypeMultiPolygon, m)
+}
+
+// Contains 判断点是否位于区域内，边界上的点视为在区域内
+func (m MultiPolygon) Contains(lat, lng float64) bool {
+	for _, polygon := range m {
+		if polygon.Contains(lat, lng) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bounds 返回区域的经纬度外接矩形
+func (m MultiPolygon) Bounds() (minLat, maxLat, minLng, maxLng float64) {
+	minLat, minLng = math.Inf(1), math.Inf(1)
+	maxLat, maxLng = math.Inf(-1), math.Inf(-1)
+	for _, polygon := range m {
+		for _, p := range polygon[0] {
+			minLng, maxLng = math.Min(minLng, p[0]), math.Max(maxLng, p[0])
+			minLat, maxLat = math.Min(minLat, p[1]), math.Max(maxLat, p[1])
+		}
+	}
+	return minLat, maxLat, minLng, maxLng
+}
+
+// Circumcircle 返回覆盖整个区域的圆：圆心为外接矩形中心，半径（米）为圆心到最远顶点的距离
+func (m MultiPolygon) Circumcircle() (lat, lng, radius float64) {
+	minLat, maxLat, minLng, maxLng := m.Bounds()
+	lat, lng = (minLat+maxLat)/2, (minLng+maxLng)/2
+	for _, polygon := range m {
+		for _, p := range polygon[0] {
+			radius = math.Max(radius, Distance(lat, lng, p[1], p[0]))
+		}
+	}
+	return lat, lng, radius
+}
+
+// Contains 判断点是否位于多边形内（在外边界内且不在任何洞内）
+func (p Polygon) Contains(lat, lng float64) bool {
+	if !p[0].contains(lat, lng) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.contains(lat, lng) && !hole.onBoundary(lat, lng) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains 射线法判断点是否位于环内，边界上的点视为在环内
+func (r Ring) contains(lat, lng float64) bool {
+	if r.onBoundary(lat, lng) {
+		return true
+	}
+
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// onBoundary 判断点是否位于环的边上
+func (r Ring) onBoundary(lat, lng float64) bool {
+	const epsilon = 1e-12
+	for i := 0; i+1 < len(r); i++ {
+		x1, y1 := r[i][0], r[i][1]
+		x2, y2 := r[i+1][0], r[i+1][1]
+		cross := (x2-x1)*(lat-y1) - (y2-y1)*(lng-x1)
+		if math.Abs(cross) > epsilon {
+			continue
+		}
+		if lng >= math.Min(x1, x2) && lng <= math.Max(x1, x2) &&
+			lat >= math.Min(y1, y2) && lat <= math.Max(y1, y2) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate 校验每个环至少有4个点、首尾闭合且坐标合法
+func (m MultiPolygon) validate() error {
+	if len(m) == 0 {
+		return ErrInvalidPolygon
+	}
+	for _, polygon := range m {
+		if len(polygon) == 0 {
+			return ErrInvalidPolygon
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+				return ErrInvalidPolygon
+			}
+			for _, p := range ring {
+				if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+					return ErrInvalidPolygon
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package geo
+
+import "testing"
+
+// square 经度 116.0~116.1、纬度 39.9~40.0 的正方形
+var square = Ring{{116.0, 39.9}, {116.1, 39.9}, {116.1, 40.0}, {116.0, 40.0}, {116.0, 39.9}}
+
+// hole 正方形中央经度 116.04~116.06、纬度 39.94~39.96 的洞
+var hole = Ring{{116.04, 39.94}, {116.06, 39.94}, {116.06, 39.96}, {116.04, 39.96}, {116.04, 39.94}}
+
+func TestRingContains(t *testing.T) {
+	// 凹多边形：正方形右侧切掉一个三角形缺口
+	concave := Ring{{116.0, 39.9}, {116.1, 39.9}, {116.05, 39.95}, {116.1, 40.0}, {116.0, 40.0}, {116.0, 39.9}}
+
+	tests := []struct {
+		name string
+		ring Ring
+		lat  float64
+		lng  float64
+		want bool
+	}{
+		{"内部", square, 39.95, 116.05, true},
+		{"外部", square, 39.95, 116.2, false},
+		{"外部且与边等高", square, 40.0, 116.2, false},
+		{"下边上", square, 39.9, 116.05, true},
+		{"左边上", square, 39.95, 116.0, true},
+		{"顶点", square, 40.0, 116.1, true},
+		{"边的延长线上", square, 39.9, 116.2, false},
+		{"凹多边形内部", concave, 39.95, 116.02, true},
+		{"凹多边形缺口内", concave, 39.95, 116.08, false},
+		{"凹多边形缺口边上", concave, 39.925, 116.075, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ring.contains(tt.lat, tt.lng); got != tt.want {
+				t.Errorf("contains(%v, %v) = %v, want %v", tt.lat, tt.lng, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolygonContainsHole(t *testing.T) {
+	polygon := Polygon{square, hole}
+
+	tests := []struct {
+		name string
+		lat  float64
+		lng  float64
+		want bool
+	}{
+		{"外边界与洞之间", 39.92, 116.02, true},
+		{"洞内", 39.95, 116.05, false},
+		{"洞的边上", 39.94, 116.05, true},
+		{"洞的顶点", 39.96, 116.06, true},
+		{"外边界上", 40.0, 116.05, true},
+		{"外部", 39.85, 116.05, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := polygon.Contains(tt.lat, tt.lng); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.lng, got, tt.want)
+			}
+		})
+	}
+}
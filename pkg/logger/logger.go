@@ -1,38 +1,64 @@
 package logger
 
 import (
+	"dididaren/pkg/config"
 	"fmt"
+	"io"
+	"os"
 	"time"
 )
 
 type Logger struct {
 	Level string
+	out   io.Writer
 }
 
 func NewLogger(level string) *Logger {
 	return &Logger{
 		Level: level,
+		out:   os.Stdout,
 	}
 }
 
+// New 根据配置创建日志，配置了 filename 时同时写入标准输出和按大小切分的日志文件
+func New(cfg config.LogConfig) (*Logger, error) {
+	l := NewLogger(cfg.Level)
+	if cfg.Filename != "" {
+		file, err := newRotateWriter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		l.out = io.MultiWriter(os.Stdout, file)
+	}
+	return l, nil
+}
+
 func (l *Logger) Info(format string, args ...interface{}) {
 	if l.Level == "debug" || l.Level == "info" {
-		fmt.Printf("[INFO] %s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+		l.write("INFO", format, args...)
 	}
 }
 
 func (l *Logger) Error(format string, args ...interface{}) {
-	fmt.Printf("[ERROR] %s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+	l.write("ERROR", format, args...)
 }
 
 func (l *Logger) Debug(format string, args ...interface{}) {
 	if l.Level == "debug" {
-		fmt.Printf("[DEBUG] %s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+		l.write("DEBUG", format, args...)
 	}
 }
 
 func (l *Logger) Warn(format string, args ...interface{}) {
 	if l.Level == "debug" || l.Level == "info" || l.Level == "warn" {
-		fmt.Printf("[WARN] %s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+		l.write("WARN", format, args...)
+	}
+}
+
+func (l *Logger) write(level, format string, args ...interface{}) {
+	out := l.out
+	if out == nil {
+		out = os.Stdout
 	}
+	fmt.Fprintf(out, "[%s] %s %s\n", level, time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
 }
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"dididaren/pkg/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 切分出的旧日志文件名中的时间，如 app-20240102T150405.000.log
+const backupTimeFormat = "20060102T150405.000"
+
+// rotateWriter 写入日志文件，超过大小上限时将当前文件改名为带时间的旧日志，
+// 并按保留天数和个数清理旧日志
+type rotateWriter struct {
+	mu         sync.Mutex
+	filename   string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotateWriter(cfg config.LogConfig) (*rotateWriter, error) {
+	w := &rotateWriter{
+		filename:   cfg.Filename,
+		maxSize:    int64(cfg.MaxSize) * 1024 * 1024,
+		maxAge:     time.Duration(cfg.MaxAge) * 24 * time.Hour,
+		maxBackups: cfg.MaxBackups,
+	}
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotateWriter) open() error {
+	file, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotateWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(w.filename)
+	prefix := strings.TrimSuffix(w.filename, ext) + "-"
+	if err := os.Rename(w.filename, prefix+time.Now().Format(backupTimeFormat)+ext); err != nil {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+	w.cleanup(prefix, ext)
+	return nil
+}
+
+// cleanup 清理超过保留天数或个数的旧日志，清理失败不影响写入
+func (w *rotateWriter) cleanup(prefix, ext string) {
+	if w.maxAge <= 0 && w.maxBackups <= 0 {
+		return
+	}
+	backups, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return
+	}
+	// 文件名中的时间格式固定，按名称倒序即从新到旧
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	cutoff := time.Now().Add(-w.maxAge)
+	for i, backup := range backups {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(backup, prefix), ext)
+		rotatedAt, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		if (w.maxBackups > 0 && i >= w.maxBackups) || (w.maxAge > 0 && rotatedAt.Before(cutoff)) {
+			os.Remove(backup)
+		}
+	}
+}
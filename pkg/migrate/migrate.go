@@ -0,0 +1,184 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrIrreversible 迁移没有提供回滚
+var ErrIrreversible = errors.New("该迁移不可回滚")
+
+// Migration 一个版本的数据库变更。
+// Up 和 Down 在同一个事务中执行并记录版本，但 MySQL 的 DDL 会隐式提交，
+// 因此迁移应当可以在中途失败后重复执行，例如先用 HasTable/HasColumn 检查
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // 为空表示不可回滚
+}
+
+// Status 迁移的执行状态
+type Status struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"` // 未执行时为空
+	Unknown   bool       `json:"unknown"`    // 数据库中有记录但当前代码中没有该迁移
+}
+
+// record 已执行的迁移
+type record struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:100;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (record) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator 按版本号顺序执行和回滚迁移，执行记录保存在 schema_migrations 表
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New 创建 Migrator，版本号重复时返回错误
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return nil, fmt.Errorf("迁移版本号重复: %d", sorted[i].Version)
+		}
+	}
+	return &Migrator{db: db, migrations: sorted}, nil
+}
+
+// Up 执行所有未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up() ([]Migration, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&record{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("执行迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down 按版本号从新到旧回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[uint]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var done []Migration
+	for i := len(applied) - 1; i >= 0 && len(done) < steps; i-- {
+		migration, ok := byVersion[applied[i].Version]
+		if !ok {
+			return done, fmt.Errorf("迁移 %d_%s 不在当前代码中，无法回滚", applied[i].Version, applied[i].Name)
+		}
+		if migration.Down == nil {
+			return done, fmt.Errorf("回滚迁移 %d_%s 失败: %w", migration.Version, migration.Name, ErrIrreversible)
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&record{}, migration.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("回滚迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Pending 获取未执行的迁移
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[uint]bool, len(applied))
+	for _, r := range applied {
+		done[r.Version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Status 获取所有迁移的执行状态，按版本号排列
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[uint]record, len(applied))
+	for _, r := range applied {
+		byVersion[r.Version] = r
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if r, ok := byVersion[migration.Version]; ok {
+			appliedAt := r.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(byVersion, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, r := range byVersion {
+		appliedAt := r.AppliedAt
+		statuses = append(statuses, Status{Version: r.Version, Name: r.Name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// applied 获取已执行的迁移记录，按版本号排列，首次使用时创建记录表
+func (m *Migrator) applied() ([]record, error) {
+	if err := m.db.AutoMigrate(&record{}); err != nil {
+		return nil, err
+	}
+	var records []record
+	if err := m.db.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SMSNotifier 通过 HTTP 短信网关发送短信，请求体为 {"phone": "...", "content": "..."}
+type SMSNotifier struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func NewSMSNotifier(url, apiKey string) *SMSNotifier {
+	return &SMSNotifier{url: url, apiKey: apiKey, client: http.DefaultClient}
+}
+
+// Send 实现 Notifier
+func (n *SMSNotifier) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(map[string]string{
+		"phone":   msg.To,
+		"content": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+	return post(ctx, n.client, n.url, body, map[string]string{
+		"Authorization": "Bearer " + n.apiKey,
+	})
+}
+
+// WebhookNotifier 把通知以 JSON 推送到业务方的 webhook，
+// 请求头 X-Signature 为请求体的 HMAC-SHA256 签名，供接收方校验来源
+type WebhookNotifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url, secret string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, secret: secret, client: http.DefaultClient}
+}
+
+// Send 实现 Notifier
+func (n *WebhookNotifier) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(n.secret))
+	mac.Write(body)
+	return post(ctx, n.client, n.url, body, map[string]string{
+		"X-Signature": hex.EncodeToString(mac.Sum(nil)),
+	})
+}
+
+// post 发送 JSON 请求，非 2xx 响应视为失败
+func post(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("通知发送失败，状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package notify
+
+import (
+	"context"
+	"dididaren/pkg/logger"
+)
+
+// LogNotifier 只把通知写入日志，用于本地开发和测试环境
+type LogNotifier struct {
+	logger *logger.Logger
+}
+
+func NewLogNotifier(logger *logger.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+// Send 实现 Notifier
+func (n *LogNotifier) Send(ctx context.Context, msg *Message) error {
+	n.logger.Info("[notify:%s] to=%s subject=%s body=%s", msg.Channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
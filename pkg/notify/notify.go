@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"dididaren/pkg/config"
+	"dididaren/pkg/logger"
+	"errors"
+)
+
+// 通知渠道
+const (
+	ChannelSMS     = "sms"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// ErrUnsupportedChannel 未配置该渠道的发送实现
+var ErrUnsupportedChannel = errors.New("不支持的通知渠道")
+
+// Message 一条待发送的通知
+type Message struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"` // 接收方：短信和 webhook 为手机号，邮件为邮箱地址
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier 通知发送接口，返回错误时由调用方决定是否重试
+type Notifier interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Router 按渠道把消息转给对应的 Notifier
+type Router map[string]Notifier
+
+// Send 实现 Notifier
+func (r Router) Send(ctx context.Context, msg *Message) error {
+	notifier, ok := r[msg.Channel]
+	if !ok {
+		return ErrUnsupportedChannel
+	}
+	return notifier.Send(ctx, msg)
+}
+
+// New 根据配置创建 Notifier：provider 为 live 时使用配置的真实渠道，否则只写日志
+func New(cfg config.NotifyConfig, logger *logger.Logger) Notifier {
+	if cfg.Provider != "live" {
+		return NewLogNotifier(logger)
+	}
+
+	router := Router{}
+	if cfg.SMS.URL != "" {
+		router[ChannelSMS] = NewSMSNotifier(cfg.SMS.URL, cfg.SMS.APIKey)
+	}
+	if cfg.Email.Host != "" {
+		router[ChannelEmail] = NewEmailNotifier(cfg.Email.Host, cfg.Email.Port, cfg.Email.Username, cfg.Email.Password, cfg.Email.From)
+	}
+	if cfg.Webhook.URL != "" {
+		router[ChannelWebhook] = NewWebhookNotifier(cfg.Webhook.URL, cfg.Webhook.Secret)
+	}
+	return router
+}
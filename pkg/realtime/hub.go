@@ -0,0 +1,123 @@
+package realtime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 推送事件类型
+const (
+	EventStatusChanged  = "status_changed"  // 紧急事件状态变更
+	EventHandlingRecord = "handling_record" // 新增处理记录
+	EventStaffLocation  = "staff_location"  // 接单安保人员的实时位置
+	EventDispatchOffer  = "dispatch_offer"  // 安保人员收到新派单
+	EventOfferClosed    = "offer_closed"    // 派单已被他人接单、超时或取消
+	EventGeofenceAlert  = "geofence_alert"  // 用户进入危险区域
+)
+
+// subscriptionBuffer 每个订阅的缓冲大小，消费过慢时丢弃新事件而不是阻塞发布方
+const subscriptionBuffer = 64
+
+// Event 推送给客户端的事件
+type Event struct {
+	Topic string      `json:"topic"`
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data"`
+	Time  time.Time   `json:"time"`
+}
+
+// EmergencyTopic 紧急事件的推送主题，报警人和接单安保人员订阅
+func EmergencyTopic(emergencyID uint) string {
+	return fmt.Sprintf("emergency:%d", emergencyID)
+}
+
+// UserTopic 用户个人的推送主题，安保人员通过它接收派单
+func UserTopic(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// Subscription 一个客户端连接的订阅
+type Subscription struct {
+	ch     chan Event
+	topics []string
+	hub    *Hub
+	once   sync.Once
+}
+
+// Events 返回接收事件的通道，订阅关闭后通道被关闭
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.unsubscribe(s)
+	})
+}
+
+// Hub 进程内的发布订阅中心，不依赖外部消息队列，适用于单节点部署
+type Hub struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		topics: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe 订阅一个或多个主题
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	sub := &Subscription{
+		ch:     make(chan Event, subscriptionBuffer),
+		topics: topics,
+		hub:    h,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range topics {
+		if h.topics[topic] == nil {
+			h.topics[topic] = make(map[*Subscription]struct{})
+		}
+		h.topics[topic][sub] = struct{}{}
+	}
+	return sub
+}
+
+// Publish 向主题发布事件，不会阻塞
+func (h *Hub) Publish(topic, eventType string, data interface{}) {
+	event := Event{
+		Topic: topic,
+		Type:  eventType,
+		Data:  data,
+		Time:  time.Now(),
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.topics[topic] {
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+func (h *Hub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range sub.topics {
+		delete(h.topics[topic], sub)
+		if len(h.topics[topic]) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+	close(sub.ch)
+}
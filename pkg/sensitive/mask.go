@@ -0,0 +1,51 @@
+package sensitive
+
+import (
+	"regexp"
+	"strings"
+)
+
+// phonePattern 文本中的手机号
+var phonePattern = regexp.MustCompile(`1[3-9]\d{9}`)
+
+// Mask 保留前 keepStart 个和后 keepEnd 个字符，其余替换为 *，
+// 长度不足时全部替换
+func Mask(value string, keepStart, keepEnd int) string {
+	runes := []rune(value)
+	if len(runes) <= keepStart+keepEnd {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keepStart]) + strings.Repeat("*", len(runes)-keepStart-keepEnd) + string(runes[len(runes)-keepEnd:])
+}
+
+// MaskPhone 手机号脱敏，如 138****1234
+func MaskPhone(phone string) string {
+	return Mask(phone, 3, 4)
+}
+
+// MaskIDCard 身份证号脱敏，如 110***********1234
+func MaskIDCard(idCard string) string {
+	return Mask(idCard, 3, 4)
+}
+
+// MaskEmail 邮箱脱敏，保留用户名首字符和域名，如 z***@example.com
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return Mask(email, 1, 0)
+	}
+	return Mask(email[:at], 1, 0) + email[at:]
+}
+
+// MaskContact 联系方式脱敏，包含 @ 的按邮箱处理，其余按手机号处理
+func MaskContact(value string) string {
+	if strings.Contains(value, "@") {
+		return MaskEmail(value)
+	}
+	return MaskPhone(value)
+}
+
+// MaskPhonesIn 将文本中出现的手机号全部脱敏
+func MaskPhonesIn(text string) string {
+	return phonePattern.ReplaceAllStringFunc(text, MaskPhone)
+}
@@ -0,0 +1,223 @@
+package sensitive
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"dididaren/pkg/config"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName 加密字段使用的 GORM 序列化器名称，字段标签写作 serializer:encrypted
+const SerializerName = "encrypted"
+
+// prefix 密文前缀，完整格式为 enc:<kid>:<base64(nonce+密文)>，不带前缀的值按明文处理
+const prefix = "enc:"
+
+var (
+	ErrNotConfigured = errors.New("未配置数据加密密钥")
+	ErrUnknownKey    = errors.New("未知的数据加密密钥")
+	ErrInvalidCipher = errors.New("无效的密文")
+)
+
+// Cipher 敏感字段加密和盲索引。
+// 加密使用 AES-256-GCM，每次加密使用随机 nonce，相同明文的密文不同，
+// 因此按值查询需要使用 BlindIndex 计算的 HMAC-SHA256 盲索引
+type Cipher struct {
+	aeads     map[string]cipher.AEAD
+	activeKID string
+	indexKey  []byte
+}
+
+// New 根据配置创建 Cipher，密钥为 base64 编码的 32 字节随机数
+func New(cfg config.EncryptionConfig) (*Cipher, error) {
+	c := &Cipher{aeads: make(map[string]cipher.AEAD, len(cfg.Keys)), activeKID: cfg.ActiveKID}
+	for kid, encoded := range cfg.Keys {
+		if kid == "" || strings.Contains(kid, ":") {
+			return nil, fmt.Errorf("无效的数据加密密钥ID: %q", kid)
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("数据加密密钥 %s: %w", kid, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		c.aeads[kid] = aead
+	}
+	if _, ok := c.aeads[cfg.ActiveKID]; !ok {
+		return nil, fmt.Errorf("数据加密密钥 %s 不存在", cfg.ActiveKID)
+	}
+
+	indexKey, err := decodeKey(cfg.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("盲索引密钥: %w", err)
+	}
+	c.indexKey = indexKey
+	return c, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("密钥不是有效的 base64")
+	}
+	if len(key) != 32 {
+		return nil, errors.New("密钥长度必须为 32 字节")
+	}
+	return key, nil
+}
+
+// Encrypt 使用当前密钥加密，空字符串不加密
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead := c.aeads[c.activeKID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + c.activeKID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 的结果，不带密文前缀的值视为加密前写入的明文原样返回
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	kid, encoded, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", ErrInvalidCipher
+	}
+	aead, ok := c.aeads[kid]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCipher
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidCipher
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex 计算用于等值查询的盲索引，空字符串返回空字符串
+func (c *Cipher) BlindIndex(plaintext string) string {
+	if plaintext == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(plaintext))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsEncrypted 判断值是否为 Encrypt 生成的密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+var (
+	mu      sync.RWMutex
+	current *Cipher
+)
+
+// Init 设置模型字段加密使用的 Cipher，须在访问数据库之前调用
+func Init(c *Cipher) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = c
+}
+
+// Current 获取 Init 设置的 Cipher
+func Current() (*Cipher, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if current == nil {
+		return nil, ErrNotConfigured
+	}
+	return current, nil
+}
+
+// BlindIndex 使用 Init 设置的 Cipher 计算盲索引，空字符串返回空字符串
+func BlindIndex(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	c, err := Current()
+	if err != nil {
+		return "", err
+	}
+	return c.BlindIndex(plaintext), nil
+}
+
+func init() {
+	schema.RegisterSerializer(SerializerName, serializer{})
+}
+
+// serializer 读取时解密、写入时加密字符串字段
+type serializer struct{}
+
+func (serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("字段 %s 的类型不支持加密: %T", field.Name, dbValue)
+	}
+
+	if value != "" {
+		c, err := Current()
+		if err != nil {
+			return err
+		}
+		if value, err = c.Decrypt(value); err != nil {
+			return fmt.Errorf("解密字段 %s 失败: %w", field.Name, err)
+		}
+	}
+	return field.Set(ctx, dst, value)
+}
+
+func (serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("字段 %s 的类型不支持加密: %T", field.Name, fieldValue)
+	}
+	if plaintext == "" {
+		return "", nil
+	}
+	c, err := Current()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := c.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return ciphertext, nil
+}
@@ -0,0 +1,187 @@
+package sensitive
+
+import (
+	"crypto/rand"
+	"dididaren/pkg/config"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func newCipher(t *testing.T, cfg config.EncryptionConfig) *Cipher {
+	t.Helper()
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// tamper 修改密文的最后一个字符
+func tamper(ciphertext string) string {
+	last := "A"
+	if strings.HasSuffix(ciphertext, last) {
+		last = "B"
+	}
+	return ciphertext[:len(ciphertext)-1] + last
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	c := newCipher(t, config.EncryptionConfig{
+		ActiveKID: "k1",
+		Keys:      map[string]string{"k1": newKey(t)},
+		IndexKey:  newKey(t),
+	})
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{"手机号", "13800138000"},
+		{"身份证号", "11010519491231002X"},
+		{"中文", "北京市东城区东长安街"},
+		{"空字符串", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := c.Encrypt(tt.plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.plaintext == "" {
+				if ciphertext != "" {
+					t.Fatalf("Encrypt(\"\") = %q, want \"\"", ciphertext)
+				}
+				return
+			}
+			if !strings.HasPrefix(ciphertext, "enc:k1:") {
+				t.Fatalf("Encrypt() = %q, want prefix enc:k1:", ciphertext)
+			}
+			if strings.Contains(ciphertext, tt.plaintext) {
+				t.Fatalf("Encrypt() = %q contains plaintext", ciphertext)
+			}
+
+			got, err := c.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.plaintext {
+				t.Errorf("Decrypt() = %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptRandomNonce(t *testing.T) {
+	c := newCipher(t, config.EncryptionConfig{
+		ActiveKID: "k1",
+		Keys:      map[string]string{"k1": newKey(t)},
+		IndexKey:  newKey(t),
+	})
+
+	a, _ := c.Encrypt("13800138000")
+	b, _ := c.Encrypt("13800138000")
+	if a == b {
+		t.Fatal("相同明文两次加密的密文相同")
+	}
+	if c.BlindIndex("13800138000") != c.BlindIndex("13800138000") {
+		t.Fatal("相同明文的盲索引不同")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	k1, k2, indexKey := newKey(t), newKey(t), newKey(t)
+	before := newCipher(t, config.EncryptionConfig{
+		ActiveKID: "k1",
+		Keys:      map[string]string{"k1": k1},
+		IndexKey:  indexKey,
+	})
+	// 轮换：新增 k2 作为当前密钥，保留 k1 用于解密旧数据
+	after := newCipher(t, config.EncryptionConfig{
+		ActiveKID: "k2",
+		Keys:      map[string]string{"k1": k1, "k2": k2},
+		IndexKey:  indexKey,
+	})
+	// 旧密钥下线后无法再解密 k1 的密文
+	retired := newCipher(t, config.EncryptionConfig{
+		ActiveKID: "k2",
+		Keys:      map[string]string{"k2": k2},
+		IndexKey:  indexKey,
+	})
+
+	const plaintext = "13800138000"
+	old, err := before.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := after.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rotated, "enc:k2:") {
+		t.Fatalf("轮换后 Encrypt() = %q, want prefix enc:k2:", rotated)
+	}
+
+	tests := []struct {
+		name       string
+		cipher     *Cipher
+		ciphertext string
+		want       string
+		wantErr    error
+	}{
+		{"新密钥解密旧密文", after, old, plaintext, nil},
+		{"新密钥解密新密文", after, rotated, plaintext, nil},
+		{"旧密钥无法解密新密文", before, rotated, "", ErrUnknownKey},
+		{"下线旧密钥后无法解密旧密文", retired, old, "", ErrUnknownKey},
+		{"明文原样返回", after, plaintext, plaintext, nil},
+		{"密文被篡改", after, tamper(rotated), "", ErrInvalidCipher},
+		{"缺少密钥ID", after, "enc:" + strings.TrimPrefix(rotated, "enc:k2:"), "", ErrInvalidCipher},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cipher.Decrypt(tt.ciphertext)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// 盲索引只依赖盲索引密钥，轮换加密密钥后按手机号查询不受影响
+	if before.BlindIndex(plaintext) != after.BlindIndex(plaintext) {
+		t.Error("轮换加密密钥后盲索引发生变化")
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	key := newKey(t)
+	tests := []struct {
+		name string
+		cfg  config.EncryptionConfig
+	}{
+		{"当前密钥不存在", config.EncryptionConfig{ActiveKID: "k2", Keys: map[string]string{"k1": key}, IndexKey: key}},
+		{"密钥ID包含冒号", config.EncryptionConfig{ActiveKID: "k:1", Keys: map[string]string{"k:1": key}, IndexKey: key}},
+		{"密钥不是 base64", config.EncryptionConfig{ActiveKID: "k1", Keys: map[string]string{"k1": "not base64"}, IndexKey: key}},
+		{"密钥长度不足", config.EncryptionConfig{ActiveKID: "k1", Keys: map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte("short"))}, IndexKey: key}},
+		{"缺少盲索引密钥", config.EncryptionConfig{ActiveKID: "k1", Keys: map[string]string{"k1": key}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg); err == nil {
+				t.Error("New() error = nil, want error")
+			}
+		})
+	}
+}